@@ -0,0 +1,78 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types for Group resources
+const (
+	// GroupCreatedCondition indicates whether the group has been successfully created in the user pool
+	GroupCreatedCondition = "GroupCreated"
+)
+
+// GroupSpec defines the desired state of Group.
+// The group is created in the user pool under the name of the Group resource.
+type GroupSpec struct {
+	// Description is the group's description in the user pool
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Precedence decides which group takes priority when a user belongs to several groups.
+	// Lower values take precedence.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Precedence *int32 `json:"precedence,omitempty"`
+}
+
+// GroupStatus defines the observed state of Group.
+type GroupStatus struct {
+	// LastSyncTime is the timestamp of the last successful sync with the user pool
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// ObservedGeneration is the last generation that was acted upon
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the current service state of the Group
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// Group is the Schema for the groups API.
+type Group struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GroupSpec   `json:"spec,omitempty"`
+	Status GroupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GroupList contains a list of Group.
+type GroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Group `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Group{}, &GroupList{})
+}
@@ -26,6 +26,70 @@ const (
 	UserCreatedCondition = "UserCreated"
 	// UserSyncedCondition indicates whether the user is successfully synced with the user pool
 	UserSyncedCondition = "UserSynced"
+	// UserDriftedCondition indicates whether the last drift check found the user pool diverging from the spec
+	UserDriftedCondition = "Drifted"
+)
+
+// Password states reported in UserStatus.PasswordStatus
+const (
+	// PasswordStatusTemporary means a temporary password was set and must be changed at first sign-in
+	PasswordStatusTemporary = "Temporary"
+	// PasswordStatusConsumed means the user signed in and replaced the temporary password
+	PasswordStatusConsumed = "Consumed"
+	// PasswordStatusPermanent means a permanent password was set
+	PasswordStatusPermanent = "Permanent"
+)
+
+// DeliveryMedium is a channel through which the user pool sends invitations
+// +kubebuilder:validation:Enum=EMAIL;SMS
+type DeliveryMedium string
+
+// Supported invitation delivery mediums
+const (
+	DeliveryMediumEmail DeliveryMedium = "EMAIL"
+	DeliveryMediumSMS   DeliveryMedium = "SMS"
+)
+
+// DeletionPolicy decides what happens to the user pool identity when a User is deleted
+// +kubebuilder:validation:Enum=Delete;Disable;Retain
+type DeletionPolicy string
+
+// Supported deletion policies
+const (
+	// DeletionPolicyDelete deletes the user from the user pool
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyDisable disables the user in the user pool and keeps the identity
+	DeletionPolicyDisable DeletionPolicy = "Disable"
+	// DeletionPolicyRetain leaves the user in the user pool untouched
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+)
+
+// AdoptionPolicy decides whether a User may take over an identity that already exists in the user pool
+// +kubebuilder:validation:Enum=Never;IfUnowned;Always
+type AdoptionPolicy string
+
+// Supported adoption policies
+const (
+	// AdoptionPolicyNever refuses to take over existing identities
+	AdoptionPolicyNever AdoptionPolicy = "Never"
+	// AdoptionPolicyIfUnowned takes over existing identities that carry no ownership marker
+	AdoptionPolicyIfUnowned AdoptionPolicy = "IfUnowned"
+	// AdoptionPolicyAlways takes over existing identities, even when they are marked by another User
+	// that no longer exists
+	AdoptionPolicyAlways AdoptionPolicy = "Always"
+)
+
+// IdentitySharing decides whether Users of different workspaces may share the identity of an email
+// +kubebuilder:validation:Enum=Exclusive;Shared
+type IdentitySharing string
+
+// Supported identity sharing modes
+const (
+	// IdentitySharingExclusive gives the User an identity of its own
+	IdentitySharingExclusive IdentitySharing = "Exclusive"
+	// IdentitySharingShared maps all shared Users with the same email to one identity, which is only
+	// deleted when the last of them is
+	IdentitySharingShared IdentitySharing = "Shared"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -36,8 +100,109 @@ type UserSpec struct {
 	// Email is the user's email address
 	Email string `json:"email,omitempty"`
 
-	// Enabled indicates whether the user is enabled
-	Enabled bool `json:"enabled,omitempty"`
+	// Enabled indicates whether the user is enabled. Users are enabled unless this is set to false.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// GivenName is the user's given name (given_name claim)
+	// +optional
+	GivenName string `json:"givenName,omitempty"`
+
+	// FamilyName is the user's family name (family_name claim)
+	// +optional
+	FamilyName string `json:"familyName,omitempty"`
+
+	// PhoneNumber is the user's phone number in E.164 format (phone_number claim)
+	// +kubebuilder:validation:Pattern=`^\+[1-9][0-9]{1,14}$`
+	// +optional
+	PhoneNumber string `json:"phoneNumber,omitempty"`
+
+	// Locale is the user's locale, e.g. en-US (locale claim)
+	// +optional
+	Locale string `json:"locale,omitempty"`
+
+	// PreferredUsername is the user's preferred username (preferred_username claim)
+	// +optional
+	PreferredUsername string `json:"preferredUsername,omitempty"`
+
+	// CustomAttributes are custom user pool attributes keyed by name without the
+	// "custom:" prefix. The attributes must be declared in the user pool schema.
+	// +optional
+	CustomAttributes map[string]string `json:"customAttributes,omitempty"`
+
+	// Groups lists the user pool groups the user is a member of.
+	// When set, membership is converged to exactly this list.
+	// +listType=set
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// PasswordSecretRef references a Secret in the User's namespace holding the user's
+	// initial password. The password is applied whenever the Secret changes.
+	// +optional
+	PasswordSecretRef *PasswordSecretReference `json:"passwordSecretRef,omitempty"`
+
+	// DeletionPolicy decides what happens to the user pool identity when the User is deleted.
+	// Defaults to the controller-wide policy, which is Delete unless configured otherwise.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// AdoptionPolicy decides whether the User may take over an identity with the same email that
+	// already exists in the user pool. Identities owned by this User are always reclaimed.
+	// Defaults to Never.
+	// +optional
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+
+	// IdentitySharing decides whether the identity of the email is shared with Users of other
+	// workspaces. Shared identities combine the attributes, enabled state and groups of all Users
+	// sharing them and require email claims to be enabled. Defaults to Exclusive.
+	// +optional
+	IdentitySharing IdentitySharing `json:"identitySharing,omitempty"`
+
+	// Invitation controls the invitation message sent by the user pool when the user is created.
+	// When unset, no invitation is sent.
+	// +optional
+	Invitation *InvitationSpec `json:"invitation,omitempty"`
+}
+
+// InvitationSpec defines how user pool invitations are delivered to the user.
+type InvitationSpec struct {
+	// DeliveryMediums lists the channels the invitation is sent through
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	DeliveryMediums []DeliveryMedium `json:"deliveryMediums"`
+
+	// ResendToken triggers a new invitation whenever it is changed to a value
+	// that has not been handled yet, e.g. a timestamp
+	// +optional
+	ResendToken string `json:"resendToken,omitempty"`
+}
+
+// IsEnabled reports whether the user should be enabled in the user pool.
+// An unset Enabled field means enabled.
+func (s *UserSpec) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// IsShared reports whether the identity of the user is shared with Users of other workspaces
+func (s *UserSpec) IsShared() bool {
+	return s.IdentitySharing == IdentitySharingShared
+}
+
+// PasswordSecretReference selects the key of a Secret holding a password.
+type PasswordSecretReference struct {
+	// Name is the name of the Secret in the User's namespace
+	Name string `json:"name"`
+
+	// Key is the key in the Secret holding the password
+	// +kubebuilder:default=password
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Permanent sets the password as permanent instead of requiring the user
+	// to change it at first sign-in
+	// +optional
+	Permanent bool `json:"permanent,omitempty"`
 }
 
 // UserStatus defines the observed state of User.
@@ -45,9 +210,57 @@ type UserStatus struct {
 	// Sub is the user's unique identifier (subject) in the user pool
 	Sub string `json:"sub,omitempty"`
 
+	// UserPool identifies the user pool holding the identity and the email claim of the user, as
+	// region/ID for Cognito. It is empty for backends with a single user pool.
+	UserPool string `json:"userPool,omitempty"`
+
 	// UserPoolStatus represents the current status of the user in the user pool
 	UserPoolStatus string `json:"userPoolStatus,omitempty"`
 
+	// UserPoolCreatedTime is when the user was created in the user pool
+	UserPoolCreatedTime *metav1.Time `json:"userPoolCreatedTime,omitempty"`
+
+	// UserPoolLastModifiedTime is when the user was last modified in the user pool
+	UserPoolLastModifiedTime *metav1.Time `json:"userPoolLastModifiedTime,omitempty"`
+
+	// MFAEnabled reports whether the user has at least one MFA method enabled
+	MFAEnabled bool `json:"mfaEnabled,omitempty"`
+
+	// MFAMethods lists the MFA methods enabled for the user, e.g. SMS_MFA or SOFTWARE_TOKEN_MFA
+	MFAMethods []string `json:"mfaMethods,omitempty"`
+
+	// Groups lists the user pool groups the user was last synced into
+	Groups []string `json:"groups,omitempty"`
+
+	// ManagedAttributes lists the user pool attributes last applied from the spec. Only these are
+	// removed from the user pool when they are dropped from the spec.
+	ManagedAttributes []string `json:"managedAttributes,omitempty"`
+
+	// PasswordStatus reports the state of the password applied from passwordSecretRef:
+	// Temporary, Consumed or Permanent
+	PasswordStatus string `json:"passwordStatus,omitempty"`
+
+	// PasswordSecretVersion is the resourceVersion of the password Secret last applied
+	PasswordSecretVersion string `json:"passwordSecretVersion,omitempty"`
+
+	// InvitationSentTime is the timestamp of the last invitation sent to the user
+	InvitationSentTime *metav1.Time `json:"invitationSentTime,omitempty"`
+
+	// TemporaryPasswordExpirationTime is when the temporary password from the last invitation expires
+	TemporaryPasswordExpirationTime *metav1.Time `json:"temporaryPasswordExpirationTime,omitempty"`
+
+	// InvitationResendToken is the last invitation resendToken that was handled
+	InvitationResendToken string `json:"invitationResendToken,omitempty"`
+
+	// ClaimedEmail is the email claimed for this User among the workspaces sharing its user pool.
+	// The claim is released when the User is deleted or its email changes.
+	ClaimedEmail string `json:"claimedEmail,omitempty"`
+
+	// References is the number of Users sharing the identity, including this one. It is only set
+	// for shared identities.
+	// +optional
+	References int32 `json:"references,omitempty"`
+
 	// LastSyncTime is the timestamp of the last successful sync with the user pool
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 
@@ -60,6 +273,12 @@ type UserStatus struct {
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Email",type=string,JSONPath=`.spec.email`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.userPoolStatus`
+// +kubebuilder:printcolumn:name="MFA",type=boolean,JSONPath=`.status.mfaEnabled`
+// +kubebuilder:printcolumn:name="Created",type=date,JSONPath=`.status.userPoolCreatedTime`,priority=1
+// +kubebuilder:printcolumn:name="Modified",type=date,JSONPath=`.status.userPoolLastModifiedTime`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // User is the Schema for the users API.
 type User struct {
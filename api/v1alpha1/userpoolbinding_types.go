@@ -0,0 +1,125 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UserPoolBindingName is the name of the only UserPoolBinding honored in a workspace
+const UserPoolBindingName = "default"
+
+// Condition types for UserPoolBinding resources
+const (
+	// UserPoolBindingReadyCondition indicates whether the bound user pool is reachable with the configured credentials
+	UserPoolBindingReadyCondition = "Ready"
+)
+
+// Keys read from the credentials Secret of a UserPoolBinding
+const (
+	AccessKeyIDSecretKey     = "AWS_ACCESS_KEY_ID"
+	SecretAccessKeySecretKey = "AWS_SECRET_ACCESS_KEY"
+	SessionTokenSecretKey    = "AWS_SESSION_TOKEN"
+)
+
+// UserPoolBindingSpec defines the user pool the Users and Groups of a workspace are synced to.
+// +kubebuilder:validation:XValidation:rule="has(self.userPoolId) != has(self.userPoolName)",message="exactly one of userPoolId or userPoolName must be set"
+type UserPoolBindingSpec struct {
+	// UserPoolID is the ID of the Cognito user pool
+	// +optional
+	UserPoolID string `json:"userPoolId,omitempty"`
+
+	// UserPoolName is the name of the Cognito user pool, resolved to its ID
+	// +optional
+	UserPoolName string `json:"userPoolName,omitempty"`
+
+	// Region is the AWS region of the user pool. Defaults to the controller's region.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecretRef references a Secret holding AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+	// optionally AWS_SESSION_TOKEN. Defaults to the controller's own credentials, which only manage
+	// the user pools the operator allows them for.
+	// +optional
+	CredentialsSecretRef *corev1.SecretReference `json:"credentialsSecretRef,omitempty"`
+
+	// AssumeRole is an IAM role assumed with the credentials from CredentialsSecretRef, or the
+	// controller's own credentials, to manage a user pool in another AWS account. It replaces the
+	// role configured for the controller.
+	// +optional
+	AssumeRole *AssumeRoleSpec `json:"assumeRole,omitempty"`
+}
+
+// AssumeRoleSpec configures the STS AssumeRole call made for a user pool
+type AssumeRoleSpec struct {
+	// RoleARN is the ARN of the role to assume. It must be allowed by the operator, and is assumed
+	// with the logical cluster name of the workspace as external ID.
+	// +kubebuilder:validation:Pattern=`^arn:[^:]+:iam::[0-9]{12}:role/.+$`
+	RoleARN string `json:"roleArn"`
+
+	// SessionName names the role session in CloudTrail. Defaults to kcp-users-controller.
+	// +optional
+	// +kubebuilder:validation:MaxLength=64
+	SessionName string `json:"sessionName,omitempty"`
+
+	// Duration is the lifetime of the assumed role credentials, between 15 minutes and the
+	// role's maximum session duration. Defaults to 15 minutes.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// UserPoolBindingStatus defines the observed state of UserPoolBinding.
+type UserPoolBindingStatus struct {
+	// ObservedGeneration is the last generation that was acted upon
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the current service state of the UserPoolBinding
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the UserPoolBinding must be named default"
+// +kubebuilder:printcolumn:name="Pool ID",type=string,JSONPath=`.spec.userPoolId`
+// +kubebuilder:printcolumn:name="Pool Name",type=string,JSONPath=`.spec.userPoolName`
+// +kubebuilder:printcolumn:name="Region",type=string,JSONPath=`.spec.region`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+
+// UserPoolBinding is the Schema for the userpoolbindings API.
+// It selects the user pool of the workspace it is created in.
+type UserPoolBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UserPoolBindingSpec   `json:"spec,omitempty"`
+	Status UserPoolBindingStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UserPoolBindingList contains a list of UserPoolBinding.
+type UserPoolBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserPoolBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserPoolBinding{}, &UserPoolBindingList{})
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types for UserPoolInventory resources
+const (
+	// UserPoolInventoryReadyCondition indicates whether the last inventory of the user pool succeeded
+	UserPoolInventoryReadyCondition = "Ready"
+)
+
+// OrphanAction decides what happens to identities whose owning User no longer exists
+// +kubebuilder:validation:Enum=Report;Disable;Delete
+type OrphanAction string
+
+// Supported orphan actions
+const (
+	// OrphanActionReport only lists orphaned identities
+	OrphanActionReport OrphanAction = "Report"
+	// OrphanActionDisable disables orphaned identities once their grace period has passed
+	OrphanActionDisable OrphanAction = "Disable"
+	// OrphanActionDelete deletes orphaned identities once their grace period has passed
+	OrphanActionDelete OrphanAction = "Delete"
+)
+
+// OrphanedIdentity is a user pool identity whose ownership marker names no existing User
+type OrphanedIdentity struct {
+	// Sub is the identifier of the identity in the user pool
+	Sub string `json:"sub"`
+
+	// Email is the email of the identity
+	// +optional
+	Email string `json:"email,omitempty"`
+
+	// Owner is the ownership marker of the identity, as <cluster>/<namespace>/<name>
+	Owner string `json:"owner"`
+
+	// Reason explains why the owner no longer matches the identity
+	Reason string `json:"reason"`
+
+	// FirstSeenTime is when the identity was first found orphaned. The grace period starts then.
+	FirstSeenTime metav1.Time `json:"firstSeenTime"`
+
+	// Action is what has been done to the identity, empty while it is within its grace period
+	// +optional
+	Action OrphanAction `json:"action,omitempty"`
+}
+
+// UserPoolInventoryStatus defines the observed state of UserPoolInventory.
+type UserPoolInventoryStatus struct {
+	// LastRunTime is when the user pool was last inventoried
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// Identities is the number of identities in the user pool
+	Identities int32 `json:"identities"`
+
+	// Managed is the number of identities owned by an existing User, or by a User of a workspace
+	// that could not be checked
+	Managed int32 `json:"managed"`
+
+	// Unmanaged is the number of identities without an ownership marker or marked by another
+	// controller instance, which are never collected
+	Unmanaged int32 `json:"unmanaged"`
+
+	// Orphaned is the number of identities whose owning User no longer exists
+	Orphaned int32 `json:"orphaned"`
+
+	// Orphans lists the orphaned identities
+	// +optional
+	// +listType=map
+	// +listMapKey=sub
+	Orphans []OrphanedIdentity `json:"orphans,omitempty"`
+
+	// Conditions represent the current state of the inventory
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Identities",type=integer,JSONPath=`.status.identities`
+// +kubebuilder:printcolumn:name="Managed",type=integer,JSONPath=`.status.managed`
+// +kubebuilder:printcolumn:name="Orphaned",type=integer,JSONPath=`.status.orphaned`
+// +kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.lastRunTime`
+
+// UserPoolInventory is the Schema for the userpoolinventories API.
+// It is maintained by the controller and reports the orphaned identities of its user pool.
+type UserPoolInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status UserPoolInventoryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// UserPoolInventoryList contains a list of UserPoolInventory.
+type UserPoolInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UserPoolInventory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UserPoolInventory{}, &UserPoolInventoryList{})
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"os"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -44,8 +46,14 @@ import (
 	"github.com/kcp-dev/multicluster-provider/apiexport"
 
 	"github.com/cogniteo/kcp-users-controller/internal/controller"
+	"github.com/cogniteo/kcp-users-controller/internal/importer"
+	"github.com/cogniteo/kcp-users-controller/internal/scimserver"
+	webhookv1alpha1 "github.com/cogniteo/kcp-users-controller/internal/webhook/v1alpha1"
 	"github.com/cogniteo/kcp-users-controller/pkg/cognito"
+	"github.com/cogniteo/kcp-users-controller/pkg/keycloak"
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
 	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
 
 	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
 
@@ -53,6 +61,14 @@ import (
 	// +kubebuilder:scaffold:imports
 )
 
+// Supported values of --userpool-backend
+const (
+	backendCognito  = "cognito"
+	backendKeycloak = "keycloak"
+	backendSCIM     = "scim"
+	backendMemory   = "memory"
+)
+
 var (
 	setupLog = ctrl.Log.WithName("setup")
 )
@@ -104,12 +120,182 @@ func main() {
 				"https://kcp.example.com/clusters/org_myorg_workspace_myworkspace). "+
 				"This will override the host in the kubeconfig.").
 			Envar("VIRTUAL_WORKSPACE_URL").String()
+		userPoolBackend = app.Flag("userpool-backend",
+			"The identity provider users are managed in: cognito, keycloak, scim, or memory for development "+
+				"without an identity provider.").
+			Envar("USERPOOL_BACKEND").Default(backendCognito).
+			Enum(backendCognito, backendKeycloak, backendSCIM, backendMemory)
 		cognitoUserPoolID = app.Flag("cognito-user-pool-id",
 			"AWS Cognito User Pool ID. If not provided, Cognito integration will be disabled.").
 			Envar("COGNITO_USER_POOL_ID").String()
 		cognitoUserPoolName = app.Flag("cognito-user-pool-name",
 			"AWS Cognito User Pool Name. If not provided, Cognito integration will be disabled.").
 			Envar("COGNITO_USER_POOL_NAME").String()
+		cognitoEndpoint = app.Flag("cognito-endpoint",
+			"Cognito endpoint URL overriding the AWS endpoint, e.g. http://localhost:9229 for a local fake "+
+				"started with go run ./hack/fake-cognito.").
+			Envar("COGNITO_ENDPOINT").String()
+		cognitoRegion = app.Flag("cognito-region",
+			"AWS region of the Cognito user pool. Defaults to the region of the AWS configuration.").
+			Envar("COGNITO_REGION").String()
+		cognitoProfile = app.Flag("cognito-profile",
+			"Profile of the shared AWS configuration and credentials files used for Cognito.").
+			Envar("COGNITO_PROFILE").String()
+		cognitoAccessKeyID = app.Flag("cognito-access-key-id",
+			"Static AWS access key ID used for Cognito instead of the default credential chain.").
+			Envar("COGNITO_ACCESS_KEY_ID").String()
+		cognitoSecretAccessKey = app.Flag("cognito-secret-access-key",
+			"Static AWS secret access key, required with --cognito-access-key-id.").
+			Envar("COGNITO_SECRET_ACCESS_KEY").String()
+		cognitoSessionToken = app.Flag("cognito-session-token",
+			"Session token of temporary static AWS credentials.").
+			Envar("COGNITO_SESSION_TOKEN").String()
+		cognitoRoleARN = app.Flag("cognito-role-arn",
+			"ARN of an IAM role assumed before calling Cognito, e.g. to manage a user pool in another account.").
+			Envar("COGNITO_ROLE_ARN").String()
+		cognitoRoleExternalID = app.Flag("cognito-role-external-id",
+			"External ID passed when assuming --cognito-role-arn.").
+			Envar("COGNITO_ROLE_EXTERNAL_ID").String()
+		cognitoRoleSessionName = app.Flag("cognito-role-session-name",
+			"Session name used when assuming --cognito-role-arn.").
+			Envar("COGNITO_ROLE_SESSION_NAME").Default(cognito.DefaultRoleSessionName).String()
+		cognitoRoleDuration = app.Flag("cognito-role-duration",
+			"Lifetime of the credentials of --cognito-role-arn. They are refreshed before they expire.").
+			Envar("COGNITO_ROLE_DURATION").Default("15m").Duration()
+		cognitoDefaultCredentialsUserPools = app.Flag("cognito-default-credentials-user-pools",
+			"IDs or names of the user pools that UserPoolBindings without a credentials Secret may manage "+
+				"with the controller's credentials. Bindings of other user pools must bring their own credentials.").
+			Envar("COGNITO_DEFAULT_CREDENTIALS_USER_POOLS").Strings()
+		cognitoAssumableRoles = app.Flag("cognito-assumable-roles",
+			"ARNs of the roles UserPoolBindings may assume, as patterns such as "+
+				"arn:aws:iam::*:role/kcp-users-controller. Roles are assumed with the logical cluster name "+
+				"of the workspace as external ID.").
+			Envar("COGNITO_ASSUMABLE_ROLES").Strings()
+		keycloakURL = app.Flag("keycloak-url",
+			"Base URL of the Keycloak server, e.g. https://keycloak.example.com. "+
+				"Required with --userpool-backend=keycloak.").
+			Envar("KEYCLOAK_URL").String()
+		keycloakRealm = app.Flag("keycloak-realm",
+			"Keycloak realm users are managed in.").
+			Envar("KEYCLOAK_REALM").String()
+		keycloakClientID = app.Flag("keycloak-client-id",
+			"Client ID of the Keycloak service account client.").
+			Envar("KEYCLOAK_CLIENT_ID").String()
+		keycloakClientSecret = app.Flag("keycloak-client-secret",
+			"Client secret of the Keycloak service account client.").
+			Envar("KEYCLOAK_CLIENT_SECRET").String()
+		scimURL = app.Flag("scim-url",
+			"SCIM 2.0 base URL of the identity provider, e.g. https://example.okta.com/scim/v2. "+
+				"Required with --userpool-backend=scim.").
+			Envar("SCIM_URL").String()
+		scimToken = app.Flag("scim-token",
+			"Bearer token for the SCIM API.").
+			Envar("SCIM_TOKEN").String()
+		scimExtensionSchema = app.Flag("scim-extension-schema",
+			"Schema extension URN custom attributes are stored in, by the SCIM backend and the SCIM server.").
+			Envar("SCIM_EXTENSION_SCHEMA").Default(scim.DefaultExtensionSchema).String()
+		memoryLatency = app.Flag("memory-latency",
+			"Latency added to every call of the memory backend, to mimic a remote identity provider.").
+			Envar("MEMORY_LATENCY").Default("0").Duration()
+		memoryErrorRate = app.Flag("memory-error-rate",
+			"Fraction of memory backend calls that fail, between 0 and 1.").
+			Envar("MEMORY_ERROR_RATE").Default("0").Float64()
+		memoryCustomAttributes = app.Flag("memory-custom-attributes",
+			"Custom attributes declared in the schema of the memory backend, e.g. department.").
+			Envar("MEMORY_CUSTOM_ATTRIBUTES").Strings()
+		scimServerAddr = app.Flag("scim-server-bind-address",
+			"The address the inbound SCIM server binds to, e.g. :8443. Identity providers provision users "+
+				"into a workspace at /clusters/<workspace>/scim/v2. Leave as 0 to disable the SCIM server.").
+			Envar("SCIM_SERVER_BIND_ADDRESS").Default("0").String()
+		scimServerCertPath = app.Flag("scim-server-cert-path",
+			"The directory that contains the SCIM server certificate (tls.crt and tls.key). "+
+				"The SCIM server uses plain HTTP when not set.").
+			Envar("SCIM_SERVER_CERT_PATH").String()
+		scimServerNamespace = app.Flag("scim-server-namespace",
+			"The namespace in each workspace the SCIM server stores Users and Groups in.").
+			Envar("SCIM_SERVER_NAMESPACE").Default(scimserver.DefaultNamespace).String()
+		scimServerTokenSecret = app.Flag("scim-server-token-secret",
+			"The Secret in the SCIM server namespace of each workspace holding the bearer token "+
+				"identity providers authenticate with under the token key.").
+			Envar("SCIM_SERVER_TOKEN_SECRET").Default("scim-token").String()
+		temporaryPasswordValidity = app.Flag("temporary-password-validity",
+			"How long temporary passwords sent with invitations stay valid. "+
+				"Should match the temporary password validity of the user pool's password policy.").
+			Envar("TEMPORARY_PASSWORD_VALIDITY").Default("168h").Duration()
+		resyncInterval = app.Flag("resync-interval",
+			"How often synced Users are compared with the user pool to correct changes made outside kcp, "+
+				"such as users disabled, edited or deleted in the Cognito console. 0 disables drift detection.").
+			Envar("RESYNC_INTERVAL").Default("1h").Duration()
+		defaultDeletionPolicy = app.Flag("default-deletion-policy",
+			"What happens to the user pool identity of a deleted User that does not set spec.deletionPolicy: "+
+				"Delete, Disable or Retain.").
+			Envar("DEFAULT_DELETION_POLICY").Default(string(kcpv1alpha1.DeletionPolicyDelete)).
+			Enum(string(kcpv1alpha1.DeletionPolicyDelete), string(kcpv1alpha1.DeletionPolicyDisable),
+				string(kcpv1alpha1.DeletionPolicyRetain))
+		enableWebhooks = app.Flag("enable-webhooks",
+			"Serve the validating admission webhook for User resources.").
+			Envar("ENABLE_WEBHOOKS").Default("false").Bool()
+		webhookCertPath = app.Flag("webhook-cert-path",
+			"The directory that contains the webhook certificate (tls.crt and tls.key).").
+			Envar("WEBHOOK_CERT_PATH").String()
+		instanceID = app.Flag("instance-id",
+			"Identifies this controller in the ownership markers of the identities it manages. Controllers "+
+				"sharing a user pool must use distinct IDs.").
+			Envar("INSTANCE_ID").Default(controller.DefaultInstanceID).String()
+		usernameStrategy = app.Flag("username-strategy",
+			"How the usernames of identities are chosen in user pools that keep the username they are given: "+
+				"email, name (of the User resource), uuid (the UID of the User resource) or template. "+
+				"Cognito pools signing users in with their email or phone number generate usernames of their own.").
+			Envar("USERNAME_STRATEGY").Default(string(controller.UsernameStrategyEmail)).
+			Enum(string(controller.UsernameStrategyEmail), string(controller.UsernameStrategyName),
+				string(controller.UsernameStrategyUUID), string(controller.UsernameStrategyTemplate))
+		usernameTemplate = app.Flag("username-template",
+			"Go template rendering usernames with --username-strategy=template, over the fields "+
+				".Workspace, .Namespace, .Name, .Email and .UID of the User, e.g. {{ .Workspace }}.{{ .Name }}.").
+			Envar("USERNAME_TEMPLATE").String()
+		emailClaimWorkspace = app.Flag("email-claim-workspace",
+			"Logical cluster name of the workspace holding the email claims that keep Users of different "+
+				"workspaces from sharing an identity. Emails are not claimed when empty.").
+			Envar("EMAIL_CLAIM_WORKSPACE").String()
+		emailClaimNamespace = app.Flag("email-claim-namespace",
+			"Namespace of the email claim Leases.").
+			Envar("EMAIL_CLAIM_NAMESPACE").Default(controller.DefaultEmailClaimNamespace).String()
+		orphanCollectionInterval = app.Flag("orphan-collection-interval",
+			"How often the user pool is searched for identities whose owning User no longer exists in any "+
+				"workspace. 0 disables orphan collection.").
+			Envar("ORPHAN_COLLECTION_INTERVAL").Default("0").Duration()
+		orphanAction = app.Flag("orphan-action",
+			"What happens to orphaned identities once their grace period has passed: Report, Disable or Delete.").
+			Envar("ORPHAN_ACTION").Default(string(kcpv1alpha1.OrphanActionReport)).
+			Enum(string(kcpv1alpha1.OrphanActionReport), string(kcpv1alpha1.OrphanActionDisable),
+				string(kcpv1alpha1.OrphanActionDelete))
+		orphanGracePeriod = app.Flag("orphan-grace-period",
+			"How long an identity must stay orphaned before --orphan-action is taken.").
+			Envar("ORPHAN_GRACE_PERIOD").Default("24h").Duration()
+		orphanInventoryWorkspace = app.Flag("orphan-inventory-workspace",
+			"Logical cluster name of the workspace the UserPoolInventory is written to. "+
+				"No inventory is written when empty.").
+			Envar("ORPHAN_INVENTORY_WORKSPACE").String()
+		orphanInventoryName = app.Flag("orphan-inventory-name",
+			"Name of the UserPoolInventory.").
+			Envar("ORPHAN_INVENTORY_NAME").Default(controller.DefaultInventoryName).String()
+		importAttribute = app.Flag("import-attribute",
+			"User pool attribute whose value selects the workspace imported users are created in, "+
+				"e.g. custom:tenant.").
+			Envar("IMPORT_ATTRIBUTE").String()
+		importWorkspaces = app.Flag("import-workspace",
+			"Maps a value of --import-attribute to a workspace, as value=workspace. May be repeated.").
+			Envar("IMPORT_WORKSPACES").StringMap()
+		importDefaultWorkspace = app.Flag("import-default-workspace",
+			"Workspace users that match no --import-workspace are imported into. They are skipped when empty.").
+			Envar("IMPORT_DEFAULT_WORKSPACE").String()
+		importNamespace = app.Flag("import-namespace",
+			"Namespace imported Users are created in.").
+			Envar("IMPORT_NAMESPACE").Default(importer.DefaultNamespace).String()
+		importInterval = app.Flag("import-interval",
+			"How often the controller imports users created outside kcp. Workspaces are then given as "+
+				"logical cluster names. 0 disables importing in the controller.").
+			Envar("IMPORT_INTERVAL").Default("0").Duration()
 		// Zap logger flags
 		zapDevel = app.Flag("zap-devel",
 			"Development Mode defaults(encoder=consoleEncoder,logLevel=Debug,stackTraceLevel=Warn). "+
@@ -117,7 +303,17 @@ func main() {
 			Envar("ZAP_DEVEL").Default("true").Bool()
 	)
 
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	app.Command("run", "Run the controller manager.").Default()
+	importCmd := app.Command("import",
+		"Import the users of the user pool as User resources and print a report of the outcome per user. "+
+			"Workspaces are given as paths, e.g. root:acme.")
+	importDryRun := importCmd.Flag("dry-run",
+		"Report what would be imported without creating any User.").Bool()
+	importOutput := importCmd.Flag("output",
+		"Format of the report: table or json.").
+		Short('o').Default(importer.FormatTable).Enum(importer.FormatTable, importer.FormatJSON)
+
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	opts := zap.Options{
 		Development: *zapDevel,
@@ -145,6 +341,7 @@ func main() {
 
 	webhookServer := webhook.NewServer(webhook.Options{
 		TLSOpts: tlsOpts,
+		CertDir: *webhookCertPath,
 	})
 
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
@@ -183,6 +380,98 @@ func main() {
 		setupLog.Info("using bearer token for authentication")
 	}
 	log.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	// Initialize the user pool client of the selected backend
+	// Region, endpoint and credentials shared by the controller-wide and bound Cognito user pools
+	cognitoConfig := cognito.Config{
+		Region:          *cognitoRegion,
+		Endpoint:        *cognitoEndpoint,
+		Profile:         *cognitoProfile,
+		AccessKeyID:     *cognitoAccessKeyID,
+		SecretAccessKey: *cognitoSecretAccessKey,
+		SessionToken:    *cognitoSessionToken,
+		RoleARN:         *cognitoRoleARN,
+		ExternalID:      *cognitoRoleExternalID,
+		RoleSessionName: *cognitoRoleSessionName,
+		RoleDuration:    *cognitoRoleDuration,
+	}
+
+	var userPoolClient userpool.Client
+	if *userPoolBackend == backendKeycloak {
+		setupLog.Info("Initializing Keycloak client", "url", *keycloakURL, "realm", *keycloakRealm)
+		client, err := keycloak.NewClient(keycloak.Config{
+			URL:          *keycloakURL,
+			Realm:        *keycloakRealm,
+			ClientID:     *keycloakClientID,
+			ClientSecret: *keycloakClientSecret,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create Keycloak client")
+			os.Exit(1)
+		}
+		userPoolClient = client
+	} else if *userPoolBackend == backendSCIM {
+		setupLog.Info("Initializing SCIM client", "url", *scimURL)
+		client, err := scim.NewClient(scim.Config{
+			URL:             *scimURL,
+			Token:           *scimToken,
+			ExtensionSchema: *scimExtensionSchema,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create SCIM client")
+			os.Exit(1)
+		}
+		userPoolClient = client
+	} else if *userPoolBackend == backendMemory {
+		setupLog.Info("Initializing in-memory user pool, users are lost on restart",
+			"latency", *memoryLatency, "errorRate", *memoryErrorRate)
+		userPoolClient = memory.NewClient(
+			memory.WithLatency(*memoryLatency),
+			memory.WithErrorRate(*memoryErrorRate),
+			memory.WithCustomAttributes(*memoryCustomAttributes...),
+		)
+	} else if *cognitoUserPoolID != "" && *cognitoUserPoolName != "" {
+		setupLog.Error(nil, "both cognito-user-pool-id and cognito-user-pool-name provided, please specify only one")
+		os.Exit(1)
+	} else if *cognitoUserPoolID != "" {
+		setupLog.Info("Initializing AWS Cognito client", "userPoolId", *cognitoUserPoolID)
+		poolConfig := cognitoConfig
+		poolConfig.UserPoolID = *cognitoUserPoolID
+		client, err := cognito.NewClientFromConfig(context.Background(), poolConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to create Cognito client")
+			os.Exit(1)
+		}
+		userPoolClient = client
+	} else if *cognitoUserPoolName != "" {
+		setupLog.Info("Initializing AWS Cognito client", "userPoolName", *cognitoUserPoolName)
+		poolConfig := cognitoConfig
+		poolConfig.UserPoolName = *cognitoUserPoolName
+		client, err := cognito.NewClientFromConfig(context.Background(), poolConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to create Cognito client")
+			os.Exit(1)
+		}
+		userPoolClient = client
+	} else {
+		setupLog.Info("Cognito User Pool ID or Name not provided, Cognito integration disabled")
+	}
+
+	mapping := importer.Mapping{
+		Attribute:        *importAttribute,
+		Workspaces:       *importWorkspaces,
+		DefaultWorkspace: *importDefaultWorkspace,
+		Namespace:        *importNamespace,
+	}
+	if command == importCmd.FullCommand() {
+		os.Exit(runImport(&importer.Importer{
+			UserPool:  userPoolClient,
+			GetClient: importer.NewWorkspaceClientFunc(cfg, clientgoscheme.Scheme),
+			Mapping:   mapping,
+			DryRun:    *importDryRun,
+		}, *importOutput))
+	}
+
 	provider, err := apiexport.New(cfg, apiexport.Options{
 		Scheme: clientgoscheme.Scheme,
 	})
@@ -205,40 +494,123 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize Cognito client if User Pool ID or Name is provided
-	var userPoolClient userpool.Client
-	if *cognitoUserPoolID != "" && *cognitoUserPoolName != "" {
-		setupLog.Error(nil, "both cognito-user-pool-id and cognito-user-pool-name provided, please specify only one")
-		os.Exit(1)
-	} else if *cognitoUserPoolID != "" {
-		setupLog.Info("Initializing AWS Cognito client", "userPoolId", *cognitoUserPoolID)
-		client, err := cognito.NewClient(context.Background(), *cognitoUserPoolID)
-		if err != nil {
-			setupLog.Error(err, "unable to create Cognito client")
-			os.Exit(1)
+	// Workspaces may select their own Cognito user pool with a UserPoolBinding
+	var userPools *controller.UserPoolResolver
+	if *userPoolBackend == backendCognito {
+		userPools = &controller.UserPoolResolver{
+			Defaults:                    cognitoConfig,
+			DefaultCredentialsUserPools: *cognitoDefaultCredentialsUserPools,
+			AssumableRoles:              *cognitoAssumableRoles,
 		}
-		userPoolClient = client
-	} else if *cognitoUserPoolName != "" {
-		setupLog.Info("Initializing AWS Cognito client", "userPoolName", *cognitoUserPoolName)
-		client, err := cognito.NewClientByName(context.Background(), *cognitoUserPoolName)
+	}
+
+	// clusterClient returns the client of a workspace engaged through the APIExport
+	clusterClient := func(ctx context.Context, clusterName string) (client.Client, error) {
+		cl, err := mgr.GetCluster(ctx, clusterName)
 		if err != nil {
-			setupLog.Error(err, "unable to create Cognito client")
-			os.Exit(1)
+			return nil, err
+		}
+		return cl.GetClient(), nil
+	}
+	var emailClaims *controller.EmailClaims
+	if *emailClaimWorkspace != "" {
+		emailClaims = &controller.EmailClaims{
+			Workspace: *emailClaimWorkspace,
+			Namespace: *emailClaimNamespace,
+			GetClient: clusterClient,
 		}
-		userPoolClient = client
-	} else {
-		setupLog.Info("Cognito User Pool ID or Name not provided, Cognito integration disabled")
+	}
+
+	usernames, err := controller.NewUsernames(controller.UsernameStrategy(*usernameStrategy), *usernameTemplate)
+	if err != nil {
+		setupLog.Error(err, "invalid username strategy")
+		os.Exit(1)
 	}
 
 	if err := (&controller.UserReconciler{
+		Client:                    mgr.GetLocalManager().GetClient(),
+		Scheme:                    mgr.GetLocalManager().GetScheme(),
+		Manager:                   mgr,
+		UserPoolClient:            userPoolClient,
+		UserPools:                 userPools,
+		DefaultDeletionPolicy:     kcpv1alpha1.DeletionPolicy(*defaultDeletionPolicy),
+		TemporaryPasswordValidity: *temporaryPasswordValidity,
+		ResyncInterval:            *resyncInterval,
+		InstanceID:                *instanceID,
+		Usernames:                 usernames,
+		EmailClaims:               emailClaims,
+		GetClient:                 clusterClient,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "User")
+		os.Exit(1)
+	}
+	if err := (&controller.GroupReconciler{
 		Client:         mgr.GetLocalManager().GetClient(),
 		Scheme:         mgr.GetLocalManager().GetScheme(),
 		Manager:        mgr,
 		UserPoolClient: userPoolClient,
+		UserPools:      userPools,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "User")
+		setupLog.Error(err, "unable to create controller", "controller", "Group")
 		os.Exit(1)
 	}
+	if userPools != nil {
+		if err := (&controller.UserPoolBindingReconciler{
+			Client:    mgr.GetLocalManager().GetClient(),
+			Scheme:    mgr.GetLocalManager().GetScheme(),
+			Manager:   mgr,
+			UserPools: userPools,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "UserPoolBinding")
+			os.Exit(1)
+		}
+	}
+	if *enableWebhooks {
+		if err := webhookv1alpha1.SetupUserWebhookWithManager(mgr, userPoolClient, userPools); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "User")
+			os.Exit(1)
+		}
+	}
+	if *scimServerAddr != "0" {
+		if err := mgr.GetLocalManager().Add(&scimserver.Server{
+			Addr:            *scimServerAddr,
+			CertDir:         *scimServerCertPath,
+			Namespace:       *scimServerNamespace,
+			TokenSecretName: *scimServerTokenSecret,
+			ExtensionSchema: *scimExtensionSchema,
+			GetClient:       clusterClient,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up SCIM server")
+			os.Exit(1)
+		}
+	}
+	if *orphanCollectionInterval > 0 && userPoolClient != nil {
+		if err := mgr.Add(&controller.OrphanCollector{
+			UserPoolClient:   userPoolClient,
+			Action:           kcpv1alpha1.OrphanAction(*orphanAction),
+			InstanceID:       *instanceID,
+			GracePeriod:      *orphanGracePeriod,
+			Interval:         *orphanCollectionInterval,
+			InventoryCluster: *orphanInventoryWorkspace,
+			InventoryName:    *orphanInventoryName,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up orphan collector")
+			os.Exit(1)
+		}
+	}
+	if *importInterval > 0 && userPoolClient != nil {
+		if err := mgr.GetLocalManager().Add(&importer.Runner{
+			Importer: &importer.Importer{
+				UserPool:  userPoolClient,
+				GetClient: clusterClient,
+				Mapping:   mapping,
+			},
+			Interval: *importInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up importer")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -271,3 +643,31 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runImport imports the users of the user pool, prints the report and returns the exit code, which
+// is non-zero when users could not be imported
+func runImport(imp *importer.Importer, output string) int {
+	if imp.UserPool == nil {
+		setupLog.Error(nil, "no user pool configured to import users from")
+		return 1
+	}
+	if imp.Mapping.Attribute == "" && imp.Mapping.DefaultWorkspace == "" {
+		setupLog.Error(nil, "--import-attribute with --import-workspace or --import-default-workspace is required")
+		return 1
+	}
+
+	report, err := imp.Import(signals.SetupSignalHandler())
+	if err != nil {
+		setupLog.Error(err, "unable to import users")
+		return 1
+	}
+	if err := report.Write(os.Stdout, output); err != nil {
+		setupLog.Error(err, "unable to write import report")
+		return 1
+	}
+	if report.Count(importer.OutcomeConflict) > 0 || report.Count(importer.OutcomeFailed) > 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "some users were not imported, see the report for details")
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command fake-cognito serves a stateful fake of the Cognito API for running the manager locally
+// with --cognito-endpoint. State is kept in memory and lost on exit.
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	kingpin "github.com/alecthomas/kingpin/v2"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito/fake"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+func main() {
+	app := kingpin.New("fake-cognito", "Stateful fake of the AWS Cognito API.")
+	address := app.Flag("address", "The address the fake Cognito API binds to.").
+		Default("localhost:9229").String()
+	poolNames := app.Flag("user-pool", "Name of a user pool to create on startup. Can be repeated.").
+		Default("kcp-users").Strings()
+	customAttributes := app.Flag("custom-attributes",
+		"Custom attributes declared by the created user pools, in addition to the ownership markers "+
+			strings.Join(userpool.OwnershipAttributes, ", ")+".").
+		Strings()
+	ownUsernames := app.Flag("own-usernames",
+		"Create user pools that keep the username given at creation instead of signing users in with their email.").
+		Bool()
+	kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	usernameAttributes := []types.UsernameAttributeType{types.UsernameAttributeTypeEmail}
+	if *ownUsernames {
+		usernameAttributes = nil
+	}
+	api := fake.New()
+	for _, name := range *poolNames {
+		id := api.CreateUserPoolWithUsernameAttributes(name, usernameAttributes,
+			append(append([]string{}, userpool.OwnershipAttributes...), *customAttributes...)...)
+		fmt.Printf("Created user pool %s (%s)\n", name, id)
+	}
+
+	fmt.Printf("Serving the Cognito API on http://%s\n", *address)
+	if err := http.ListenAndServe(*address, api.Handler()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to serve the Cognito API: %v\n", err)
+		os.Exit(1)
+	}
+}
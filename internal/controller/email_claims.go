@@ -0,0 +1,277 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+)
+
+const (
+	// DefaultEmailClaimNamespace is the namespace of the claim Leases unless configured otherwise
+	DefaultEmailClaimNamespace = "default"
+
+	// EmailClaimAnnotation records the claimed email on its Lease, whose name is a hash
+	EmailClaimAnnotation = "kcp.cogniteo.io/email"
+	// EmailClaimScopeAnnotation records the user pool a claim applies to as region/ID, empty for
+	// backends with a single user pool
+	EmailClaimScopeAnnotation = "kcp.cogniteo.io/user-pool"
+	// EmailClaimReferencesAnnotation lists the Users sharing the identity of a shared claim as a JSON
+	// array, in the order they claimed it. The first reference is the holder of the Lease.
+	EmailClaimReferencesAnnotation = "kcp.cogniteo.io/references"
+
+	// emailClaimedElsewhereReason is the condition reason used when another User holds the claim on the email
+	emailClaimedElsewhereReason = "EmailClaimedElsewhere"
+)
+
+// EmailClaims makes Users claim their email before it is synced to a user pool, so that Users of
+// different workspaces declaring the same email cannot share one identity unless they all ask for
+// it. Claims are Leases in a single workspace, held by the owner ID of the User. The first User to
+// claim an email exclusively keeps it until it is deleted or changes its email; shared claims are
+// held by every User referencing them. Claims of Users that no longer exist are taken over.
+type EmailClaims struct {
+	// Workspace is the logical cluster name of the workspace holding the claim Leases
+	Workspace string
+	// Namespace of the claim Leases. Defaults to DefaultEmailClaimNamespace.
+	Namespace string
+	// GetClient returns a client for a workspace engaged through the APIExport
+	GetClient func(ctx context.Context, clusterName string) (client.Client, error)
+}
+
+// EmailClaim is the outcome of claiming an email
+type EmailClaim struct {
+	// Holder is the owner ID of a User holding the email when it cannot be claimed
+	Holder string
+	// References lists the owner IDs of the Users sharing the identity, in the order they claimed
+	// it. It is empty for exclusive claims.
+	References []string
+}
+
+// errUnreachable is returned for Users in workspaces that cannot be reached
+var errUnreachable = stderrors.New("workspace cannot be reached")
+
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update;delete
+
+// Claim claims the email of a user pool for owner, exclusively or as a reference of a shared
+// identity. The claim fails, reporting a holder, when another User holds the email exclusively or
+// when an exclusive claim is requested on an email shared with other Users.
+func (c *EmailClaims) Claim(ctx context.Context, scope, email, owner string, shared bool) (*EmailClaim, error) {
+	cl, err := c.GetClient(ctx, c.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client of claim workspace %s: %w", c.Workspace, err)
+	}
+
+	lease := &coordinationv1.Lease{}
+	key := c.key(scope, email)
+	err = cl.Get(ctx, key, lease)
+	found := err == nil
+	if errors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: key.Namespace,
+				Name:      key.Name,
+				Annotations: map[string]string{
+					EmailClaimAnnotation:      email,
+					EmailClaimScopeAnnotation: scope,
+				},
+			},
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get claim on email %s: %w", email, err)
+	}
+
+	holders, isShared := claimHolders(lease)
+	// Holders gone without releasing their claim, e.g. because their finalizer was removed by
+	// hand, are forgotten
+	var references []string
+	for _, holder := range holders {
+		if holder != owner {
+			if exists, err := c.holderExists(ctx, holder); err != nil {
+				return nil, err
+			} else if !exists {
+				continue
+			}
+			if !shared || !isShared {
+				return &EmailClaim{Holder: holder}, nil
+			}
+		}
+		references = append(references, holder)
+	}
+	if !slices.Contains(references, owner) {
+		references = append(references, owner)
+	}
+
+	claim := &EmailClaim{}
+	if shared {
+		claim.References = references
+	}
+	if !setClaimHolders(lease, references, shared) {
+		return claim, nil
+	}
+	if !found {
+		if err := cl.Create(ctx, lease); err != nil {
+			// Losing the race to another User is reported on the next attempt
+			return nil, fmt.Errorf("failed to create claim on email %s: %w", email, err)
+		}
+		return claim, nil
+	}
+	if err := cl.Update(ctx, lease); err != nil {
+		return nil, fmt.Errorf("failed to update claim on email %s: %w", email, err)
+	}
+	return claim, nil
+}
+
+// Release releases the claim of owner on the email of a user pool. Claims held by others are kept.
+// It returns the references left on a shared identity, which is only released by its last reference.
+func (c *EmailClaims) Release(ctx context.Context, scope, email, owner string) ([]string, error) {
+	cl, err := c.GetClient(ctx, c.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client of claim workspace %s: %w", c.Workspace, err)
+	}
+
+	lease := &coordinationv1.Lease{}
+	if err := cl.Get(ctx, c.key(scope, email), lease); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	holders, isShared := claimHolders(lease)
+	var remaining []string
+	if isShared {
+		remaining = slices.DeleteFunc(slices.Clone(holders), func(holder string) bool { return holder == owner })
+	}
+	if !slices.Contains(holders, owner) {
+		return remaining, nil
+	}
+
+	if len(remaining) > 0 {
+		setClaimHolders(lease, remaining, true)
+		if err := cl.Update(ctx, lease); err != nil {
+			return nil, fmt.Errorf("failed to release claim on email %s: %w", email, err)
+		}
+		return remaining, nil
+	}
+	err = cl.Delete(ctx, lease, client.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion})
+	if err := client.IgnoreNotFound(err); err != nil {
+		return nil, fmt.Errorf("failed to release claim on email %s: %w", email, err)
+	}
+	return nil, nil
+}
+
+// user returns the User identified by an owner ID, or nil when it no longer exists. Users in
+// workspaces that cannot be reached are reported with errUnreachable.
+func (c *EmailClaims) user(ctx context.Context, owner string) (*kcpv1alpha1.User, error) {
+	return lookupUser(ctx, c.GetClient, owner)
+}
+
+// lookupUser returns the User identified by an owner ID through the client of its workspace, or nil
+// when it no longer exists. Users in workspaces that cannot be reached are reported with errUnreachable.
+func lookupUser(ctx context.Context, getClient func(context.Context, string) (client.Client, error),
+	owner string) (*kcpv1alpha1.User, error) {
+	parts := strings.SplitN(owner, "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid owner %q: %w", owner, errUnreachable)
+	}
+	cl, err := getClient(ctx, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client of workspace %s: %w: %w", parts[0], errUnreachable, err)
+	}
+	user := &kcpv1alpha1.User{}
+	err = cl.Get(ctx, client.ObjectKey{Namespace: parts[1], Name: parts[2]}, user)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get User %s: %w", owner, err)
+	}
+	return user, nil
+}
+
+// holderExists reports whether the User identified by a claim holder still exists. Holders in
+// workspaces that cannot be reached are assumed to exist, so their claims are never taken over.
+func (c *EmailClaims) holderExists(ctx context.Context, holder string) (bool, error) {
+	user, err := c.user(ctx, holder)
+	if stderrors.Is(err, errUnreachable) {
+		return true, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to get claim holder: %w", err)
+	}
+	return user != nil, nil
+}
+
+// key returns the Lease of an email claim. Emails are not valid object names and are compared
+// case-insensitively like user pool usernames, so the name is a hash of the lowercased email.
+func (c *EmailClaims) key(scope, email string) client.ObjectKey {
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = DefaultEmailClaimNamespace
+	}
+	sum := sha256.Sum256([]byte(scope + "\x00" + strings.ToLower(email)))
+	return client.ObjectKey{Namespace: namespace, Name: "email-" + hex.EncodeToString(sum[:])}
+}
+
+// claimHolders returns the Users holding a claim Lease and whether the claim is shared
+func claimHolders(lease *coordinationv1.Lease) ([]string, bool) {
+	if encoded, ok := lease.Annotations[EmailClaimReferencesAnnotation]; ok {
+		var references []string
+		if err := json.Unmarshal([]byte(encoded), &references); err == nil {
+			return references, true
+		}
+	}
+	if holder := ptr.Deref(lease.Spec.HolderIdentity, ""); holder != "" {
+		return []string{holder}, false
+	}
+	return nil, false
+}
+
+// setClaimHolders records the Users holding a claim Lease and reports whether it changed
+func setClaimHolders(lease *coordinationv1.Lease, holders []string, shared bool) bool {
+	previous, wasShared := claimHolders(lease)
+	if slices.Equal(previous, holders) && wasShared == shared {
+		return false
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	delete(lease.Annotations, EmailClaimReferencesAnnotation)
+	if shared {
+		encoded, _ := json.Marshal(holders)
+		lease.Annotations[EmailClaimReferencesAnnotation] = string(encoded)
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != holders[0] {
+		if lease.Spec.HolderIdentity != nil {
+			lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		}
+		lease.Spec.HolderIdentity = ptr.To(holders[0])
+		lease.Spec.AcquireTime = ptr.To(metav1.NowMicro())
+	}
+	return true
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
+)
+
+// newEmailClaims returns email claims stored in the claims workspace, with fake clients for the
+// claims workspace and the given workspaces
+func newEmailClaims(t *testing.T, workspaces ...string) (*EmailClaims, map[string]client.Client) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+
+	clients := map[string]client.Client{}
+	for _, name := range append(workspaces, "claims") {
+		clients[name] = fake.NewClientBuilder().WithScheme(scheme).Build()
+	}
+	return &EmailClaims{
+		Workspace: "claims",
+		GetClient: func(_ context.Context, clusterName string) (client.Client, error) {
+			if cl, ok := clients[clusterName]; ok {
+				return cl, nil
+			}
+			return nil, errors.New("cluster not engaged")
+		},
+	}, clients
+}
+
+// claimingUser returns a User named jane in the given workspace, stored in its client
+func claimingUser(t *testing.T, cl client.Client, workspace, email string) *kcpv1alpha1.User {
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			Annotations: map[string]string{"kcp.io/cluster": workspace},
+		},
+		Spec: kcpv1alpha1.UserSpec{Email: email},
+	}
+	require.NoError(t, cl.Create(context.Background(), user))
+	return user
+}
+
+func TestEmailClaims(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first claim wins", func(t *testing.T) {
+		claims, clients := newEmailClaims(t, "team-a")
+		claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+		assert.Empty(t, claim.Holder)
+		claim, err = claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+		assert.Empty(t, claim.Holder, "claiming again is a no-op")
+
+		claim, err = claims.Claim(ctx, "", "Jane@Example.com", "team-b/default/jane", false)
+		require.NoError(t, err)
+		assert.Equal(t, "team-a/default/jane", claim.Holder, "emails are compared case-insensitively")
+
+		var leases coordinationv1.LeaseList
+		require.NoError(t, clients["claims"].List(ctx, &leases))
+		require.Len(t, leases.Items, 1)
+		assert.Equal(t, DefaultEmailClaimNamespace, leases.Items[0].Namespace)
+		assert.Equal(t, "jane@example.com", leases.Items[0].Annotations[EmailClaimAnnotation])
+	})
+
+	t.Run("claims are scoped to a user pool", func(t *testing.T) {
+		claims, clients := newEmailClaims(t, "team-a")
+		claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+		_, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+
+		claim, err := claims.Claim(ctx, "eu-west-1/eu-west-1_abc", "jane@example.com", "team-b/default/jane", false)
+
+		require.NoError(t, err)
+		assert.Empty(t, claim.Holder)
+	})
+
+	t.Run("claims of deleted Users are taken over", func(t *testing.T) {
+		claims, _ := newEmailClaims(t, "team-a")
+		_, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", false)
+		require.NoError(t, err)
+		assert.Empty(t, claim.Holder)
+		claim, err = claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+		assert.Equal(t, "team-b/default/jane", claim.Holder)
+	})
+
+	t.Run("claims in unreachable workspaces are kept", func(t *testing.T) {
+		claims, _ := newEmailClaims(t)
+		_, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "team-a/default/jane", claim.Holder)
+	})
+
+	t.Run("only the holder releases a claim", func(t *testing.T) {
+		claims, clients := newEmailClaims(t, "team-a")
+		claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+		_, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+
+		_, err = claims.Release(ctx, "", "jane@example.com", "team-b/default/jane")
+		require.NoError(t, err)
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", false)
+		require.NoError(t, err)
+		assert.Equal(t, "team-a/default/jane", claim.Holder)
+
+		_, err = claims.Release(ctx, "", "jane@example.com", "team-a/default/jane")
+		require.NoError(t, err)
+		_, err = claims.Release(ctx, "", "jane@example.com", "team-a/default/jane")
+		require.NoError(t, err)
+		claim, err = claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", false)
+		require.NoError(t, err)
+		assert.Empty(t, claim.Holder)
+	})
+
+	t.Run("shared claims are referenced by every User", func(t *testing.T) {
+		claims, clients := newEmailClaims(t, "team-a", "team-b")
+		claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+		claimingUser(t, clients["team-b"], "team-b", "jane@example.com")
+
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"team-a/default/jane"}, claim.References)
+		claim, err = claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"team-a/default/jane", "team-b/default/jane"}, claim.References)
+
+		claim, err = claims.Claim(ctx, "", "jane@example.com", "team-c/default/jane", false)
+		require.NoError(t, err)
+		assert.Equal(t, "team-a/default/jane", claim.Holder, "shared emails cannot be claimed exclusively")
+
+		remaining, err := claims.Release(ctx, "", "jane@example.com", "team-a/default/jane")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"team-b/default/jane"}, remaining)
+		remaining, err = claims.Release(ctx, "", "jane@example.com", "team-b/default/jane")
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+		var leases coordinationv1.LeaseList
+		require.NoError(t, clients["claims"].List(ctx, &leases))
+		assert.Empty(t, leases.Items, "the last reference releases the claim")
+	})
+
+	t.Run("exclusive claims are not shared", func(t *testing.T) {
+		claims, clients := newEmailClaims(t, "team-a")
+		claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+		_, err := claims.Claim(ctx, "", "jane@example.com", "team-a/default/jane", false)
+		require.NoError(t, err)
+
+		claim, err := claims.Claim(ctx, "", "jane@example.com", "team-b/default/jane", true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "team-a/default/jane", claim.Holder)
+		assert.Empty(t, claim.References)
+	})
+}
+
+func TestUserEmailClaims(t *testing.T) {
+	ctx := context.Background()
+	userPool := memory.NewClient()
+	claims, clients := newEmailClaims(t, "team-a", "team-b")
+	reconciler := &UserReconciler{UserPoolClient: userPool, EmailClaims: claims}
+	first := claimingUser(t, clients["team-a"], "team-a", "jane@example.com")
+	second := claimingUser(t, clients["team-b"], "team-b", "jane@example.com")
+	second.Spec.AdoptionPolicy = kcpv1alpha1.AdoptionPolicyAlways
+
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, first, logr.Discard()))
+	assert.Equal(t, "jane@example.com", first.Status.ClaimedEmail)
+
+	err := reconciler.syncUserWithUserPool(ctx, second, logr.Discard())
+	require.ErrorContains(t, err, "Email jane@example.com is claimed by team-a/default/jane")
+	assert.Empty(t, second.Status.Sub, "the identity of the first User is not adopted")
+	created := meta.FindStatusCondition(second.Status.Conditions, kcpv1alpha1.UserCreatedCondition)
+	require.NotNil(t, created)
+	assert.Equal(t, metav1.ConditionFalse, created.Status)
+	assert.Equal(t, emailClaimedElsewhereReason, created.Reason)
+
+	// Changing the email releases the previous claim
+	first.Spec.Email = "jane.doe@example.com"
+	first.Generation++
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, first, logr.Discard()))
+	assert.Equal(t, "jane.doe@example.com", first.Status.ClaimedEmail)
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, second, logr.Discard()))
+	assert.NotEmpty(t, second.Status.Sub)
+
+	// Deleting a User releases its claim
+	require.NoError(t, reconciler.releaseEmailClaims(ctx, first))
+	var leases coordinationv1.LeaseList
+	require.NoError(t, clients["claims"].List(ctx, &leases))
+	require.Len(t, leases.Items, 1)
+	assert.Equal(t, "jane@example.com", leases.Items[0].Annotations[EmailClaimAnnotation])
+}
+
+func TestUserSharedIdentity(t *testing.T) {
+	ctx := context.Background()
+	userPool := memory.NewClient(memory.WithCustomAttributes("department"))
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	claims, clients := newEmailClaims(t, "team-a", "team-b")
+	reconciler := &UserReconciler{UserPoolClient: userPool, EmailClaims: claims}
+
+	// sharingUser stores a User sharing the identity of jane@example.com in the workspace
+	sharingUser := func(workspace string, spec kcpv1alpha1.UserSpec) *kcpv1alpha1.User {
+		user := claimingUser(t, clients[workspace], workspace, "jane@example.com")
+		spec.Email = user.Spec.Email
+		spec.IdentitySharing = kcpv1alpha1.IdentitySharingShared
+		spec.DeletionPolicy = kcpv1alpha1.DeletionPolicyDelete
+		user.Spec = spec
+		require.NoError(t, clients[workspace].Update(ctx, user))
+		return user
+	}
+	first := sharingUser("team-a", kcpv1alpha1.UserSpec{
+		Enabled:          ptr.To(false),
+		GivenName:        "Jane",
+		CustomAttributes: map[string]string{"department": "R&D"},
+		Groups:           []string{"developers"},
+	})
+	second := sharingUser("team-b", kcpv1alpha1.UserSpec{
+		GivenName:  "Janet",
+		FamilyName: "Doe",
+		Groups:     []string{"admins"},
+	})
+
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, first, logr.Discard()))
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, second, logr.Discard()))
+
+	t.Run("Users share one identity", func(t *testing.T) {
+		assert.Equal(t, first.Status.Sub, second.Status.Sub)
+		assert.Equal(t, int32(2), second.Status.References)
+
+		poolUser, err := userPool.GetUser(ctx, second.Status.Sub)
+		require.NoError(t, err)
+		assert.True(t, poolUser.Enabled, "the identity is enabled when any User enables it")
+		assert.Equal(t, "Jane", poolUser.Attributes[userpool.AttributeGivenName], "the first User sets an attribute")
+		assert.Equal(t, "Doe", poolUser.Attributes[userpool.AttributeFamilyName])
+		assert.Equal(t, "R&D", poolUser.Attributes["custom:department"])
+		assert.Equal(t, "team-a/default/jane", poolUser.Attributes[userpool.OwnerAttribute])
+		groups, err := userPool.ListGroupsForUser(ctx, second.Status.Sub)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"developers", "admins"}, groups)
+	})
+
+	t.Run("deleting a User hands the identity over", func(t *testing.T) {
+		require.NoError(t, clients["team-a"].Delete(ctx, first))
+		require.NoError(t, reconciler.cleanupUserInUserPool(ctx, first, logr.Discard()))
+
+		poolUser, err := userPool.GetUser(ctx, second.Status.Sub)
+		require.NoError(t, err, "the identity is kept for the remaining User")
+		assert.Equal(t, "Janet", poolUser.Attributes[userpool.AttributeGivenName])
+		assert.NotContains(t, poolUser.Attributes, "custom:department")
+		assert.Equal(t, "team-b/default/jane", poolUser.Attributes[userpool.OwnerAttribute])
+		groups, err := userPool.ListGroupsForUser(ctx, second.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins"}, groups)
+	})
+
+	t.Run("the last User deletes the identity", func(t *testing.T) {
+		require.NoError(t, reconciler.cleanupUserInUserPool(ctx, second, logr.Discard()))
+
+		_, err := userPool.GetUser(ctx, second.Status.Sub)
+		assert.ErrorIs(t, err, userpool.ErrUserNotFound)
+	})
+
+	t.Run("shared identities require email claims", func(t *testing.T) {
+		user := &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane", Namespace: "default"},
+			Spec:       kcpv1alpha1.UserSpec{Email: "jane@example.com", IdentitySharing: kcpv1alpha1.IdentitySharingShared},
+		}
+
+		err := (&UserReconciler{UserPoolClient: userPool}).syncUserWithUserPool(ctx, user, logr.Discard())
+
+		require.ErrorContains(t, err, "Shared identities require email claims")
+		assert.Empty(t, user.Status.Sub)
+	})
+}
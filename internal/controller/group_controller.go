@@ -0,0 +1,235 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mcbuilder "sigs.k8s.io/multicluster-runtime/pkg/builder"
+	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
+	mcreconcile "sigs.k8s.io/multicluster-runtime/pkg/reconcile"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+const groupFinalizerName = "kcp.cogniteo.io/group-pool-cleanup"
+
+// GroupReconciler reconciles a Group object
+type GroupReconciler struct {
+	client.Client
+	Scheme         *runtime.Scheme
+	Manager        mcmanager.Manager
+	UserPoolClient userpool.Client
+
+	// UserPools resolves the user pool of workspaces that have a UserPoolBinding.
+	// UserPoolClient is used for all other workspaces.
+	UserPools *UserPoolResolver
+}
+
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=groups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=groups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=groups/finalizers,verbs=update
+
+// Reconcile creates, updates and deletes the user pool group backing a Group object.
+func (r *GroupReconciler) Reconcile(ctx context.Context, req mcreconcile.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx).WithValues("cluster", req.ClusterName)
+	log.Info("Reconciling Group")
+
+	// Fetch the Group instance
+	var group kcpv1alpha1.Group
+	cl, err := r.Manager.GetCluster(ctx, req.ClusterName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	clusterClient := cl.GetClient()
+	if err := clusterClient.Get(ctx, req.NamespacedName, &group); err != nil {
+		if errors.IsNotFound(err) {
+			// Group was deleted, no action needed as finalizer should have handled cleanup
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Skip reconciliation if generation hasn't changed and the group is not being deleted
+	if group.DeletionTimestamp == nil && group.Status.ObservedGeneration == group.Generation {
+		log.Info("Resource unchanged, skipping reconciliation",
+			"generation", group.Generation,
+			"observedGeneration", group.Status.ObservedGeneration)
+		return ctrl.Result{}, nil
+	}
+
+	// Talk to the user pool bound to the workspace, if it has one
+	poolClient, err := resolveUserPoolClient(ctx, r.UserPools, req.ClusterName, clusterClient, r.UserPoolClient)
+	if err != nil {
+		log.Error(err, "Failed to resolve user pool of workspace")
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+	}
+	r = r.withUserPoolClient(poolClient)
+
+	if group.DeletionTimestamp != nil {
+		// Group is being deleted, run cleanup
+		if r.UserPoolClient != nil {
+			if err := r.deleteGroupFromUserPool(ctx, &group, log); err != nil {
+				log.Error(err, "Failed to delete group from user pool", "group", group.Name)
+				return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+			}
+		}
+
+		// Remove finalizer
+		group.Finalizers = removeFinalizer(group.Finalizers, groupFinalizerName)
+		if err := clusterClient.Update(ctx, &group); err != nil {
+			log.Error(err, "Failed to remove finalizer")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Add finalizer if not present
+	if !containsFinalizer(group.Finalizers, groupFinalizerName) {
+		group.Finalizers = append(group.Finalizers, groupFinalizerName)
+		if err := clusterClient.Update(ctx, &group); err != nil {
+			log.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.syncGroupWithUserPool(ctx, &group, log); err != nil {
+		log.Error(err, "Failed to sync group with user pool")
+		if statusErr := clusterClient.Status().Update(ctx, &group); statusErr != nil {
+			log.Error(statusErr, "Failed to update Group status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+	}
+
+	// Update the observed generation to indicate we've processed this version
+	group.Status.ObservedGeneration = group.Generation
+	if err := clusterClient.Status().Update(ctx, &group); err != nil {
+		log.Error(err, "Failed to update Group status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// syncGroupWithUserPool creates or updates the group in the user pool. Groups owned by another
+// Group, or created outside kcp, are left untouched.
+func (r *GroupReconciler) syncGroupWithUserPool(ctx context.Context, group *kcpv1alpha1.Group, log logr.Logger) error {
+	// Skip sync if UserPoolClient is not configured
+	if r.UserPoolClient == nil {
+		log.Info("UserPoolClient not configured, skipping user pool sync", "group", group.Name)
+		return nil
+	}
+
+	existing, err := r.UserPoolClient.GetGroup(ctx, group.Name)
+	if err != nil && !stderrors.Is(err, userpool.ErrGroupNotFound) {
+		setCondition(&group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition, metav1.ConditionFalse,
+			failureReason(err, "GroupCreationFailed"), fmt.Sprintf("Failed to get group from user pool: %v", err))
+		return fmt.Errorf("failed to get group from user pool: %w", err)
+	}
+	if existing != nil && !ownsGroup(group, existing) {
+		conflict := groupOwnershipConflict(existing)
+		log.Info("Refusing to update group owned by someone else", "group", group.Name, "owner", existing.Owner)
+		setCondition(&group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition, metav1.ConditionFalse,
+			ownershipConflictReason, conflict)
+		return fmt.Errorf("refused to sync group with user pool: %s", conflict)
+	}
+
+	poolGroup := &userpool.Group{
+		Name:        group.Name,
+		Description: group.Spec.Description,
+		Precedence:  group.Spec.Precedence,
+		Owner:       ownerID(group),
+	}
+
+	if err := r.UserPoolClient.CreateGroup(ctx, poolGroup); err != nil {
+		setCondition(&group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition, metav1.ConditionFalse,
+			failureReason(err, "GroupCreationFailed"), fmt.Sprintf("Failed to create group in user pool: %v", err))
+		return fmt.Errorf("failed to create group in user pool: %w", err)
+	}
+
+	log.Info("Group synced with user pool", "group", group.Name)
+	setCondition(&group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition, metav1.ConditionTrue,
+		"GroupCreated", "Group successfully created in user pool")
+	now := metav1.Now()
+	group.Status.LastSyncTime = &now
+	return nil
+}
+
+// deleteGroupFromUserPool deletes the group from the user pool unless another Group owns it
+func (r *GroupReconciler) deleteGroupFromUserPool(ctx context.Context, group *kcpv1alpha1.Group,
+	log logr.Logger) error {
+	existing, err := r.UserPoolClient.GetGroup(ctx, group.Name)
+	if stderrors.Is(err, userpool.ErrGroupNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get group from user pool: %w", err)
+	}
+	if !ownsGroup(group, existing) {
+		log.Info("Leaving group owned by someone else in user pool", "group", group.Name, "owner", existing.Owner)
+		return nil
+	}
+	if err := r.UserPoolClient.DeleteGroup(ctx, group.Name); err != nil {
+		return err
+	}
+	log.Info("Group deleted from user pool", "group", group.Name)
+	return nil
+}
+
+// ownsGroup reports whether the Group owns the user pool group. Groups synced before owners were
+// recorded keep the unmarked group they synced.
+func ownsGroup(group *kcpv1alpha1.Group, poolGroup *userpool.Group) bool {
+	if poolGroup.Owner == "" {
+		return group.Status.LastSyncTime != nil
+	}
+	return poolGroup.Owner == ownerID(group)
+}
+
+// groupOwnershipConflict describes who owns a user pool group the Group does not own
+func groupOwnershipConflict(poolGroup *userpool.Group) string {
+	if poolGroup.Owner == "" {
+		return fmt.Sprintf("Group %s already exists in the user pool and is not managed by kcp", poolGroup.Name)
+	}
+	return fmt.Sprintf("Group %s is owned by %s", poolGroup.Name, poolGroup.Owner)
+}
+
+// withUserPoolClient returns a shallow copy of the reconciler that talks to the given user pool
+func (r *GroupReconciler) withUserPoolClient(poolClient userpool.Client) *GroupReconciler {
+	scoped := *r
+	scoped.UserPoolClient = poolClient
+	return &scoped
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GroupReconciler) SetupWithManager(mgr mcmanager.Manager) error {
+	return mcbuilder.ControllerManagedBy(mgr).
+		For(&kcpv1alpha1.Group{}).
+		Named("group").
+		Complete(mcreconcile.Func(r.Reconcile))
+}
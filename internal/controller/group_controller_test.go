@@ -0,0 +1,227 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/internal/controller/mocks"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
+)
+
+func TestGroupReconciler(t *testing.T) {
+	t.Run("syncGroupWithUserPool", func(t *testing.T) {
+		t.Run("create group successfully", func(t *testing.T) {
+			precedence := int32(10)
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "admins",
+					Namespace:   "default",
+					Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+				},
+				Spec: kcpv1alpha1.GroupSpec{
+					Description: "Administrators",
+					Precedence:  &precedence,
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetGroup", mock.Anything, "admins").Return(nil, userpool.ErrGroupNotFound)
+			mockUserPool.On("CreateGroup", mock.Anything, &userpool.Group{
+				Name:        "admins",
+				Description: "Administrators",
+				Precedence:  &precedence,
+				Owner:       "root:org:team/default/admins",
+			}).Return(nil)
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			require.NoError(t, err)
+			assert.NotNil(t, group.Status.LastSyncTime)
+			assert.True(t, meta.IsStatusConditionTrue(group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition))
+		})
+
+		t.Run("create group fails", func(t *testing.T) {
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetGroup", mock.Anything, "admins").Return(nil, userpool.ErrGroupNotFound)
+			mockUserPool.On("CreateGroup", mock.Anything, mock.AnythingOfType("*userpool.Group")).
+				Return(errors.New("creation failed"))
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to create group in user pool")
+			assert.Nil(t, group.Status.LastSyncTime)
+			assert.True(t, meta.IsStatusConditionFalse(group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition))
+		})
+
+		t.Run("get group fails", func(t *testing.T) {
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetGroup", mock.Anything, "admins").Return(nil, errors.New("lookup failed"))
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to get group from user pool")
+			mockUserPool.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+		})
+
+		t.Run("group created outside kcp is not taken over", func(t *testing.T) {
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetGroup", mock.Anything, "admins").
+				Return(&userpool.Group{Name: "admins", Description: "Managed in the console"}, nil)
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			require.Error(t, err)
+			mockUserPool.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+			condition := meta.FindStatusCondition(group.Status.Conditions, kcpv1alpha1.GroupCreatedCondition)
+			require.NotNil(t, condition)
+			assert.Equal(t, ownershipConflictReason, condition.Reason)
+			assert.Equal(t, "Group admins already exists in the user pool and is not managed by kcp", condition.Message)
+		})
+
+		t.Run("group synced before owners were recorded is claimed", func(t *testing.T) {
+			now := metav1.Now()
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "admins",
+					Namespace:   "default",
+					Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+				},
+				Status: kcpv1alpha1.GroupStatus{LastSyncTime: &now},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetGroup", mock.Anything, "admins").Return(&userpool.Group{Name: "admins"}, nil)
+			mockUserPool.On("CreateGroup", mock.Anything, mock.MatchedBy(func(g *userpool.Group) bool {
+				return g.Owner == "root:org:team/default/admins"
+			})).Return(nil)
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			require.NoError(t, err)
+		})
+
+		t.Run("without user pool client", func(t *testing.T) {
+			group := &kcpv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			}
+
+			reconciler := &GroupReconciler{
+				UserPoolClient: nil, // No user pool client
+			}
+
+			err := reconciler.syncGroupWithUserPool(context.Background(), group, logr.Discard())
+
+			// Should not error when UserPoolClient is nil (graceful handling)
+			require.NoError(t, err)
+		})
+	})
+}
+
+func TestGroupOwnershipAcrossWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	userPool := memory.NewClient()
+	reconciler := &GroupReconciler{UserPoolClient: userPool}
+	newGroup := func(workspace, description string) *kcpv1alpha1.Group {
+		return &kcpv1alpha1.Group{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "admins",
+				Namespace:   "default",
+				Annotations: map[string]string{"kcp.io/cluster": workspace},
+			},
+			Spec: kcpv1alpha1.GroupSpec{Description: description},
+		}
+	}
+	first := newGroup("root:org:team-a", "Administrators of team A")
+	second := newGroup("root:org:team-b", "Administrators of team B")
+
+	require.NoError(t, reconciler.syncGroupWithUserPool(ctx, first, logr.Discard()))
+
+	t.Run("group of another workspace is not updated", func(t *testing.T) {
+		err := reconciler.syncGroupWithUserPool(ctx, second, logr.Discard())
+
+		require.Error(t, err)
+		condition := meta.FindStatusCondition(second.Status.Conditions, kcpv1alpha1.GroupCreatedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, ownershipConflictReason, condition.Reason)
+		assert.Equal(t, "Group admins is owned by root:org:team-a/default/admins", condition.Message)
+		poolGroup, err := userPool.GetGroup(ctx, "admins")
+		require.NoError(t, err)
+		assert.Equal(t, "Administrators of team A", poolGroup.Description)
+		assert.Equal(t, "root:org:team-a/default/admins", poolGroup.Owner)
+	})
+
+	t.Run("group of another workspace is not deleted", func(t *testing.T) {
+		require.NoError(t, reconciler.deleteGroupFromUserPool(ctx, second, logr.Discard()))
+
+		_, err := userPool.GetGroup(ctx, "admins")
+		require.NoError(t, err)
+	})
+
+	t.Run("owner deletes its group", func(t *testing.T) {
+		require.NoError(t, reconciler.deleteGroupFromUserPool(ctx, first, logr.Discard()))
+
+		_, err := userPool.GetGroup(ctx, "admins")
+		assert.ErrorIs(t, err, userpool.ErrGroupNotFound)
+	})
+}
@@ -23,6 +23,101 @@ func (_m *MockUserPoolClient) EXPECT() *MockUserPoolClient_Expecter {
 	return &MockUserPoolClient_Expecter{mock: &_m.Mock}
 }
 
+// AddUserToGroup provides a mock function with given fields: ctx, username, group
+func (_m *MockUserPoolClient) AddUserToGroup(ctx context.Context, username string, group string) error {
+	ret := _m.Called(ctx, username, group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddUserToGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_AddUserToGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddUserToGroup'
+type MockUserPoolClient_AddUserToGroup_Call struct {
+	*mock.Call
+}
+
+// AddUserToGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - group string
+func (_e *MockUserPoolClient_Expecter) AddUserToGroup(ctx interface{}, username interface{}, group interface{}) *MockUserPoolClient_AddUserToGroup_Call {
+	return &MockUserPoolClient_AddUserToGroup_Call{Call: _e.mock.On("AddUserToGroup", ctx, username, group)}
+}
+
+func (_c *MockUserPoolClient_AddUserToGroup_Call) Run(run func(ctx context.Context, username string, group string)) *MockUserPoolClient_AddUserToGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_AddUserToGroup_Call) Return(_a0 error) *MockUserPoolClient_AddUserToGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_AddUserToGroup_Call) RunAndReturn(run func(context.Context, string, string) error) *MockUserPoolClient_AddUserToGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateGroup provides a mock function with given fields: ctx, group
+func (_m *MockUserPoolClient) CreateGroup(ctx context.Context, group *userpool.Group) error {
+	ret := _m.Called(ctx, group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *userpool.Group) error); ok {
+		r0 = rf(ctx, group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_CreateGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateGroup'
+type MockUserPoolClient_CreateGroup_Call struct {
+	*mock.Call
+}
+
+// CreateGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - group *userpool.Group
+func (_e *MockUserPoolClient_Expecter) CreateGroup(ctx interface{}, group interface{}) *MockUserPoolClient_CreateGroup_Call {
+	return &MockUserPoolClient_CreateGroup_Call{Call: _e.mock.On("CreateGroup", ctx, group)}
+}
+
+func (_c *MockUserPoolClient_CreateGroup_Call) Run(run func(ctx context.Context, group *userpool.Group)) *MockUserPoolClient_CreateGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*userpool.Group))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_CreateGroup_Call) Return(_a0 error) *MockUserPoolClient_CreateGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_CreateGroup_Call) RunAndReturn(run func(context.Context, *userpool.Group) error) *MockUserPoolClient_CreateGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateUser provides a mock function with given fields: ctx, user
 func (_m *MockUserPoolClient) CreateUser(ctx context.Context, user *userpool.User) (*userpool.User, error) {
 	ret := _m.Called(ctx, user)
@@ -82,6 +177,53 @@ func (_c *MockUserPoolClient_CreateUser_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// DeleteGroup provides a mock function with given fields: ctx, name
+func (_m *MockUserPoolClient) DeleteGroup(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_DeleteGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteGroup'
+type MockUserPoolClient_DeleteGroup_Call struct {
+	*mock.Call
+}
+
+// DeleteGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockUserPoolClient_Expecter) DeleteGroup(ctx interface{}, name interface{}) *MockUserPoolClient_DeleteGroup_Call {
+	return &MockUserPoolClient_DeleteGroup_Call{Call: _e.mock.On("DeleteGroup", ctx, name)}
+}
+
+func (_c *MockUserPoolClient_DeleteGroup_Call) Run(run func(ctx context.Context, name string)) *MockUserPoolClient_DeleteGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_DeleteGroup_Call) Return(_a0 error) *MockUserPoolClient_DeleteGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_DeleteGroup_Call) RunAndReturn(run func(context.Context, string) error) *MockUserPoolClient_DeleteGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DeleteUser provides a mock function with given fields: ctx, username
 func (_m *MockUserPoolClient) DeleteUser(ctx context.Context, username string) error {
 	ret := _m.Called(ctx, username)
@@ -129,6 +271,160 @@ func (_c *MockUserPoolClient_DeleteUser_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// DeleteUserAttributes provides a mock function with given fields: ctx, username, names
+func (_m *MockUserPoolClient) DeleteUserAttributes(ctx context.Context, username string, names []string) error {
+	ret := _m.Called(ctx, username, names)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserAttributes")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, username, names)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_DeleteUserAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUserAttributes'
+type MockUserPoolClient_DeleteUserAttributes_Call struct {
+	*mock.Call
+}
+
+// DeleteUserAttributes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - names []string
+func (_e *MockUserPoolClient_Expecter) DeleteUserAttributes(ctx interface{}, username interface{}, names interface{}) *MockUserPoolClient_DeleteUserAttributes_Call {
+	return &MockUserPoolClient_DeleteUserAttributes_Call{Call: _e.mock.On("DeleteUserAttributes", ctx, username, names)}
+}
+
+func (_c *MockUserPoolClient_DeleteUserAttributes_Call) Run(run func(ctx context.Context, username string, names []string)) *MockUserPoolClient_DeleteUserAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_DeleteUserAttributes_Call) Return(_a0 error) *MockUserPoolClient_DeleteUserAttributes_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_DeleteUserAttributes_Call) RunAndReturn(run func(context.Context, string, []string) error) *MockUserPoolClient_DeleteUserAttributes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DisableUser provides a mock function with given fields: ctx, username
+func (_m *MockUserPoolClient) DisableUser(ctx context.Context, username string) error {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_DisableUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DisableUser'
+type MockUserPoolClient_DisableUser_Call struct {
+	*mock.Call
+}
+
+// DisableUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *MockUserPoolClient_Expecter) DisableUser(ctx interface{}, username interface{}) *MockUserPoolClient_DisableUser_Call {
+	return &MockUserPoolClient_DisableUser_Call{Call: _e.mock.On("DisableUser", ctx, username)}
+}
+
+func (_c *MockUserPoolClient_DisableUser_Call) Run(run func(ctx context.Context, username string)) *MockUserPoolClient_DisableUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_DisableUser_Call) Return(_a0 error) *MockUserPoolClient_DisableUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_DisableUser_Call) RunAndReturn(run func(context.Context, string) error) *MockUserPoolClient_DisableUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGroup provides a mock function with given fields: ctx, name
+func (_m *MockUserPoolClient) GetGroup(ctx context.Context, name string) (*userpool.Group, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGroup")
+	}
+
+	var r0 *userpool.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*userpool.Group, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *userpool.Group); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*userpool.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserPoolClient_GetGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGroup'
+type MockUserPoolClient_GetGroup_Call struct {
+	*mock.Call
+}
+
+// GetGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockUserPoolClient_Expecter) GetGroup(ctx interface{}, name interface{}) *MockUserPoolClient_GetGroup_Call {
+	return &MockUserPoolClient_GetGroup_Call{Call: _e.mock.On("GetGroup", ctx, name)}
+}
+
+func (_c *MockUserPoolClient_GetGroup_Call) Run(run func(ctx context.Context, name string)) *MockUserPoolClient_GetGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_GetGroup_Call) Return(_a0 *userpool.Group, _a1 error) *MockUserPoolClient_GetGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserPoolClient_GetGroup_Call) RunAndReturn(run func(context.Context, string) (*userpool.Group, error)) *MockUserPoolClient_GetGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUser provides a mock function with given fields: ctx, username
 func (_m *MockUserPoolClient) GetUser(ctx context.Context, username string) (*userpool.User, error) {
 	ret := _m.Called(ctx, username)
@@ -188,6 +484,123 @@ func (_c *MockUserPoolClient_GetUser_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// ListGroupsForUser provides a mock function with given fields: ctx, username
+func (_m *MockUserPoolClient) ListGroupsForUser(ctx context.Context, username string) ([]string, error) {
+	ret := _m.Called(ctx, username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListGroupsForUser")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]string, error)); ok {
+		return rf(ctx, username)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserPoolClient_ListGroupsForUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListGroupsForUser'
+type MockUserPoolClient_ListGroupsForUser_Call struct {
+	*mock.Call
+}
+
+// ListGroupsForUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+func (_e *MockUserPoolClient_Expecter) ListGroupsForUser(ctx interface{}, username interface{}) *MockUserPoolClient_ListGroupsForUser_Call {
+	return &MockUserPoolClient_ListGroupsForUser_Call{Call: _e.mock.On("ListGroupsForUser", ctx, username)}
+}
+
+func (_c *MockUserPoolClient_ListGroupsForUser_Call) Run(run func(ctx context.Context, username string)) *MockUserPoolClient_ListGroupsForUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_ListGroupsForUser_Call) Return(_a0 []string, _a1 error) *MockUserPoolClient_ListGroupsForUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserPoolClient_ListGroupsForUser_Call) RunAndReturn(run func(context.Context, string) ([]string, error)) *MockUserPoolClient_ListGroupsForUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListSchemaAttributes provides a mock function with given fields: ctx
+func (_m *MockUserPoolClient) ListSchemaAttributes(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSchemaAttributes")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockUserPoolClient_ListSchemaAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSchemaAttributes'
+type MockUserPoolClient_ListSchemaAttributes_Call struct {
+	*mock.Call
+}
+
+// ListSchemaAttributes is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockUserPoolClient_Expecter) ListSchemaAttributes(ctx interface{}) *MockUserPoolClient_ListSchemaAttributes_Call {
+	return &MockUserPoolClient_ListSchemaAttributes_Call{Call: _e.mock.On("ListSchemaAttributes", ctx)}
+}
+
+func (_c *MockUserPoolClient_ListSchemaAttributes_Call) Run(run func(ctx context.Context)) *MockUserPoolClient_ListSchemaAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_ListSchemaAttributes_Call) Return(_a0 []string, _a1 error) *MockUserPoolClient_ListSchemaAttributes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockUserPoolClient_ListSchemaAttributes_Call) RunAndReturn(run func(context.Context) ([]string, error)) *MockUserPoolClient_ListSchemaAttributes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListUsers provides a mock function with given fields: ctx
 func (_m *MockUserPoolClient) ListUsers(ctx context.Context) ([]*userpool.User, error) {
 	ret := _m.Called(ctx)
@@ -246,6 +659,151 @@ func (_c *MockUserPoolClient_ListUsers_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// RemoveUserFromGroup provides a mock function with given fields: ctx, username, group
+func (_m *MockUserPoolClient) RemoveUserFromGroup(ctx context.Context, username string, group string) error {
+	ret := _m.Called(ctx, username, group)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUserFromGroup")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, username, group)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_RemoveUserFromGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveUserFromGroup'
+type MockUserPoolClient_RemoveUserFromGroup_Call struct {
+	*mock.Call
+}
+
+// RemoveUserFromGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - group string
+func (_e *MockUserPoolClient_Expecter) RemoveUserFromGroup(ctx interface{}, username interface{}, group interface{}) *MockUserPoolClient_RemoveUserFromGroup_Call {
+	return &MockUserPoolClient_RemoveUserFromGroup_Call{Call: _e.mock.On("RemoveUserFromGroup", ctx, username, group)}
+}
+
+func (_c *MockUserPoolClient_RemoveUserFromGroup_Call) Run(run func(ctx context.Context, username string, group string)) *MockUserPoolClient_RemoveUserFromGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_RemoveUserFromGroup_Call) Return(_a0 error) *MockUserPoolClient_RemoveUserFromGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_RemoveUserFromGroup_Call) RunAndReturn(run func(context.Context, string, string) error) *MockUserPoolClient_RemoveUserFromGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResendInvitation provides a mock function with given fields: ctx, username, deliveryMediums
+func (_m *MockUserPoolClient) ResendInvitation(ctx context.Context, username string, deliveryMediums []string) error {
+	ret := _m.Called(ctx, username, deliveryMediums)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResendInvitation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) error); ok {
+		r0 = rf(ctx, username, deliveryMediums)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_ResendInvitation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResendInvitation'
+type MockUserPoolClient_ResendInvitation_Call struct {
+	*mock.Call
+}
+
+// ResendInvitation is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - deliveryMediums []string
+func (_e *MockUserPoolClient_Expecter) ResendInvitation(ctx interface{}, username interface{}, deliveryMediums interface{}) *MockUserPoolClient_ResendInvitation_Call {
+	return &MockUserPoolClient_ResendInvitation_Call{Call: _e.mock.On("ResendInvitation", ctx, username, deliveryMediums)}
+}
+
+func (_c *MockUserPoolClient_ResendInvitation_Call) Run(run func(ctx context.Context, username string, deliveryMediums []string)) *MockUserPoolClient_ResendInvitation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].([]string))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_ResendInvitation_Call) Return(_a0 error) *MockUserPoolClient_ResendInvitation_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_ResendInvitation_Call) RunAndReturn(run func(context.Context, string, []string) error) *MockUserPoolClient_ResendInvitation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetUserPassword provides a mock function with given fields: ctx, username, password, permanent
+func (_m *MockUserPoolClient) SetUserPassword(ctx context.Context, username string, password string, permanent bool) error {
+	ret := _m.Called(ctx, username, password, permanent)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUserPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool) error); ok {
+		r0 = rf(ctx, username, password, permanent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockUserPoolClient_SetUserPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUserPassword'
+type MockUserPoolClient_SetUserPassword_Call struct {
+	*mock.Call
+}
+
+// SetUserPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - username string
+//   - password string
+//   - permanent bool
+func (_e *MockUserPoolClient_Expecter) SetUserPassword(ctx interface{}, username interface{}, password interface{}, permanent interface{}) *MockUserPoolClient_SetUserPassword_Call {
+	return &MockUserPoolClient_SetUserPassword_Call{Call: _e.mock.On("SetUserPassword", ctx, username, password, permanent)}
+}
+
+func (_c *MockUserPoolClient_SetUserPassword_Call) Run(run func(ctx context.Context, username string, password string, permanent bool)) *MockUserPoolClient_SetUserPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(bool))
+	})
+	return _c
+}
+
+func (_c *MockUserPoolClient_SetUserPassword_Call) Return(_a0 error) *MockUserPoolClient_SetUserPassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockUserPoolClient_SetUserPassword_Call) RunAndReturn(run func(context.Context, string, string, bool) error) *MockUserPoolClient_SetUserPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateUser provides a mock function with given fields: ctx, user
 func (_m *MockUserPoolClient) UpdateUser(ctx context.Context, user *userpool.User) error {
 	ret := _m.Called(ctx, user)
@@ -0,0 +1,373 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// DefaultInventoryName is the name of the UserPoolInventory maintained by the OrphanCollector
+const DefaultInventoryName = "default"
+
+var (
+	userPoolIdentities = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kcp_users_userpool_identities",
+		Help: "Identities in the user pool by ownership: managed, unmanaged or orphaned.",
+	}, []string{"state"})
+	orphansCollected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kcp_users_orphans_collected_total",
+		Help: "Orphaned identities disabled or deleted in the user pool.",
+	}, []string{"action"})
+	orphanCollectionErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "kcp_users_orphan_collection_errors_total",
+		Help: "Orphan collection runs that failed.",
+	})
+	orphanCollectionLastRun = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kcp_users_orphan_collection_last_run_timestamp_seconds",
+		Help: "Time of the last successful orphan collection run.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(userPoolIdentities, orphansCollected, orphanCollectionErrors,
+		orphanCollectionLastRun)
+}
+
+// OrphanCollector finds identities in the user pool whose ownership marker names a User that no
+// longer exists in its engaged workspace, e.g. because its finalizer was removed by hand or the
+// deletion from the user pool failed. Orphans are reported, disabled or deleted once they have been
+// orphaned for the grace period. Identities without an ownership marker, marked by another
+// controller instance or owned by a workspace that is not engaged are never touched.
+//
+// Only UserPoolClient, the controller-wide user pool, is inventoried. User pools selected with a
+// UserPoolBinding are not scanned.
+type OrphanCollector struct {
+	UserPoolClient userpool.Client
+
+	// Action is taken on orphans once their grace period has passed. Defaults to Report.
+	Action kcpv1alpha1.OrphanAction
+
+	// InstanceID identifies this controller in ownership markers. Identities of other instances
+	// sharing the user pool count as unmanaged.
+	InstanceID string
+
+	// GracePeriod is how long an identity must stay orphaned before Action is taken
+	GracePeriod time.Duration
+
+	// Interval is the time between collection runs
+	Interval time.Duration
+
+	// InventoryCluster is the workspace the UserPoolInventory is written to. No inventory is
+	// written when empty.
+	InventoryCluster string
+
+	// InventoryName is the name of the UserPoolInventory. Defaults to DefaultInventoryName.
+	InventoryName string
+
+	mu        sync.Mutex
+	clusters  map[string]cluster.Cluster
+	firstSeen map[string]time.Time
+}
+
+var _ mcmanager.Runnable = &OrphanCollector{}
+var _ manager.LeaderElectionRunnable = &OrphanCollector{}
+
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=userpoolinventories,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=userpoolinventories/status,verbs=get;update;patch
+
+// Engage tracks a workspace whose Users own identities, until ctx is done
+func (c *OrphanCollector) Engage(ctx context.Context, name string, cl cluster.Cluster) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clusters == nil {
+		c.clusters = map[string]cluster.Cluster{}
+	}
+	c.clusters[name] = cl
+
+	go func() {
+		<-ctx.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.clusters[name] == cl {
+			delete(c.clusters, name)
+		}
+	}()
+	return nil
+}
+
+// Start collects orphans every Interval until ctx is done. The first run waits one interval so
+// that all workspaces have been engaged.
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("orphan-collector")
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if _, err := c.Collect(logf.IntoContext(ctx, log)); err != nil {
+			orphanCollectionErrors.Inc()
+			log.Error(err, "Failed to collect orphaned identities")
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (c *OrphanCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect runs a single collection and returns the resulting inventory, which is also written to
+// the UserPoolInventory when one is configured
+func (c *OrphanCollector) Collect(ctx context.Context) (*kcpv1alpha1.UserPoolInventoryStatus, error) {
+	log := logf.FromContext(ctx)
+	clusters := c.engagedClusters()
+	if len(clusters) == 0 {
+		// Without any workspace every identity would look orphaned
+		log.Info("No workspaces engaged yet, skipping orphan collection")
+		return nil, nil
+	}
+
+	inventory, err := c.getInventory(ctx, clusters)
+	if err != nil {
+		return nil, err
+	}
+	// The previous inventory is kept when the user pool cannot be listed
+	status := &kcpv1alpha1.UserPoolInventoryStatus{}
+	if inventory != nil {
+		status = inventory.Status.DeepCopy()
+	}
+	collectErr := c.inventory(ctx, clusters, status, log)
+	if collectErr != nil {
+		setCondition(&status.Conditions, kcpv1alpha1.UserPoolInventoryReadyCondition, metav1.ConditionFalse,
+			failureReason(collectErr, "CollectionFailed"), collectErr.Error())
+	} else {
+		setCondition(&status.Conditions, kcpv1alpha1.UserPoolInventoryReadyCondition, metav1.ConditionTrue,
+			"Collected", fmt.Sprintf("%d of %d identities are orphaned", status.Orphaned, status.Identities))
+		orphanCollectionLastRun.SetToCurrentTime()
+	}
+
+	if inventory != nil {
+		inventory.Status = *status
+		if err := clusters[c.InventoryCluster].GetClient().Status().Update(ctx, inventory); err != nil {
+			return status, fmt.Errorf("failed to update UserPoolInventory: %w", err)
+		}
+	}
+	return status, collectErr
+}
+
+// inventory replaces status with the classified identities of the user pool and acts on orphans
+// past their grace period
+func (c *OrphanCollector) inventory(ctx context.Context, clusters map[string]cluster.Cluster,
+	status *kcpv1alpha1.UserPoolInventoryStatus, log logr.Logger) error {
+
+	// Subs of the identities owned by the Users of every workspace, by ownership marker. Workspaces
+	// whose Users cannot be listed are left out of this run.
+	owners := map[string]string{}
+	unavailable := map[string]bool{}
+	for name, cl := range clusters {
+		var users kcpv1alpha1.UserList
+		if err := cl.GetClient().List(ctx, &users); err != nil {
+			log.Error(err, "Failed to list Users, skipping workspace", "cluster", name)
+			unavailable[name] = true
+			continue
+		}
+		for _, user := range users.Items {
+			owners[name+"/"+user.Namespace+"/"+user.Name] = user.Status.Sub
+		}
+	}
+
+	poolUsers, err := c.UserPoolClient.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users of user pool: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.firstSeen == nil {
+		c.firstSeen = map[string]time.Time{}
+	}
+	// Orphans found before a restart keep their grace period
+	for _, orphan := range status.Orphans {
+		if seen, ok := c.firstSeen[orphan.Sub]; !ok || orphan.FirstSeenTime.Time.Before(seen) {
+			c.firstSeen[orphan.Sub] = orphan.FirstSeenTime.Time
+		}
+	}
+	now := metav1.Now()
+	*status = kcpv1alpha1.UserPoolInventoryStatus{LastRunTime: &now, Conditions: status.Conditions}
+
+	orphaned := map[string]bool{}
+	var failures []error
+	for _, poolUser := range poolUsers {
+		status.Identities++
+		owner := poolUser.Attributes[userpool.OwnerAttribute]
+		instance := poolUser.Attributes[userpool.InstanceAttribute]
+		if owner == "" || (instance != "" && c.InstanceID != "" && instance != c.InstanceID) {
+			status.Unmanaged++
+			continue
+		}
+		reason := orphanReason(owner, poolUser.Sub, clusters, owners, unavailable)
+		if reason == "" {
+			status.Managed++
+			continue
+		}
+
+		status.Orphaned++
+		orphaned[poolUser.Sub] = true
+		if _, ok := c.firstSeen[poolUser.Sub]; !ok {
+			c.firstSeen[poolUser.Sub] = now.Time
+		}
+		orphan := kcpv1alpha1.OrphanedIdentity{
+			Sub:           poolUser.Sub,
+			Email:         poolUser.Email,
+			Owner:         owner,
+			Reason:        reason,
+			FirstSeenTime: metav1.NewTime(c.firstSeen[poolUser.Sub]),
+		}
+		if now.Sub(orphan.FirstSeenTime.Time) >= c.GracePeriod {
+			if err := c.collect(ctx, poolUser, &orphan, log); err != nil {
+				failures = append(failures, err)
+			}
+		}
+		status.Orphans = append(status.Orphans, orphan)
+	}
+	for sub := range c.firstSeen {
+		if !orphaned[sub] {
+			delete(c.firstSeen, sub)
+		}
+	}
+	sort.Slice(status.Orphans, func(i, j int) bool { return status.Orphans[i].Sub < status.Orphans[j].Sub })
+
+	userPoolIdentities.WithLabelValues("managed").Set(float64(status.Managed))
+	userPoolIdentities.WithLabelValues("unmanaged").Set(float64(status.Unmanaged))
+	userPoolIdentities.WithLabelValues("orphaned").Set(float64(status.Orphaned))
+	return stderrors.Join(failures...)
+}
+
+// collect takes the configured action on an orphan past its grace period
+func (c *OrphanCollector) collect(ctx context.Context, poolUser *userpool.User,
+	orphan *kcpv1alpha1.OrphanedIdentity, log logr.Logger) error {
+	switch c.Action {
+	case kcpv1alpha1.OrphanActionDisable:
+		if poolUser.Enabled {
+			if err := c.UserPoolClient.DisableUser(ctx, poolUser.Sub); err != nil {
+				return fmt.Errorf("failed to disable orphaned user %s: %w", poolUser.Sub, err)
+			}
+			orphansCollected.WithLabelValues(string(kcpv1alpha1.OrphanActionDisable)).Inc()
+			log.Info("Disabled orphaned user", "sub", poolUser.Sub, "owner", orphan.Owner, "reason", orphan.Reason)
+		}
+		orphan.Action = kcpv1alpha1.OrphanActionDisable
+	case kcpv1alpha1.OrphanActionDelete:
+		if err := c.UserPoolClient.DeleteUser(ctx, poolUser.Sub); err != nil {
+			return fmt.Errorf("failed to delete orphaned user %s: %w", poolUser.Sub, err)
+		}
+		orphansCollected.WithLabelValues(string(kcpv1alpha1.OrphanActionDelete)).Inc()
+		log.Info("Deleted orphaned user", "sub", poolUser.Sub, "owner", orphan.Owner, "reason", orphan.Reason)
+		orphan.Action = kcpv1alpha1.OrphanActionDelete
+	default:
+		orphan.Action = kcpv1alpha1.OrphanActionReport
+	}
+	return nil
+}
+
+// orphanReason returns why the owner of an identity no longer matches it, or an empty string when
+// the identity is owned or its workspace could not be checked. Workspaces that are not engaged may
+// just be reconnecting, so their identities are never considered orphaned.
+func orphanReason(owner, sub string, clusters map[string]cluster.Cluster, owners map[string]string,
+	unavailable map[string]bool) string {
+	clusterName, _, _ := strings.Cut(owner, "/")
+	if _, ok := clusters[clusterName]; !ok || unavailable[clusterName] {
+		return ""
+	}
+	ownerSub, ok := owners[owner]
+	if !ok {
+		return fmt.Sprintf("User %s does not exist", strings.TrimPrefix(owner, clusterName+"/"))
+	}
+	// Users that have not recorded their sub yet may still be creating or adopting the identity
+	if ownerSub != "" && ownerSub != sub {
+		return fmt.Sprintf("User %s owns identity %s", strings.TrimPrefix(owner, clusterName+"/"), ownerSub)
+	}
+	return ""
+}
+
+// engagedClusters returns a snapshot of the engaged workspaces
+func (c *OrphanCollector) engagedClusters() map[string]cluster.Cluster {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clusters := make(map[string]cluster.Cluster, len(c.clusters))
+	for name, cl := range c.clusters {
+		clusters[name] = cl
+	}
+	return clusters
+}
+
+// getInventory returns the UserPoolInventory from its engaged workspace, creating it when missing.
+// It returns nil when no inventory is configured.
+func (c *OrphanCollector) getInventory(ctx context.Context,
+	clusters map[string]cluster.Cluster) (*kcpv1alpha1.UserPoolInventory, error) {
+	if c.InventoryCluster == "" {
+		return nil, nil
+	}
+	inventoryCluster, ok := clusters[c.InventoryCluster]
+	if !ok {
+		return nil, fmt.Errorf("inventory workspace %s is not engaged", c.InventoryCluster)
+	}
+	cl := inventoryCluster.GetClient()
+
+	name := c.InventoryName
+	if name == "" {
+		name = DefaultInventoryName
+	}
+	inventory := &kcpv1alpha1.UserPoolInventory{}
+	err := cl.Get(ctx, client.ObjectKey{Name: name}, inventory)
+	if errors.IsNotFound(err) {
+		inventory.Name = name
+		if err := cl.Create(ctx, inventory); err != nil {
+			return nil, fmt.Errorf("failed to create UserPoolInventory: %w", err)
+		}
+		return inventory, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UserPoolInventory: %w", err)
+	}
+	return inventory, nil
+}
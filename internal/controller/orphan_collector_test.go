@@ -0,0 +1,245 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
+)
+
+// fakeCluster is an engaged workspace backed by a fake client
+type fakeCluster struct {
+	cluster.Cluster
+	client client.Client
+}
+
+func (c *fakeCluster) GetClient() client.Client {
+	return c.client
+}
+
+func newFakeCluster(t *testing.T, objects ...client.Object) *fakeCluster {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+	return &fakeCluster{client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithStatusSubresource(&kcpv1alpha1.User{}, &kcpv1alpha1.UserPoolInventory{}).Build()}
+}
+
+func TestOrphanCollector(t *testing.T) {
+	ctx := context.Background()
+
+	// newPool returns a user pool with one identity per ownership case and the sub of the identity
+	// owned by root:team-a/default/jane
+	newPool := func(t *testing.T) (*memory.Client, string) {
+		pool := memory.NewClient()
+		var janeSub string
+		for email, owner := range map[string]string{
+			"jane@example.com":    "team-a/default/jane",
+			"pending@example.com": "team-a/default/pending",
+			"gone@example.com":    "team-a/default/gone",
+			"moved@example.com":   "team-a/default/moved",
+			"unbound@example.com": "team-c/default/john",
+			"manual@example.com":  "",
+			"foreign@example.com": "team-d/default/john",
+		} {
+			attributes := map[string]string{}
+			if owner != "" {
+				attributes[userpool.OwnerAttribute] = owner
+				attributes[userpool.InstanceAttribute] = "eu-1"
+			}
+			if email == "foreign@example.com" {
+				attributes[userpool.InstanceAttribute] = "us-1"
+			}
+			created, err := pool.CreateUser(ctx, &userpool.User{Email: email, Enabled: true, Attributes: attributes})
+			require.NoError(t, err)
+			if email == "jane@example.com" {
+				janeSub = created.Sub
+			}
+		}
+		return pool, janeSub
+	}
+	newCollector := func(t *testing.T, pool userpool.Client, janeSub string) *OrphanCollector {
+		collector := &OrphanCollector{UserPoolClient: pool, GracePeriod: time.Hour, InstanceID: "eu-1"}
+		require.NoError(t, collector.Engage(ctx, "team-a", newFakeCluster(t,
+			&kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "jane"},
+				Status:     kcpv1alpha1.UserStatus{Sub: janeSub},
+			},
+			&kcpv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pending"}},
+			&kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "moved"},
+				Status:     kcpv1alpha1.UserStatus{Sub: "other-sub"},
+			},
+		)))
+		require.NoError(t, collector.Engage(ctx, "team-b", newFakeCluster(t)))
+		return collector
+	}
+
+	t.Run("classifies identities", func(t *testing.T) {
+		pool, janeSub := newPool(t)
+		collector := newCollector(t, pool, janeSub)
+
+		status, err := collector.Collect(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), status.Identities)
+		assert.Equal(t, int32(3), status.Managed,
+			"owned identities, Users without a sub yet and workspaces that are not engaged are managed")
+		assert.Equal(t, int32(2), status.Unmanaged, "unmarked identities and those of other instances are unmanaged")
+		assert.Equal(t, int32(2), status.Orphaned)
+
+		reasons := map[string]string{}
+		for _, orphan := range status.Orphans {
+			reasons[orphan.Email] = orphan.Reason
+			assert.Empty(t, orphan.Action, "orphans within their grace period are left alone")
+		}
+		assert.Equal(t, map[string]string{
+			"gone@example.com":  "User default/gone does not exist",
+			"moved@example.com": "User default/moved owns identity other-sub",
+		}, reasons, "identities of workspaces that are not engaged are never orphaned")
+		ready := meta.FindStatusCondition(status.Conditions, kcpv1alpha1.UserPoolInventoryReadyCondition)
+		require.NotNil(t, ready)
+		assert.Equal(t, metav1.ConditionTrue, ready.Status)
+		assert.Equal(t, "2 of 7 identities are orphaned", ready.Message)
+	})
+
+	t.Run("deletes orphans after the grace period", func(t *testing.T) {
+		pool, janeSub := newPool(t)
+		collector := newCollector(t, pool, janeSub)
+		collector.Action = kcpv1alpha1.OrphanActionDelete
+		_, err := collector.Collect(ctx)
+		require.NoError(t, err)
+		for sub := range collector.firstSeen {
+			collector.firstSeen[sub] = time.Now().Add(-2 * time.Hour)
+		}
+
+		status, err := collector.Collect(ctx)
+
+		require.NoError(t, err)
+		for _, orphan := range status.Orphans {
+			assert.Equal(t, kcpv1alpha1.OrphanActionDelete, orphan.Action)
+		}
+		users, err := pool.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 5)
+
+		status, err = collector.Collect(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, status.Orphaned)
+		assert.Empty(t, collector.firstSeen, "collected orphans are forgotten")
+	})
+
+	t.Run("disables orphans", func(t *testing.T) {
+		pool, janeSub := newPool(t)
+		collector := newCollector(t, pool, janeSub)
+		collector.Action = kcpv1alpha1.OrphanActionDisable
+		collector.GracePeriod = 0
+
+		status, err := collector.Collect(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, status.Orphans, 2)
+		for _, orphan := range status.Orphans {
+			assert.Equal(t, kcpv1alpha1.OrphanActionDisable, orphan.Action)
+			user, err := pool.GetUser(ctx, orphan.Sub)
+			require.NoError(t, err)
+			assert.False(t, user.Enabled)
+		}
+		jane, err := pool.GetUser(ctx, janeSub)
+		require.NoError(t, err)
+		assert.True(t, jane.Enabled)
+	})
+
+	t.Run("writes the inventory", func(t *testing.T) {
+		pool, janeSub := newPool(t)
+		collector := newCollector(t, pool, janeSub)
+		collector.InventoryCluster = "team-b"
+		_, err := collector.Collect(ctx)
+		require.NoError(t, err)
+
+		var inventory kcpv1alpha1.UserPoolInventory
+		inventoryClient := collector.engagedClusters()["team-b"].GetClient()
+		require.NoError(t, inventoryClient.Get(ctx, client.ObjectKey{Name: DefaultInventoryName}, &inventory))
+		assert.Equal(t, int32(2), inventory.Status.Orphaned)
+		require.Len(t, inventory.Status.Orphans, 2)
+
+		// A restarted collector resumes the grace period from the inventory
+		firstSeen := metav1.NewTime(time.Now().Add(-2 * time.Hour).Truncate(time.Second))
+		for i := range inventory.Status.Orphans {
+			inventory.Status.Orphans[i].FirstSeenTime = firstSeen
+		}
+		require.NoError(t, inventoryClient.Status().Update(ctx, &inventory))
+		restarted := &OrphanCollector{UserPoolClient: pool, GracePeriod: time.Hour, InstanceID: "eu-1",
+			InventoryCluster: "team-b", clusters: collector.engagedClusters()}
+
+		status, err := restarted.Collect(ctx)
+
+		require.NoError(t, err)
+		for _, orphan := range status.Orphans {
+			assert.Equal(t, firstSeen.Unix(), orphan.FirstSeenTime.Unix())
+			assert.Equal(t, kcpv1alpha1.OrphanActionReport, orphan.Action)
+		}
+	})
+
+	t.Run("keeps the inventory when the user pool fails", func(t *testing.T) {
+		pool, janeSub := newPool(t)
+		collector := newCollector(t, pool, janeSub)
+		collector.InventoryCluster = "team-b"
+		_, err := collector.Collect(ctx)
+		require.NoError(t, err)
+		pool.InjectError("ListUsers", assert.AnError)
+
+		status, err := collector.Collect(ctx)
+
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, int32(2), status.Orphaned)
+		ready := meta.FindStatusCondition(status.Conditions, kcpv1alpha1.UserPoolInventoryReadyCondition)
+		require.NotNil(t, ready)
+		assert.Equal(t, "CollectionFailed", ready.Reason)
+	})
+
+	t.Run("skips runs without engaged workspaces", func(t *testing.T) {
+		pool, _ := newPool(t)
+		collector := &OrphanCollector{UserPoolClient: pool, Action: kcpv1alpha1.OrphanActionDelete}
+		engaged, disengage := context.WithCancel(ctx)
+		require.NoError(t, collector.Engage(engaged, "team-a", newFakeCluster(t)))
+		disengage()
+		require.Eventually(t, func() bool { return len(collector.engagedClusters()) == 0 },
+			time.Second, 10*time.Millisecond)
+
+		status, err := collector.Collect(ctx)
+
+		require.NoError(t, err)
+		assert.Nil(t, status)
+		users, err := pool.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 7)
+	})
+}
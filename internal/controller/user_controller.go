@@ -18,19 +18,29 @@ package controller
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/kcp-dev/logicalcluster/v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mcbuilder "sigs.k8s.io/multicluster-runtime/pkg/builder"
+	mchandler "sigs.k8s.io/multicluster-runtime/pkg/handler"
 	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
 	mcreconcile "sigs.k8s.io/multicluster-runtime/pkg/reconcile"
 
@@ -38,17 +48,77 @@ import (
 	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
 )
 
+const (
+	// defaultPasswordSecretKey is the Secret key read when passwordSecretRef.key is empty
+	defaultPasswordSecretKey = "password"
+	// passwordCheckInterval is how often a pending temporary password is checked for consumption
+	passwordCheckInterval = 10 * time.Minute
+	// defaultTemporaryPasswordValidity matches the Cognito default of seven days
+	defaultTemporaryPasswordValidity = 7 * 24 * time.Hour
+	// credentialsFailedReason is the condition reason used when the user pool credentials cannot be obtained
+	credentialsFailedReason = "CredentialsFailed"
+	// credentialsRequiredReason is the condition reason used when a UserPoolBinding must bring its own
+	// credentials to manage its user pool
+	credentialsRequiredReason = "CredentialsRequired"
+	// roleNotAllowedReason is the condition reason used when a UserPoolBinding assumes a role the
+	// operator does not allow
+	roleNotAllowedReason = "RoleNotAllowed"
+	// ownershipConflictReason is the condition reason used when the ownership markers of the
+	// identity name another User or controller instance
+	ownershipConflictReason = "OwnershipConflict"
+	// userPoolChangedReason is the condition reason used when the workspace is bound to another user
+	// pool than the one the User was synced to
+	userPoolChangedReason = "UserPoolChanged"
+)
+
+// DefaultInstanceID identifies the controller in ownership markers unless configured otherwise
+const DefaultInstanceID = "kcp-users-controller"
+
 // UserReconciler reconciles a User object
 type UserReconciler struct {
 	client.Client
 	Scheme         *runtime.Scheme
 	Manager        mcmanager.Manager
 	UserPoolClient userpool.Client
+
+	// UserPools resolves the user pool of workspaces that have a UserPoolBinding.
+	// UserPoolClient is used for all other workspaces.
+	UserPools *UserPoolResolver
+
+	// DefaultDeletionPolicy applies to Users that do not set spec.deletionPolicy.
+	// Defaults to Delete.
+	DefaultDeletionPolicy kcpv1alpha1.DeletionPolicy
+
+	// TemporaryPasswordValidity is how long temporary passwords sent with invitations stay valid.
+	// It should match the user pool's password policy and defaults to seven days.
+	TemporaryPasswordValidity time.Duration
+
+	// ResyncInterval is how often synced Users are compared with the user pool to detect and
+	// correct changes made outside kcp. Zero disables drift detection.
+	ResyncInterval time.Duration
+
+	// InstanceID identifies this controller in the ownership markers of the identities it manages.
+	// Identities marked by another instance are never updated, disabled or deleted.
+	InstanceID string
+
+	// Usernames decides the usernames of the identities created in user pools that keep the username
+	// they are given. Nil uses the email.
+	Usernames *Usernames
+
+	// EmailClaims makes Users claim their email before it is synced, so that Users of different
+	// workspaces cannot share an identity. Nil disables claiming.
+	EmailClaims *EmailClaims
+
+	// GetClient returns a client for a workspace engaged through the APIExport. It is used to check
+	// that the User named by the ownership marker of an identity is gone before the identity is
+	// adopted with the Always policy, which is refused when nil.
+	GetClient func(ctx context.Context, clusterName string) (client.Client, error)
 }
 
 // +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=users,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=users/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=users/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -79,20 +149,45 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req mcreconcile.Request)
 	}
 
 	// Skip reconciliation if generation hasn't changed and status is up to date
-	// Only skip if not being deleted (DeletionTimestamp is nil)
-	if user.DeletionTimestamp == nil && user.Status.ObservedGeneration == user.Generation {
+	// Only skip if not being deleted (DeletionTimestamp is nil), no temporary
+	// password is waiting to be consumed, the password Secret is unchanged and no drift check is due
+	upToDate := user.Status.ObservedGeneration == user.Generation
+	awaitingPasswordChange := user.Status.PasswordStatus == kcpv1alpha1.PasswordStatusTemporary
+	resyncDue := upToDate && r.resyncDue(&user)
+	if user.DeletionTimestamp == nil && upToDate && !awaitingPasswordChange && !resyncDue &&
+		!passwordSecretChanged(ctx, clusterClient, &user) {
 		log.Info("Resource unchanged, skipping reconciliation",
 			"generation", user.Generation,
 			"observedGeneration", user.Status.ObservedGeneration)
-		return ctrl.Result{}, nil
+		return ctrl.Result{RequeueAfter: r.nextResync(&user)}, nil
+	}
+
+	// Talk to the user pool bound to the workspace, if it has one
+	poolClient, err := resolveUserPoolClient(ctx, r.UserPools, req.ClusterName, clusterClient, r.UserPoolClient)
+	if err != nil {
+		log.Error(err, "Failed to resolve user pool of workspace")
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, err
 	}
+	r = r.withUserPoolClient(poolClient)
+	userPoolChanged := r.userPoolChanged(&user)
 
 	// Handle finalizer for cleanup before deletion
 	finalizerName := "kcp.cogniteo.io/user-pool-cleanup"
 	if user.DeletionTimestamp != nil {
-		// User is being deleted, run cleanup
-		if r.UserPoolClient != nil {
-			r.deleteUserFromUserPool(ctx, user.Name, user.Status.Sub, log)
+		// User is being deleted, run cleanup according to the deletion policy. The identity is left
+		// alone when the workspace is no longer bound to its user pool.
+		if userPoolChanged {
+			log.Info("User pool of workspace changed, leaving identity in previous user pool", "username", user.Name,
+				"sub", user.Status.Sub, "userPool", user.Status.UserPool)
+		} else if r.UserPoolClient != nil {
+			if err := r.cleanupUserInUserPool(ctx, &user, log); err != nil {
+				log.Error(err, "Failed to clean up user in user pool")
+				return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+			}
+		}
+		if err := r.releaseEmailClaims(ctx, &user); err != nil {
+			log.Error(err, "Failed to release email claim")
+			return ctrl.Result{RequeueAfter: time.Minute * 5}, err
 		}
 
 		// Remove finalizer
@@ -114,14 +209,48 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req mcreconcile.Request)
 		}
 	}
 
+	// Users are neither recreated in nor synced to a user pool they were not synced to
+	if userPoolChanged {
+		message := fmt.Sprintf("User was synced to %s but the workspace is now bound to %s",
+			userPoolName(user.Status.UserPool), userPoolName(userPoolScope(r.UserPoolClient)))
+		log.Info("User pool of workspace changed, refusing to sync user", "username", user.Name,
+			"userPool", user.Status.UserPool)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse,
+			userPoolChangedReason, message)
+		if statusErr := clusterClient.Status().Update(ctx, &user); statusErr != nil {
+			log.Error(statusErr, "Failed to update User status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, fmt.Errorf("refused to sync user: %s", message)
+	}
+
 	// Sync user with user pool
-	if r.UserPoolClient != nil {
+	if r.UserPoolClient != nil && !upToDate {
 		if err := r.syncUserWithUserPool(ctx, &user, log); err != nil {
 			log.Error(err, "Failed to sync user with user pool")
+			if statusErr := clusterClient.Status().Update(ctx, &user); statusErr != nil {
+				log.Error(statusErr, "Failed to update User status")
+			}
+			return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+		}
+	} else if r.UserPoolClient != nil && resyncDue {
+		if err := r.correctDrift(ctx, &user, log); err != nil {
+			log.Error(err, "Failed to correct drift of user")
+			if statusErr := clusterClient.Status().Update(ctx, &user); statusErr != nil {
+				log.Error(statusErr, "Failed to update User status")
+			}
 			return ctrl.Result{RequeueAfter: time.Minute * 5}, err
 		}
 	}
 
+	// Apply the password from the referenced Secret and track whether it has been consumed
+	if err := r.syncUserPassword(ctx, clusterClient, &user, log); err != nil {
+		log.Error(err, "Failed to sync user password")
+		if statusErr := clusterClient.Status().Update(ctx, &user); statusErr != nil {
+			log.Error(statusErr, "Failed to update User status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+	}
+
 	// Update the observed generation to indicate we've processed this version
 	user.Status.ObservedGeneration = user.Generation
 
@@ -144,7 +273,13 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req mcreconcile.Request)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Keep polling until the user replaces the temporary password
+	requeueAfter := r.nextResync(&user)
+	if user.Status.PasswordStatus == kcpv1alpha1.PasswordStatusTemporary &&
+		(requeueAfter == 0 || passwordCheckInterval < requeueAfter) {
+		requeueAfter = passwordCheckInterval
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // syncUserWithUserPool synchronizes a Kubernetes User with User Pool
@@ -155,73 +290,809 @@ func (r *UserReconciler) syncUserWithUserPool(ctx context.Context, user *kcpv1al
 		return nil
 	}
 
+	references, err := r.claimEmail(ctx, user, log)
+	if err != nil {
+		return err
+	}
+	poolUser, groups, err := r.desiredIdentity(ctx, user, references)
+	if err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to read Users sharing the identity", err)
+		return fmt.Errorf("failed to read Users sharing the identity: %w", err)
+	}
+
+	created, adopted := false, false
+	if user.Status.Sub == "" {
+		var err error
+		if created, err = r.createUserInUserPool(ctx, user, poolUser, log); err != nil {
+			return err
+		}
+		adopted = !created
+	}
+
+	// Adopted users are updated right away to match the spec. Their ownership markers have been
+	// checked by the adoption policy and are replaced.
+	if !created {
+		if err := r.updateUserInUserPool(ctx, user, poolUser, !adopted, log); err != nil {
+			return err
+		}
+	}
+
+	if err := r.syncUserGroups(ctx, user, groups, log); err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to sync group membership", err)
+		return fmt.Errorf("failed to sync group membership: %w", err)
+	}
+
+	now := metav1.Now()
+	user.Status.LastSyncTime = &now
+	return nil
+}
+
+// claimEmail claims the email of the User among the workspaces sharing its user pool and releases
+// the previously claimed email when it changed. Users whose email is claimed by another User are
+// not synced. For shared identities it returns the Users referencing the identity in claim order.
+func (r *UserReconciler) claimEmail(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) ([]string, error) {
+	if r.EmailClaims == nil {
+		if user.Spec.IsShared() {
+			message := "Shared identities require email claims, which are not enabled"
+			r.setUserSyncedCondition(user, false, message)
+			return nil, stderrors.New(message)
+		}
+		return nil, nil
+	}
+
+	scope := userPoolScope(r.UserPoolClient)
+	claim, err := r.EmailClaims.Claim(ctx, scope, user.Spec.Email, ownerID(user), user.Spec.IsShared())
+	if err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to claim email", err)
+		return nil, fmt.Errorf("failed to claim email: %w", err)
+	}
+	if holder := claim.Holder; holder != "" {
+		message := fmt.Sprintf("Email %s is claimed by %s", user.Spec.Email, holder)
+		log.Info("Email claimed by another User", "username", user.Name, "email", user.Spec.Email, "holder", holder)
+		if user.Status.Sub == "" {
+			setCondition(&user.Status.Conditions, kcpv1alpha1.UserCreatedCondition, metav1.ConditionFalse,
+				emailClaimedElsewhereReason, message)
+		}
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse,
+			emailClaimedElsewhereReason, message)
+		return nil, fmt.Errorf("refused to sync user: %s", message)
+	}
+
+	if previous := user.Status.ClaimedEmail; previous != "" && previous != user.Spec.Email {
+		log.Info("Releasing claim on previous email", "username", user.Name, "email", previous)
+		remaining, err := r.EmailClaims.Release(ctx, r.claimScope(user), previous, ownerID(user))
+		if err != nil {
+			r.setUserSyncFailedCondition(user, "Failed to release claim on previous email", err)
+			return nil, fmt.Errorf("failed to release claim on previous email: %w", err)
+		}
+		// The identity of the previous email stays with the other Users sharing it
+		if len(remaining) > 0 && user.Status.Sub != "" {
+			log.Info("Leaving shared identity of previous email", "username", user.Name, "sub", user.Status.Sub)
+			user.Status.Sub = ""
+			user.Status.Groups = nil
+			user.Status.ManagedAttributes = nil
+		}
+	}
+	user.Status.ClaimedEmail = user.Spec.Email
+	user.Status.UserPool = scope
+	user.Status.References = int32(len(claim.References))
+	return claim.References, nil
+}
+
+// claimScope returns the user pool scope holding the email claims of the User
+func (r *UserReconciler) claimScope(user *kcpv1alpha1.User) string {
+	if user.Status.UserPool != "" {
+		return user.Status.UserPool
+	}
+	return userPoolScope(r.UserPoolClient)
+}
+
+// userPoolChanged reports whether the User was synced to another user pool than the one the
+// workspace is bound to. Users synced before their user pool was recorded are assumed to be in
+// the current one.
+func (r *UserReconciler) userPoolChanged(user *kcpv1alpha1.User) bool {
+	if r.UserPoolClient == nil || (user.Status.Sub == "" && user.Status.ClaimedEmail == "") {
+		return false
+	}
+	scope := userPoolScope(r.UserPoolClient)
+	if user.Status.UserPool == "" {
+		user.Status.UserPool = scope
+	}
+	return user.Status.UserPool != scope
+}
+
+// userPoolName describes a user pool scope in condition messages
+func userPoolName(scope string) string {
+	if scope == "" {
+		return "the default user pool"
+	}
+	return "user pool " + scope
+}
+
+// releaseEmailClaims releases the claims of a deleted User on its claimed and declared email
+func (r *UserReconciler) releaseEmailClaims(ctx context.Context, user *kcpv1alpha1.User) error {
+	if r.EmailClaims == nil {
+		return nil
+	}
+	emails := []string{user.Status.ClaimedEmail}
+	if user.Spec.Email != user.Status.ClaimedEmail {
+		emails = append(emails, user.Spec.Email)
+	}
+	for _, email := range emails {
+		if email == "" {
+			continue
+		}
+		if _, err := r.EmailClaims.Release(ctx, r.claimScope(user), email, ownerID(user)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// desiredIdentity returns the user pool identity and groups declared by the User or, for shared
+// identities, by the given references. References that cannot be read are left out. It returns a
+// nil identity when no reference can be read.
+func (r *UserReconciler) desiredIdentity(ctx context.Context, user *kcpv1alpha1.User,
+	references []string) (*userpool.User, []string, error) {
+	if len(references) == 0 {
+		poolUser, err := r.desiredUser(user)
+		return poolUser, user.Spec.Groups, err
+	}
+
+	users := make([]*kcpv1alpha1.User, 0, len(references))
+	for _, reference := range references {
+		if reference == ownerID(user) {
+			users = append(users, user)
+			continue
+		}
+		other, err := r.EmailClaims.user(ctx, reference)
+		if stderrors.Is(err, errUnreachable) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if other != nil && other.DeletionTimestamp == nil {
+			users = append(users, other)
+		}
+	}
+	if len(users) == 0 {
+		return nil, nil, nil
+	}
+
+	// The first User to declare an attribute sets it, in the order the Users joined the identity.
+	// The identity is enabled when any User enables it and belongs to the groups of every User.
+	poolUser, err := r.desiredUser(users[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	groups := sets.New(users[0].Spec.Groups...)
+	for _, other := range users[1:] {
+		for name, value := range desiredAttributes(&other.Spec) {
+			if _, ok := poolUser.Attributes[name]; !ok {
+				poolUser.Attributes[name] = value
+			}
+		}
+		poolUser.Enabled = poolUser.Enabled || other.Spec.IsEnabled()
+		groups.Insert(other.Spec.Groups...)
+	}
+	return poolUser, sets.List(groups), nil
+}
+
+// desiredUser returns the user pool identity declared by the User spec
+func (r *UserReconciler) desiredUser(user *kcpv1alpha1.User) (*userpool.User, error) {
+	username, err := r.Usernames.Username(user)
+	if err != nil {
+		return nil, err
+	}
 	poolUser := &userpool.User{
-		Username: user.Name,
-		Email:    user.Spec.Email,
-		Enabled:  user.Spec.Enabled,
+		Username:   username,
+		Email:      user.Spec.Email,
+		Enabled:    user.Spec.IsEnabled(),
+		Attributes: desiredAttributes(&user.Spec),
+	}
+
+	// Stamp the ownership markers so other Users cannot silently take over this identity and
+	// the owning workspace, object and controller can be told from the user pool alone
+	if poolUser.Attributes == nil {
+		poolUser.Attributes = map[string]string{}
+	}
+	poolUser.Attributes[userpool.OwnerAttribute] = ownerID(user)
+	if user.UID != "" {
+		poolUser.Attributes[userpool.OwnerUIDAttribute] = string(user.UID)
+	}
+	if r.InstanceID != "" {
+		poolUser.Attributes[userpool.InstanceAttribute] = r.InstanceID
+	}
+	return poolUser, nil
+}
+
+// ownershipConflict returns why the ownership markers of an identity name another User or
+// controller instance, or an empty string when the identity belongs to the User. Missing markers,
+// e.g. on identities created before they were introduced, are no conflict and are stamped on update.
+func (r *UserReconciler) ownershipConflict(user *kcpv1alpha1.User, poolUser *userpool.User) string {
+	// Shared identities are owned by every User referencing their email claim
+	if user.Spec.IsShared() {
+		return r.instanceConflict(poolUser)
+	}
+	if owner := poolUser.Attributes[userpool.OwnerAttribute]; owner != "" && owner != ownerID(user) {
+		return fmt.Sprintf("User pool identity %s is owned by %s", poolUser.Sub, owner)
+	}
+	if uid := poolUser.Attributes[userpool.OwnerUIDAttribute]; uid != "" && user.UID != "" && uid != string(user.UID) {
+		return fmt.Sprintf("User pool identity %s is owned by another User with the same name, UID %s",
+			poolUser.Sub, uid)
+	}
+	return r.instanceConflict(poolUser)
+}
+
+// instanceConflict returns why an identity is managed by another controller instance, or an empty string
+func (r *UserReconciler) instanceConflict(poolUser *userpool.User) string {
+	instance := poolUser.Attributes[userpool.InstanceAttribute]
+	if instance != "" && r.InstanceID != "" && instance != r.InstanceID {
+		return fmt.Sprintf("User pool identity %s is managed by controller instance %s", poolUser.Sub, instance)
+	}
+	return ""
+}
+
+// resyncDue reports whether a synced User should be compared with the user pool again
+func (r *UserReconciler) resyncDue(user *kcpv1alpha1.User) bool {
+	if r.ResyncInterval <= 0 || user.Status.Sub == "" {
+		return false
 	}
+	return user.Status.LastSyncTime == nil || time.Since(user.Status.LastSyncTime.Time) >= r.ResyncInterval
+}
 
-	// Check if user exists in user pool (only use sub from status)
-	var existingUser *userpool.User
-	var err error
+// nextResync returns how long until the next drift check of a User, or zero when none is scheduled
+func (r *UserReconciler) nextResync(user *kcpv1alpha1.User) time.Duration {
+	if r.ResyncInterval <= 0 || user.Status.Sub == "" {
+		return 0
+	}
+	if user.Status.LastSyncTime == nil {
+		return r.ResyncInterval
+	}
+	return max(r.ResyncInterval-time.Since(user.Status.LastSyncTime.Time), time.Second)
+}
 
-	if user.Status.Sub != "" {
-		if existingUser, err = r.UserPoolClient.GetUser(ctx, user.Status.Sub); err != nil {
-			r.setUserSyncedCondition(user, false, fmt.Sprintf("Failed to get user from user pool: %v", err))
-			return fmt.Errorf("failed to get user from user pool: %w", err)
+// correctDrift compares the user pool identity of a synced User with its spec and repairs changes
+// made outside kcp, such as in the Cognito console. Identities deleted from the user pool are
+// recreated. The outcome is recorded in the Drifted condition.
+func (r *UserReconciler) correctDrift(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) error {
+	current, err := r.UserPoolClient.GetUser(ctx, user.Status.Sub)
+	if stderrors.Is(err, userpool.ErrUserNotFound) {
+		log.Info("User disappeared from user pool, recreating it", "username", user.Name, "sub", user.Status.Sub)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserDriftedCondition, metav1.ConditionTrue,
+			"DriftDetected", fmt.Sprintf("User %s is missing from the user pool", user.Status.Sub))
+		previousSub := user.Status.Sub
+		user.Status.Sub = ""
+		if err := r.syncUserWithUserPool(ctx, user, log); err != nil {
+			return err
 		}
-		log.Info("Updating user in user pool", "username", user.Name, "sub", existingUser.Sub)
-		if err := r.UserPoolClient.UpdateUser(ctx, poolUser); err != nil {
-			r.setUserSyncedCondition(user, false, fmt.Sprintf("Failed to update user in user pool: %v", err))
-			return fmt.Errorf("failed to update user in user pool: %w", err)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserDriftedCondition, metav1.ConditionTrue,
+			"UserRecreated", fmt.Sprintf("User %s was missing from the user pool and has been recreated", previousSub))
+		return nil
+	}
+	if err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to get user from user pool", err)
+		return fmt.Errorf("failed to get user from user pool: %w", err)
+	}
+
+	var references []string
+	if user.Spec.IsShared() {
+		if references, err = r.claimEmail(ctx, user, log); err != nil {
+			return err
 		}
-		log.Info("User updated in user pool", "username", user.Name)
-		r.setUserSyncedCondition(user, true, "User successfully updated in user pool")
-	} else {
-		log.Info("Creating user in user pool", "username", user.Name)
-		createdUser, err := r.UserPoolClient.CreateUser(ctx, poolUser)
+	}
+	desired, desiredGroups, err := r.desiredIdentity(ctx, user, references)
+	if err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to read Users sharing the identity", err)
+		return fmt.Errorf("failed to read Users sharing the identity: %w", err)
+	}
+
+	drift := userDrift(desired, current, user.Status.ManagedAttributes)
+	if len(desiredGroups) > 0 || len(user.Status.Groups) > 0 {
+		groups, err := r.UserPoolClient.ListGroupsForUser(ctx, user.Status.Sub)
 		if err != nil {
-			r.setUserCreatedCondition(user, false, fmt.Sprintf("Failed to create user in user pool: %v", err))
-			r.setUserSyncedCondition(user, false, fmt.Sprintf("Failed to create user in user pool: %v", err))
-			return fmt.Errorf("failed to create user in user pool: %w", err)
+			r.setUserSyncFailedCondition(user, "Failed to list groups of user", err)
+			return fmt.Errorf("failed to list groups of user: %w", err)
+		}
+		toAdd, toRemove := diffGroups(groups, desiredGroups)
+		for _, group := range toAdd {
+			drift = append(drift, fmt.Sprintf("not a member of group %s", group))
+		}
+		for _, group := range toRemove {
+			drift = append(drift, fmt.Sprintf("member of undeclared group %s", group))
 		}
-		user.Status.Sub = createdUser.Sub
-		user.Status.UserPoolStatus = "CONFIRMED"
-		log.Info("User created in user pool", "username", user.Name, "sub", user.Status.Sub)
-		r.setUserCreatedCondition(user, true, "User successfully created in user pool")
-		r.setUserSyncedCondition(user, true, "User successfully created and synced with user pool")
 	}
+
+	if len(drift) == 0 {
+		mirrorUserPoolStatus(&user.Status, current)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserDriftedCondition, metav1.ConditionFalse,
+			"NoDrift", "User pool matches the spec")
+		now := metav1.Now()
+		user.Status.LastSyncTime = &now
+		return nil
+	}
+
+	message := "User pool diverged from the spec: " + strings.Join(drift, "; ")
+	log.Info("Correcting drift in user pool", "username", user.Name, "sub", user.Status.Sub, "drift", drift)
+	setCondition(&user.Status.Conditions, kcpv1alpha1.UserDriftedCondition, metav1.ConditionTrue,
+		"DriftDetected", message)
+	if err := r.syncUserWithUserPool(ctx, user, log); err != nil {
+		return err
+	}
+	setCondition(&user.Status.Conditions, kcpv1alpha1.UserDriftedCondition, metav1.ConditionTrue,
+		"DriftCorrected", message)
+	return nil
+}
+
+// userDrift describes how the current user pool identity differs from the desired one. Only the
+// applied attributes are expected to be unset when they are no longer desired.
+func userDrift(desired, current *userpool.User, applied []string) []string {
+	var drift []string
+	if current.Email != desired.Email {
+		drift = append(drift, fmt.Sprintf("email is %q, want %q", current.Email, desired.Email))
+	}
+	if current.Enabled != desired.Enabled {
+		drift = append(drift, fmt.Sprintf("enabled is %t, want %t", current.Enabled, desired.Enabled))
+	}
+	names := make([]string, 0, len(desired.Attributes))
+	for name := range desired.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := current.Attributes[name]; !ok {
+			drift = append(drift, fmt.Sprintf("%s is unset, want %q", name, desired.Attributes[name]))
+		} else if value != desired.Attributes[name] {
+			drift = append(drift, fmt.Sprintf("%s is %q, want %q", name, value, desired.Attributes[name]))
+		}
+	}
+	for _, name := range staleAttributes(current.Attributes, desired.Attributes, applied) {
+		drift = append(drift, fmt.Sprintf("%s is %q, want unset", name, current.Attributes[name]))
+	}
+	return drift
+}
+
+// createUserInUserPool creates the user in the user pool. When the user already exists it is
+// adopted according to the adoption policy, in which case created is false and the caller must
+// update the existing user.
+func (r *UserReconciler) createUserInUserPool(ctx context.Context, user *kcpv1alpha1.User, poolUser *userpool.User,
+	log logr.Logger) (bool, error) {
+	log.Info("Creating user in user pool", "username", user.Name)
+	poolUser.InvitationDeliveryMediums = invitationDeliveryMediums(user.Spec.Invitation)
+	createdUser, err := r.UserPoolClient.CreateUser(ctx, poolUser)
+	if stderrors.Is(err, userpool.ErrUserExists) {
+		return false, r.adoptUser(ctx, user, poolUser, log)
+	}
+	if err != nil {
+		r.setUserCreatedCondition(user, false, fmt.Sprintf("Failed to create user in user pool: %v", err))
+		r.setUserSyncFailedCondition(user, "Failed to create user in user pool", err)
+		return false, fmt.Errorf("failed to create user in user pool: %w", err)
+	}
+
+	user.Status.Sub = createdUser.Sub
+	user.Status.UserPool = userPoolScope(r.UserPoolClient)
+	user.Status.ManagedAttributes = appliedAttributes(poolUser.Attributes)
+	mirrorUserPoolStatus(&user.Status, createdUser)
+	log.Info("User created in user pool", "username", user.Name, "sub", user.Status.Sub)
+	if len(poolUser.InvitationDeliveryMediums) > 0 {
+		log.Info("Invitation sent", "username", user.Name, "deliveryMediums", poolUser.InvitationDeliveryMediums)
+		r.recordInvitation(user)
+	}
+	r.setUserCreatedCondition(user, true, "User successfully created in user pool")
+	r.setUserSyncedCondition(user, true, "User successfully created and synced with user pool")
+	return true, nil
+}
+
+// adoptUser takes over an identity that already exists in the user pool when the adoption policy
+// allows it. Identities owned by the owner of the desired identity are always taken over, which
+// lets Users join the shared identity of their email.
+func (r *UserReconciler) adoptUser(ctx context.Context, user *kcpv1alpha1.User, poolUser *userpool.User,
+	log logr.Logger) error {
+	existingUser, err := r.existingUser(ctx, poolUser)
+	if err != nil {
+		r.setUserCreatedCondition(user, false, fmt.Sprintf("Failed to get existing user from user pool: %v", err))
+		r.setUserSyncFailedCondition(user, "Failed to get existing user from user pool", err)
+		return fmt.Errorf("failed to get existing user from user pool: %w", err)
+	}
+
+	owner := existingUser.Attributes[userpool.OwnerAttribute]
+	self := poolUser.Attributes[userpool.OwnerAttribute]
+	reason := adoptionRefusal(adoptionPolicy(user), owner, self)
+	// The username may be taken by an identity of another person
+	if owner != self && !strings.EqualFold(existingUser.Email, user.Spec.Email) {
+		reason = fmt.Sprintf("Username %s is taken by a user with another email in user pool", poolUser.Username)
+	}
+	if reason == "" && owner != "" && owner != self {
+		// Always does not take identities over from Users that still exist
+		if reason, err = r.liveOwnerRefusal(ctx, owner); err != nil {
+			r.setUserSyncFailedCondition(user, "Failed to get owner of existing user", err)
+			return err
+		}
+	}
+	if reason != "" {
+		log.Info("Refusing to adopt existing user", "username", user.Name, "email", user.Spec.Email, "owner", owner)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserCreatedCondition, metav1.ConditionFalse,
+			"AdoptionRefused", reason)
+		r.setUserSyncedCondition(user, false, reason)
+		return fmt.Errorf("refused to adopt existing user %s: %s", user.Spec.Email, reason)
+	}
+
+	log.Info("Adopting existing user from user pool", "username", user.Name, "sub", existingUser.Sub,
+		"previousOwner", owner)
+	user.Status.Sub = existingUser.Sub
+	user.Status.UserPool = userPoolScope(r.UserPoolClient)
+	setCondition(&user.Status.Conditions, kcpv1alpha1.UserCreatedCondition, metav1.ConditionTrue,
+		"UserAdopted", "Existing user adopted from user pool")
+	return nil
+}
+
+// existingUser returns the identity that kept the desired identity from being created, looked up
+// by the username that was attempted. Backends that do not keep the given username report existing
+// users by email.
+func (r *UserReconciler) existingUser(ctx context.Context, poolUser *userpool.User) (*userpool.User, error) {
+	if poolUser.Username != "" && !strings.EqualFold(poolUser.Username, poolUser.Email) {
+		existingUser, err := r.UserPoolClient.GetUser(ctx, poolUser.Username)
+		if !stderrors.Is(err, userpool.ErrUserNotFound) {
+			return existingUser, err
+		}
+	}
+	return r.UserPoolClient.GetUser(ctx, poolUser.Email)
+}
+
+// updateUserInUserPool updates an existing user in the user pool to match the spec. With
+// verifyOwnership, identities whose ownership markers name another owner are left untouched.
+func (r *UserReconciler) updateUserInUserPool(ctx context.Context, user *kcpv1alpha1.User, poolUser *userpool.User,
+	verifyOwnership bool, log logr.Logger) error {
+	existingUser, err := r.UserPoolClient.GetUser(ctx, user.Status.Sub)
+	if err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to get user from user pool", err)
+		return fmt.Errorf("failed to get user from user pool: %w", err)
+	}
+	if conflict := r.ownershipConflict(user, existingUser); verifyOwnership && conflict != "" {
+		log.Info("Refusing to update user owned by someone else", "username", user.Name, "sub", existingUser.Sub,
+			"owner", existingUser.Attributes[userpool.OwnerAttribute])
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse,
+			ownershipConflictReason, conflict)
+		return fmt.Errorf("refused to update user in user pool: %s", conflict)
+	}
+	log.Info("Updating user in user pool", "username", user.Name, "sub", existingUser.Sub)
+	mirrorUserPoolStatus(&user.Status, existingUser)
+	poolUser.Sub = user.Status.Sub
+	if err := r.UserPoolClient.UpdateUser(ctx, poolUser); err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to update user in user pool", err)
+		return fmt.Errorf("failed to update user in user pool: %w", err)
+	}
+	stale := staleAttributes(existingUser.Attributes, poolUser.Attributes, user.Status.ManagedAttributes)
+	if len(stale) > 0 {
+		log.Info("Removing attributes dropped from spec", "username", user.Name, "attributes", stale)
+		if err := r.UserPoolClient.DeleteUserAttributes(ctx, user.Status.Sub, stale); err != nil {
+			r.setUserSyncFailedCondition(user, "Failed to remove user attributes", err)
+			return fmt.Errorf("failed to remove user attributes: %w", err)
+		}
+	}
+	user.Status.ManagedAttributes = appliedAttributes(poolUser.Attributes)
+	if err := r.syncInvitation(ctx, user, log); err != nil {
+		r.setUserSyncFailedCondition(user, "Failed to resend invitation", err)
+		return fmt.Errorf("failed to resend invitation: %w", err)
+	}
+	log.Info("User updated in user pool", "username", user.Name)
+	r.setUserSyncedCondition(user, true, "User successfully updated in user pool")
+	return nil
+}
+
+// syncInvitation resends the invitation when spec.invitation.resendToken changes to a value
+// that has not been handled yet
+func (r *UserReconciler) syncInvitation(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) error {
+	invitation := user.Spec.Invitation
+	if invitation == nil || invitation.ResendToken == "" ||
+		invitation.ResendToken == user.Status.InvitationResendToken {
+		return nil
+	}
+
+	mediums := invitationDeliveryMediums(invitation)
+	if err := r.UserPoolClient.ResendInvitation(ctx, user.Status.Sub, mediums); err != nil {
+		return err
+	}
+
+	log.Info("Invitation resent", "username", user.Name, "deliveryMediums", mediums)
+	r.recordInvitation(user)
+	return nil
+}
+
+// recordInvitation stores the time an invitation was sent along with the expiration of its
+// temporary password, and marks the current resend token as handled
+func (r *UserReconciler) recordInvitation(user *kcpv1alpha1.User) {
+	validity := r.TemporaryPasswordValidity
+	if validity == 0 {
+		validity = defaultTemporaryPasswordValidity
+	}
+
 	now := metav1.Now()
-	user.Status.LastSyncTime = &now
+	expiration := metav1.NewTime(now.Add(validity))
+	user.Status.InvitationSentTime = &now
+	user.Status.TemporaryPasswordExpirationTime = &expiration
+	user.Status.InvitationResendToken = user.Spec.Invitation.ResendToken
+}
+
+// passwordSecretChanged reports whether the password Secret of a created User differs from the one
+// last applied. Secrets that cannot be read count as changed so that the failure is reported.
+func passwordSecretChanged(ctx context.Context, reader client.Reader, user *kcpv1alpha1.User) bool {
+	ref := user.Spec.PasswordSecretRef
+	if ref == nil || user.Status.Sub == "" {
+		return false
+	}
+	var secret corev1.Secret
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: user.Namespace, Name: ref.Name}, &secret); err != nil {
+		return true
+	}
+	return secret.ResourceVersion != user.Status.PasswordSecretVersion
+}
+
+// syncUserPassword applies the password from spec.passwordSecretRef whenever the Secret changes
+// and records whether a temporary password has been consumed by the user
+func (r *UserReconciler) syncUserPassword(ctx context.Context, reader client.Reader, user *kcpv1alpha1.User,
+	log logr.Logger) error {
+	ref := user.Spec.PasswordSecretRef
+	if r.UserPoolClient == nil || ref == nil || user.Status.Sub == "" {
+		return nil
+	}
+
+	var secret corev1.Secret
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: user.Namespace, Name: ref.Name}, &secret); err != nil {
+		r.setUserSyncedCondition(user, false, fmt.Sprintf("Failed to read password Secret %s: %v", ref.Name, err))
+		return fmt.Errorf("failed to read password secret %s: %w", ref.Name, err)
+	}
+
+	if secret.ResourceVersion != user.Status.PasswordSecretVersion {
+		key := ref.Key
+		if key == "" {
+			key = defaultPasswordSecretKey
+		}
+		password := string(secret.Data[key])
+		if password == "" {
+			r.setUserSyncedCondition(user, false, fmt.Sprintf("Password Secret %s has no key %s", ref.Name, key))
+			return fmt.Errorf("password secret %s has no key %s", ref.Name, key)
+		}
+
+		if err := r.UserPoolClient.SetUserPassword(ctx, user.Status.Sub, password, ref.Permanent); err != nil {
+			r.setUserSyncFailedCondition(user, "Failed to set user password", err)
+			return fmt.Errorf("failed to set user password: %w", err)
+		}
+
+		log.Info("User password set from Secret", "username", user.Name, "secret", ref.Name,
+			"permanent", ref.Permanent)
+		user.Status.PasswordSecretVersion = secret.ResourceVersion
+		user.Status.PasswordStatus = kcpv1alpha1.PasswordStatusTemporary
+		if ref.Permanent {
+			user.Status.PasswordStatus = kcpv1alpha1.PasswordStatusPermanent
+		}
+		return nil
+	}
+
+	if user.Status.PasswordStatus != kcpv1alpha1.PasswordStatusTemporary {
+		return nil
+	}
+
+	poolUser, err := r.UserPoolClient.GetUser(ctx, user.Status.Sub)
+	if err != nil {
+		return fmt.Errorf("failed to get user from user pool: %w", err)
+	}
+	mirrorUserPoolStatus(&user.Status, poolUser)
+	if !poolUser.PasswordChangeRequired {
+		log.Info("Temporary password consumed", "username", user.Name)
+		user.Status.PasswordStatus = kcpv1alpha1.PasswordStatusConsumed
+	}
+	return nil
+}
+
+// syncUserGroups converges the user's group membership in the user pool with spec.groups.
+// Membership is left untouched for users that have never declared any groups.
+func (r *UserReconciler) syncUserGroups(ctx context.Context, user *kcpv1alpha1.User, groups []string,
+	log logr.Logger) error {
+	if len(groups) == 0 && len(user.Status.Groups) == 0 {
+		return nil
+	}
+
+	if err := r.applyGroups(ctx, user.Status.Sub, groups, log.WithValues("username", user.Name)); err != nil {
+		return err
+	}
+
+	user.Status.Groups = nil
+	if len(groups) > 0 {
+		user.Status.Groups = append([]string{}, groups...)
+		sort.Strings(user.Status.Groups)
+	}
+	return nil
+}
+
+// applyGroups makes the identity a member of exactly the given groups
+func (r *UserReconciler) applyGroups(ctx context.Context, sub string, groups []string, log logr.Logger) error {
+	current, err := r.UserPoolClient.ListGroupsForUser(ctx, sub)
+	if err != nil {
+		return err
+	}
+
+	toAdd, toRemove := diffGroups(current, groups)
+	for _, group := range toAdd {
+		log.Info("Adding user to group", "group", group)
+		if err := r.UserPoolClient.AddUserToGroup(ctx, sub, group); err != nil {
+			return err
+		}
+	}
+	for _, group := range toRemove {
+		log.Info("Removing user from group", "group", group)
+		if err := r.UserPoolClient.RemoveUserFromGroup(ctx, sub, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffGroups returns the groups missing from current and the groups in current that are not desired
+func diffGroups(current, desired []string) (toAdd, toRemove []string) {
+	currentSet := map[string]bool{}
+	for _, group := range current {
+		currentSet[group] = true
+	}
+	desiredSet := map[string]bool{}
+	for _, group := range desired {
+		desiredSet[group] = true
+		if !currentSet[group] {
+			toAdd = append(toAdd, group)
+		}
+	}
+	for _, group := range current {
+		if !desiredSet[group] {
+			toRemove = append(toRemove, group)
+		}
+	}
+	sort.Strings(toAdd)
+	sort.Strings(toRemove)
+	return toAdd, toRemove
+}
+
+// cleanupUserInUserPool applies the User's deletion policy to its user pool identity
+func (r *UserReconciler) cleanupUserInUserPool(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) error {
+	// Shared identities are only cleaned up by the last User referencing them
+	if user.Spec.IsShared() && r.EmailClaims != nil {
+		email := user.Status.ClaimedEmail
+		if email == "" {
+			email = user.Spec.Email
+		}
+		remaining, err := r.EmailClaims.Release(ctx, r.claimScope(user), email, ownerID(user))
+		if err != nil {
+			return fmt.Errorf("failed to release shared identity: %w", err)
+		}
+		if len(remaining) > 0 {
+			return r.handOverSharedIdentity(ctx, user, remaining, log)
+		}
+	}
+
+	switch policy := r.deletionPolicy(user); policy {
+	case kcpv1alpha1.DeletionPolicyRetain:
+		log.Info("Retaining user in user pool", "username", user.Name, "sub", user.Status.Sub)
+		return nil
+	case kcpv1alpha1.DeletionPolicyDisable:
+		return r.disableUserInUserPool(ctx, user, log)
+	case kcpv1alpha1.DeletionPolicyDelete:
+		r.deleteUserFromUserPool(ctx, user, log)
+		return nil
+	default:
+		return fmt.Errorf("unknown deletion policy %q", policy)
+	}
+}
+
+// handOverSharedIdentity leaves a shared identity to the Users still referencing it instead of
+// applying the deletion policy, dropping what only the deleted User declared
+func (r *UserReconciler) handOverSharedIdentity(ctx context.Context, user *kcpv1alpha1.User, references []string,
+	log logr.Logger) error {
+	if user.Status.Sub == "" {
+		return nil
+	}
+	poolUser, groups, err := r.desiredIdentity(ctx, user, references)
+	if err != nil {
+		return fmt.Errorf("failed to read Users sharing the identity: %w", err)
+	}
+	if poolUser == nil {
+		log.Info("No User sharing the identity can be read, leaving it untouched", "username", user.Name,
+			"sub", user.Status.Sub, "references", references)
+		return nil
+	}
+
+	existingUser, err := r.UserPoolClient.GetUser(ctx, user.Status.Sub)
+	if stderrors.Is(err, userpool.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user from user pool: %w", err)
+	}
+	log.Info("Handing over shared identity", "username", user.Name, "sub", user.Status.Sub,
+		"owner", poolUser.Attributes[userpool.OwnerAttribute])
+	poolUser.Sub = user.Status.Sub
+	if err := r.UserPoolClient.UpdateUser(ctx, poolUser); err != nil {
+		return fmt.Errorf("failed to update user in user pool: %w", err)
+	}
+	stale := staleAttributes(existingUser.Attributes, poolUser.Attributes, user.Status.ManagedAttributes)
+	if len(stale) > 0 {
+		if err := r.UserPoolClient.DeleteUserAttributes(ctx, user.Status.Sub, stale); err != nil {
+			return fmt.Errorf("failed to remove user attributes: %w", err)
+		}
+	}
+	if err := r.applyGroups(ctx, user.Status.Sub, groups, log.WithValues("username", user.Name)); err != nil {
+		return fmt.Errorf("failed to sync group membership: %w", err)
+	}
+	return nil
+}
+
+// deletionPolicy returns the deletion policy of the User, falling back to the controller default
+func (r *UserReconciler) deletionPolicy(user *kcpv1alpha1.User) kcpv1alpha1.DeletionPolicy {
+	if user.Spec.DeletionPolicy != "" {
+		return user.Spec.DeletionPolicy
+	}
+	if r.DefaultDeletionPolicy != "" {
+		return r.DefaultDeletionPolicy
+	}
+	return kcpv1alpha1.DeletionPolicyDelete
+}
+
+// disableUserInUserPool disables the user so the identity is kept for audit.
+// Unlike deletion, failures block finalizer removal so the identity is never left enabled.
+func (r *UserReconciler) disableUserInUserPool(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) error {
+	if user.Status.Sub == "" {
+		log.Info("No sub available, user was never created in user pool", "username", user.Name)
+		return nil
+	}
+
+	existingUser, err := r.UserPoolClient.GetUser(ctx, user.Status.Sub)
+	if stderrors.Is(err, userpool.ErrUserNotFound) {
+		log.Info("User not found in user pool, nothing to disable", "username", user.Name, "sub", user.Status.Sub)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user from user pool: %w", err)
+	}
+	if conflict := r.ownershipConflict(user, existingUser); conflict != "" {
+		log.Info("Not disabling user owned by someone else", "username", user.Name, "reason", conflict)
+		return nil
+	}
+
+	if err := r.UserPoolClient.DisableUser(ctx, user.Status.Sub); err != nil {
+		return fmt.Errorf("failed to disable user in user pool: %w", err)
+	}
+	log.Info("User disabled in user pool", "username", user.Name, "sub", user.Status.Sub)
 	return nil
 }
 
 // deleteUserFromUserPool safely deletes a user from the user pool with appropriate logging
-func (r *UserReconciler) deleteUserFromUserPool(ctx context.Context, username string, sub string,
-	log logr.Logger) {
+func (r *UserReconciler) deleteUserFromUserPool(ctx context.Context, user *kcpv1alpha1.User, log logr.Logger) {
+	username, sub := user.Name, user.Status.Sub
 	// Skip deletion if UserPoolClient is not configured
 	if r.UserPoolClient == nil {
 		log.Info("UserPoolClient not configured, skipping user pool deletion", "username", username)
 		return
 	}
 
-	// Determine what identifier to use for deletion
-	identifier := sub
-	if identifier == "" {
-		// Fallback to username if sub is not available
-		identifier = username
-		log.Info("No sub available, using username for deletion",
-			"username", username)
+	// Users without a sub never created or adopted an identity, and any identity found by name
+	// could belong to someone else
+	if sub == "" {
+		log.Info("No sub available, user was never created in user pool", "username", username)
+		return
 	}
+	identifier := sub
 
 	// Check if user exists first
-	_, err := r.UserPoolClient.GetUser(ctx, identifier)
+	existingUser, err := r.UserPoolClient.GetUser(ctx, identifier)
 	if err != nil {
 		// User doesn't exist in user pool
 		log.Info("User not found in user pool, nothing to delete",
 			"username", username, "identifier", identifier)
 		return
 	}
+	if conflict := r.ownershipConflict(user, existingUser); conflict != "" {
+		log.Info("Not deleting user owned by someone else", "username", username, "reason", conflict)
+		return
+	}
 
 	// User exists, proceed with deletion
 	if err := r.UserPoolClient.DeleteUser(ctx, identifier); err != nil {
@@ -234,6 +1105,135 @@ func (r *UserReconciler) deleteUserFromUserPool(ctx context.Context, username st
 	}
 }
 
+// desiredAttributes builds the user pool attributes declared in the User spec
+func desiredAttributes(spec *kcpv1alpha1.UserSpec) map[string]string {
+	attributes := map[string]string{}
+	standard := map[string]string{
+		userpool.AttributeGivenName:         spec.GivenName,
+		userpool.AttributeFamilyName:        spec.FamilyName,
+		userpool.AttributePhoneNumber:       spec.PhoneNumber,
+		userpool.AttributeLocale:            spec.Locale,
+		userpool.AttributePreferredUsername: spec.PreferredUsername,
+	}
+	for name, value := range standard {
+		if value != "" {
+			attributes[name] = value
+		}
+	}
+	for name, value := range spec.CustomAttributes {
+		attributes[userpool.CustomAttributePrefix+strings.TrimPrefix(name, userpool.CustomAttributePrefix)] = value
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// ownerID identifies the resource that owns a user pool identity or group as <cluster>/<namespace>/<name>
+func ownerID(obj metav1.Object) string {
+	return logicalcluster.From(obj).String() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// adoptionPolicy returns the User's adoption policy, defaulting to Never
+func adoptionPolicy(user *kcpv1alpha1.User) kcpv1alpha1.AdoptionPolicy {
+	if user.Spec.AdoptionPolicy == "" {
+		return kcpv1alpha1.AdoptionPolicyNever
+	}
+	return user.Spec.AdoptionPolicy
+}
+
+// adoptionRefusal returns why an existing identity with the given owner may not be adopted,
+// or an empty string when adoption is allowed
+func adoptionRefusal(policy kcpv1alpha1.AdoptionPolicy, owner, self string) string {
+	switch {
+	case owner == self, policy == kcpv1alpha1.AdoptionPolicyAlways:
+		return ""
+	case policy == kcpv1alpha1.AdoptionPolicyIfUnowned && owner == "":
+		return ""
+	case policy == kcpv1alpha1.AdoptionPolicyIfUnowned:
+		return fmt.Sprintf("User already exists in user pool and is owned by %s", owner)
+	default:
+		return fmt.Sprintf("User already exists in user pool and adoptionPolicy is %s", policy)
+	}
+}
+
+// liveOwnerRefusal returns why an identity marked by another owner cannot be adopted, or an empty
+// string when the owning User no longer exists. Owners that cannot be checked are assumed to exist.
+func (r *UserReconciler) liveOwnerRefusal(ctx context.Context, owner string) (string, error) {
+	if r.GetClient == nil {
+		return fmt.Sprintf("User already exists in user pool and owner %s cannot be checked", owner), nil
+	}
+	existing, err := lookupUser(ctx, r.GetClient, owner)
+	if stderrors.Is(err, errUnreachable) {
+		return fmt.Sprintf("User already exists in user pool and owner %s cannot be checked", owner), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get owner of existing user: %w", err)
+	}
+	if existing != nil {
+		return fmt.Sprintf("User already exists in user pool and is owned by %s", owner), nil
+	}
+	return "", nil
+}
+
+// mirrorUserPoolStatus copies the account state reported by the user pool into the User status
+func mirrorUserPoolStatus(status *kcpv1alpha1.UserStatus, poolUser *userpool.User) {
+	status.UserPoolStatus = poolUser.Status
+	status.UserPoolCreatedTime = optionalTime(poolUser.CreatedAt)
+	status.UserPoolLastModifiedTime = optionalTime(poolUser.LastModifiedAt)
+	status.MFAEnabled = len(poolUser.MFAMethods) > 0
+	status.MFAMethods = poolUser.MFAMethods
+}
+
+// optionalTime converts a time to a metav1.Time, returning nil for the zero time
+func optionalTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	mt := metav1.NewTime(t)
+	return &mt
+}
+
+// invitationDeliveryMediums returns the delivery mediums of the invitation as user pool medium names
+func invitationDeliveryMediums(invitation *kcpv1alpha1.InvitationSpec) []string {
+	if invitation == nil {
+		return nil
+	}
+	mediums := make([]string, 0, len(invitation.DeliveryMediums))
+	for _, medium := range invitation.DeliveryMediums {
+		mediums = append(mediums, string(medium))
+	}
+	return mediums
+}
+
+// staleAttributes returns the previously applied attributes present in the pool but no longer desired.
+// Attributes the controller never applied are left to whoever set them.
+func staleAttributes(current, desired map[string]string, applied []string) []string {
+	var stale []string
+	for _, name := range applied {
+		if _, ok := desired[name]; ok || !userpool.IsManagedAttribute(name) {
+			continue
+		}
+		if _, ok := current[name]; ok {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// appliedAttributes returns the sorted names of the managed attributes of a desired identity
+func appliedAttributes(attributes map[string]string) []string {
+	var names []string
+	for name := range attributes {
+		if userpool.IsManagedAttribute(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // containsFinalizer checks if a finalizer is present in the list
 func containsFinalizer(finalizers []string, finalizer string) bool {
 	for _, f := range finalizers {
@@ -255,8 +1255,9 @@ func removeFinalizer(finalizers []string, finalizer string) []string {
 	return result
 }
 
-// setCondition sets or updates a condition in the user status
-func setCondition(user *kcpv1alpha1.User, conditionType string, status metav1.ConditionStatus, reason, message string) {
+// setCondition sets or updates a condition in the given status conditions
+func setCondition(conditions *[]metav1.Condition, conditionType string, status metav1.ConditionStatus,
+	reason, message string) {
 	condition := metav1.Condition{
 		Type:               conditionType,
 		Status:             status,
@@ -265,31 +1266,90 @@ func setCondition(user *kcpv1alpha1.User, conditionType string, status metav1.Co
 		LastTransitionTime: metav1.Now(),
 	}
 
-	meta.SetStatusCondition(&user.Status.Conditions, condition)
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// failureReason returns the condition reason for a failed user pool call: CredentialsFailed when
+// the user pool credentials could not be obtained, the given reason otherwise
+func failureReason(err error, reason string) string {
+	if stderrors.Is(err, userpool.ErrCredentials) {
+		return credentialsFailedReason
+	}
+	if stderrors.Is(err, errDefaultCredentialsNotAllowed) {
+		return credentialsRequiredReason
+	}
+	if stderrors.Is(err, errRoleNotAllowed) {
+		return roleNotAllowedReason
+	}
+	return reason
 }
 
 // setUserCreatedCondition sets the UserCreated condition
 func (r *UserReconciler) setUserCreatedCondition(user *kcpv1alpha1.User, success bool, message string) {
 	if success {
-		setCondition(user, kcpv1alpha1.UserCreatedCondition, metav1.ConditionTrue, "UserCreated", message)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserCreatedCondition, metav1.ConditionTrue, "UserCreated", message)
 	} else {
-		setCondition(user, kcpv1alpha1.UserCreatedCondition, metav1.ConditionFalse, "UserCreationFailed", message)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserCreatedCondition, metav1.ConditionFalse, "UserCreationFailed", message)
 	}
 }
 
+// setUserSyncFailedCondition sets the UserSynced condition to false because a call failed with err
+func (r *UserReconciler) setUserSyncFailedCondition(user *kcpv1alpha1.User, message string, err error) {
+	setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse,
+		failureReason(err, "UserSyncFailed"), fmt.Sprintf("%s: %v", message, err))
+}
+
 // setUserSyncedCondition sets the UserSynced condition
 func (r *UserReconciler) setUserSyncedCondition(user *kcpv1alpha1.User, success bool, message string) {
 	if success {
-		setCondition(user, kcpv1alpha1.UserSyncedCondition, metav1.ConditionTrue, "UserSynced", message)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionTrue, "UserSynced", message)
 	} else {
-		setCondition(user, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse, "UserSyncFailed", message)
+		setCondition(&user.Status.Conditions, kcpv1alpha1.UserSyncedCondition, metav1.ConditionFalse, "UserSyncFailed", message)
 	}
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// withUserPoolClient returns a shallow copy of the reconciler that talks to the given user pool
+func (r *UserReconciler) withUserPoolClient(poolClient userpool.Client) *UserReconciler {
+	scoped := *r
+	scoped.UserPoolClient = poolClient
+	return &scoped
+}
+
+// SetupWithManager sets up the controller with the Manager. Users are also reconciled when the
+// Secret referenced by their passwordSecretRef changes.
 func (r *UserReconciler) SetupWithManager(mgr mcmanager.Manager) error {
 	return mcbuilder.ControllerManagedBy(mgr).
 		For(&kcpv1alpha1.User{}).
+		Watches(&corev1.Secret{}, enqueueUsersForSecret).
 		Named("user").
 		Complete(mcreconcile.Func(r.Reconcile))
 }
+
+// enqueueUsersForSecret enqueues the Users of a workspace whose passwordSecretRef names a changed Secret
+func enqueueUsersForSecret(clusterName string, cl cluster.Cluster) mchandler.EventHandler {
+	return handler.TypedEnqueueRequestsFromMapFunc(
+		func(ctx context.Context, secret client.Object) []mcreconcile.Request {
+			return usersForSecret(ctx, cl.GetClient(), clusterName, secret)
+		})
+}
+
+// usersForSecret returns requests for the Users in the namespace of a Secret that reference it
+func usersForSecret(ctx context.Context, reader client.Reader, clusterName string,
+	secret client.Object) []mcreconcile.Request {
+	var users kcpv1alpha1.UserList
+	if err := reader.List(ctx, &users, client.InNamespace(secret.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Users referencing Secret", "cluster", clusterName,
+			"namespace", secret.GetNamespace(), "secret", secret.GetName())
+		return nil
+	}
+	var requests []mcreconcile.Request
+	for _, user := range users.Items {
+		if ref := user.Spec.PasswordSecretRef; ref != nil && ref.Name == secret.GetName() {
+			requests = append(requests, mcreconcile.Request{
+				Request:     reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)},
+				ClusterName: clusterName,
+			})
+		}
+	}
+	return requests
+}
@@ -19,19 +19,47 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
+	mcreconcile "sigs.k8s.io/multicluster-runtime/pkg/reconcile"
 
 	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
 	"github.com/cogniteo/kcp-users-controller/internal/controller/mocks"
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito"
+	cognitofake "github.com/cogniteo/kcp-users-controller/pkg/cognito/fake"
 	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
 )
 
+// deletedUser returns a User named test-user holding the given sub
+func deletedUser(sub string) *kcpv1alpha1.User {
+	return &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+		Status:     kcpv1alpha1.UserStatus{Sub: sub},
+	}
+}
+
 func TestUserReconciler(t *testing.T) {
 	t.Run("Reconcile", func(t *testing.T) {
 		t.Run("nil user pool client handling", func(t *testing.T) {
@@ -43,7 +71,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -51,7 +79,7 @@ func TestUserReconciler(t *testing.T) {
 
 			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
 			require.NoError(t, err, "syncUserWithUserPool should handle nil UserPoolClient gracefully")
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 		})
 
 		t.Run("finalizer management", func(t *testing.T) {
@@ -73,7 +101,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -83,6 +111,7 @@ func TestUserReconciler(t *testing.T) {
 				Email:    "test@example.com",
 				Enabled:  true,
 				Sub:      "test-sub-123",
+				Status:   "FORCE_CHANGE_PASSWORD",
 			}, nil)
 
 			reconciler := &UserReconciler{
@@ -94,11 +123,29 @@ func TestUserReconciler(t *testing.T) {
 
 			require.NoError(t, err)
 			assert.Equal(t, "test-sub-123", user.Status.Sub)
-			assert.Equal(t, "CONFIRMED", user.Status.UserPoolStatus)
+			assert.Equal(t, "FORCE_CHANGE_PASSWORD", user.Status.UserPoolStatus)
 			assert.NotNil(t, user.Status.LastSyncTime)
 			mockUserPool.AssertExpectations(t)
 		})
 
+		t.Run("user pool sync with username strategy", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user", Namespace: "default"},
+				Spec:       kcpv1alpha1.UserSpec{Email: "test@example.com"},
+			}
+			usernames, err := NewUsernames(UsernameStrategyTemplate, "{{ .Namespace }}.{{ .Name }}")
+			require.NoError(t, err)
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(poolUser *userpool.User) bool {
+				return poolUser.Username == "default.test-user" && poolUser.Email == "test@example.com"
+			})).Return(&userpool.User{Username: "default.test-user", Sub: "test-sub-123", Enabled: true}, nil)
+
+			reconciler := &UserReconciler{UserPoolClient: mockUserPool, Usernames: usernames}
+			require.NoError(t, reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard()))
+			assert.Equal(t, "test-sub-123", user.Status.Sub)
+		})
+
 		t.Run("user pool deletion integration", func(t *testing.T) {
 			mockUserPool := mocks.NewMockUserPoolClient(t)
 			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
@@ -112,7 +159,7 @@ func TestUserReconciler(t *testing.T) {
 			}
 
 			log := logr.Discard()
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 
 			mockUserPool.AssertExpectations(t)
 		})
@@ -122,7 +169,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -146,19 +193,24 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "updated@example.com",
-					Enabled: false,
+					Enabled: ptr.To(false),
 				},
 				Status: kcpv1alpha1.UserStatus{
 					Sub: "test-sub-123",
 				},
 			}
 
+			createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
 			mockUserPool := mocks.NewMockUserPoolClient(t)
 			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
-				Username: "test-user",
-				Email:    "test@example.com",
-				Enabled:  true,
-				Sub:      "test-sub-123",
+				Username:       "test-user",
+				Email:          "test@example.com",
+				Enabled:        true,
+				Sub:            "test-sub-123",
+				Status:         "CONFIRMED",
+				CreatedAt:      createdAt,
+				LastModifiedAt: createdAt.Add(time.Hour),
+				MFAMethods:     []string{"SOFTWARE_TOKEN_MFA"},
 			}, nil)
 			mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
 
@@ -171,6 +223,13 @@ func TestUserReconciler(t *testing.T) {
 
 			require.NoError(t, err)
 			assert.NotNil(t, user.Status.LastSyncTime)
+			assert.Equal(t, "CONFIRMED", user.Status.UserPoolStatus)
+			require.NotNil(t, user.Status.UserPoolCreatedTime)
+			assert.True(t, createdAt.Equal(user.Status.UserPoolCreatedTime.Time))
+			require.NotNil(t, user.Status.UserPoolLastModifiedTime)
+			assert.True(t, createdAt.Add(time.Hour).Equal(user.Status.UserPoolLastModifiedTime.Time))
+			assert.True(t, user.Status.MFAEnabled)
+			assert.Equal(t, []string{"SOFTWARE_TOKEN_MFA"}, user.Status.MFAMethods)
 			mockUserPool.AssertExpectations(t)
 		})
 	})
@@ -183,7 +242,7 @@ func TestUserReconciler(t *testing.T) {
 			log := logr.Discard()
 
 			// Should not panic when UserPoolClient is nil
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 
 			// Test passes if no panic occurs
 		})
@@ -203,30 +262,23 @@ func TestUserReconciler(t *testing.T) {
 			log := logr.Discard()
 
 			// This method doesn't return an error, it just logs
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 
 			// Test passes if no panic occurs and mocks are satisfied
 			mockUserPool.AssertExpectations(t)
 		})
 
-		t.Run("delete user with username fallback", func(t *testing.T) {
+		t.Run("user without sub is not looked up", func(t *testing.T) {
 			mockUserPool := mocks.NewMockUserPoolClient(t)
-			mockUserPool.On("GetUser", mock.Anything, "test-user").Return(&userpool.User{
-				Username: "test-user",
-			}, nil)
-			mockUserPool.On("DeleteUser", mock.Anything, "test-user").Return(nil)
 
 			reconciler := &UserReconciler{
 				UserPoolClient: mockUserPool,
 			}
 
-			log := logr.Discard()
-
-			// This method doesn't return an error, it just logs
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser(""), logr.Discard())
 
-			// Test passes if no panic occurs and mocks are satisfied
-			mockUserPool.AssertExpectations(t)
+			mockUserPool.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
+			mockUserPool.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
 		})
 
 		t.Run("user not found in pool", func(t *testing.T) {
@@ -240,7 +292,7 @@ func TestUserReconciler(t *testing.T) {
 			log := logr.Discard()
 
 			// This method doesn't return an error, it just logs
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 
 			// Test passes if no panic occurs and mocks are satisfied
 			mockUserPool.AssertExpectations(t)
@@ -261,7 +313,7 @@ func TestUserReconciler(t *testing.T) {
 			log := logr.Discard()
 
 			// This method doesn't return an error, it just logs
-			reconciler.deleteUserFromUserPool(context.Background(), "test-user", "test-sub-123", log)
+			reconciler.deleteUserFromUserPool(context.Background(), deletedUser("test-sub-123"), log)
 
 			// Test passes if no panic occurs and mocks are satisfied
 			mockUserPool.AssertExpectations(t)
@@ -274,7 +326,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -284,6 +336,7 @@ func TestUserReconciler(t *testing.T) {
 				Email:    "test@example.com",
 				Enabled:  true,
 				Sub:      "test-sub-123",
+				Status:   "FORCE_CHANGE_PASSWORD",
 			}, nil)
 
 			reconciler := &UserReconciler{
@@ -296,7 +349,7 @@ func TestUserReconciler(t *testing.T) {
 			require.NoError(t, err)
 			assert.NotNil(t, user.Status.LastSyncTime)
 			assert.Equal(t, "test-sub-123", user.Status.Sub)
-			assert.Equal(t, "CONFIRMED", user.Status.UserPoolStatus)
+			assert.Equal(t, "FORCE_CHANGE_PASSWORD", user.Status.UserPoolStatus)
 		})
 
 		t.Run("update existing user", func(t *testing.T) {
@@ -304,7 +357,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "updated@example.com",
-					Enabled: false,
+					Enabled: ptr.To(false),
 				},
 				Status: kcpv1alpha1.UserStatus{
 					Sub: "test-sub-123",
@@ -336,7 +389,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 				Status: kcpv1alpha1.UserStatus{
 					Sub: "test-sub-123",
@@ -364,12 +417,246 @@ func TestUserReconciler(t *testing.T) {
 			mockUserPool.AssertExpectations(t)
 		})
 
+		t.Run("update user removes attributes dropped from spec", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:     "test@example.com",
+					Enabled:   ptr.To(true),
+					GivenName: "Jane",
+					CustomAttributes: map[string]string{
+						"department": "engineering",
+					},
+				},
+				Status: kcpv1alpha1.UserStatus{
+					Sub:               "test-sub-123",
+					ManagedAttributes: []string{"custom:department", "family_name", "given_name", "locale"},
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+				Username: "test-user",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+				Attributes: map[string]string{
+					"given_name":        "Jane",
+					"family_name":       "Doe",
+					"custom:department": "sales",
+					"custom:team":       "platform",
+					"zoneinfo":          "Europe/Warsaw",
+				},
+			}, nil)
+			mockUserPool.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+				return u.Sub == "test-sub-123" &&
+					u.Attributes["given_name"] == "Jane" &&
+					u.Attributes["custom:department"] == "engineering"
+			})).Return(nil)
+			// custom:team was never applied from the spec and is kept
+			mockUserPool.On("DeleteUserAttributes", mock.Anything, "test-sub-123",
+				[]string{"family_name"}).Return(nil)
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			log := logr.Discard()
+			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
+
+			require.NoError(t, err)
+			mockUserPool.AssertExpectations(t)
+			assert.Equal(t, []string{"custom:department", "given_name"}, user.Status.ManagedAttributes)
+		})
+
+		t.Run("update user keeps attributes it never applied", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: ptr.To(true),
+					Locale:  "en-US",
+				},
+				Status: kcpv1alpha1.UserStatus{
+					Sub: "test-sub-123",
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+				Username: "test-user",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+				Attributes: map[string]string{
+					"locale":      "en-US",
+					"given_name":  "Jane",
+					"custom:team": "platform",
+				},
+			}, nil)
+			mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+			require.NoError(t, err)
+			mockUserPool.AssertNotCalled(t, "DeleteUserAttributes", mock.Anything, mock.Anything, mock.Anything)
+			assert.Equal(t, []string{"locale"}, user.Status.ManagedAttributes)
+		})
+
+		t.Run("remove attributes fails", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: ptr.To(true),
+				},
+				Status: kcpv1alpha1.UserStatus{
+					Sub:               "test-sub-123",
+					ManagedAttributes: []string{"locale"},
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+				Username:   "test-user",
+				Email:      "test@example.com",
+				Enabled:    true,
+				Sub:        "test-sub-123",
+				Attributes: map[string]string{"locale": "en-US"},
+			}, nil)
+			mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
+			mockUserPool.On("DeleteUserAttributes", mock.Anything, "test-sub-123", []string{"locale"}).
+				Return(errors.New("delete failed"))
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			log := logr.Discard()
+			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to remove user attributes")
+		})
+
+		t.Run("update user converges group membership", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: ptr.To(true),
+					Groups:  []string{"viewers", "admins"},
+				},
+				Status: kcpv1alpha1.UserStatus{
+					Sub: "test-sub-123",
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+				Username: "test-user",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+			}, nil)
+			mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
+			mockUserPool.On("ListGroupsForUser", mock.Anything, "test-sub-123").
+				Return([]string{"viewers", "editors"}, nil)
+			mockUserPool.On("AddUserToGroup", mock.Anything, "test-sub-123", "admins").Return(nil)
+			mockUserPool.On("RemoveUserFromGroup", mock.Anything, "test-sub-123", "editors").Return(nil)
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			log := logr.Discard()
+			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"admins", "viewers"}, user.Status.Groups)
+			mockUserPool.AssertExpectations(t)
+		})
+
+		t.Run("groups removed from spec are revoked", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: ptr.To(true),
+				},
+				Status: kcpv1alpha1.UserStatus{
+					Sub:    "test-sub-123",
+					Groups: []string{"admins"},
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+				Username: "test-user",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+			}, nil)
+			mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
+			mockUserPool.On("ListGroupsForUser", mock.Anything, "test-sub-123").Return([]string{"admins"}, nil)
+			mockUserPool.On("RemoveUserFromGroup", mock.Anything, "test-sub-123", "admins").Return(nil)
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			log := logr.Discard()
+			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
+
+			require.NoError(t, err)
+			assert.Empty(t, user.Status.Groups)
+			mockUserPool.AssertExpectations(t)
+		})
+
+		t.Run("group membership sync fails", func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: ptr.To(true),
+					Groups:  []string{"admins"},
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("CreateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(&userpool.User{
+				Username: "test-user",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+				Status:   "FORCE_CHANGE_PASSWORD",
+			}, nil)
+			mockUserPool.On("ListGroupsForUser", mock.Anything, "test-sub-123").Return(nil, nil)
+			mockUserPool.On("AddUserToGroup", mock.Anything, "test-sub-123", "admins").
+				Return(errors.New("group not found"))
+
+			reconciler := &UserReconciler{
+				UserPoolClient: mockUserPool,
+			}
+
+			log := logr.Discard()
+			err := reconciler.syncUserWithUserPool(context.Background(), user, log)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to sync group membership")
+			assert.Equal(t, "test-sub-123", user.Status.Sub)
+		})
+
 		t.Run("create user fails", func(t *testing.T) {
 			user := &kcpv1alpha1.User{
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -392,7 +679,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "updated@example.com",
-					Enabled: false,
+					Enabled: ptr.To(false),
 				},
 				Status: kcpv1alpha1.UserStatus{
 					Sub: "test-sub-123",
@@ -424,7 +711,7 @@ func TestUserReconciler(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
 				Spec: kcpv1alpha1.UserSpec{
 					Email:   "test@example.com",
-					Enabled: true,
+					Enabled: ptr.To(true),
 				},
 			}
 
@@ -441,6 +728,1072 @@ func TestUserReconciler(t *testing.T) {
 	})
 }
 
+func TestUserAdoption(t *testing.T) {
+	const self = "root:org:team/default/test-user"
+
+	tests := []struct {
+		name          string
+		policy        kcpv1alpha1.AdoptionPolicy
+		owner         string
+		ownerExists   bool
+		expectAdopted bool
+	}{
+		{name: "never refuses unowned user", policy: kcpv1alpha1.AdoptionPolicyNever},
+		{name: "unset policy refuses unowned user"},
+		{name: "never reclaims own user", policy: kcpv1alpha1.AdoptionPolicyNever, owner: self, expectAdopted: true},
+		{name: "if unowned adopts unowned user", policy: kcpv1alpha1.AdoptionPolicyIfUnowned, expectAdopted: true},
+		{
+			name:   "if unowned refuses user owned elsewhere",
+			policy: kcpv1alpha1.AdoptionPolicyIfUnowned,
+			owner:  "root:org:other/default/test-user",
+		},
+		{
+			name:          "always adopts user whose owner is gone",
+			policy:        kcpv1alpha1.AdoptionPolicyAlways,
+			owner:         "root:org:other/default/test-user",
+			expectAdopted: true,
+		},
+		{
+			name:        "always refuses user owned by a live User elsewhere",
+			policy:      kcpv1alpha1.AdoptionPolicyAlways,
+			owner:       "root:org:other/default/test-user",
+			ownerExists: true,
+		},
+		{
+			name:   "always refuses user whose owner cannot be checked",
+			policy: kcpv1alpha1.AdoptionPolicyAlways,
+			owner:  "root:org:unbound/default/test-user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-user",
+					Namespace:   "default",
+					Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+				},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:          "test@example.com",
+					AdoptionPolicy: tt.policy,
+				},
+			}
+
+			var existingAttributes map[string]string
+			if tt.owner != "" {
+				existingAttributes = map[string]string{userpool.OwnerAttribute: tt.owner}
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+				return u.Attributes[userpool.OwnerAttribute] == self
+			})).Return(nil, fmt.Errorf("failed to create user: %w", userpool.ErrUserExists))
+			mockUserPool.On("GetUser", mock.Anything, "test@example.com").Return(&userpool.User{
+				Sub:        "existing-sub",
+				Email:      "test@example.com",
+				Attributes: existingAttributes,
+			}, nil)
+			if tt.expectAdopted {
+				mockUserPool.On("GetUser", mock.Anything, "existing-sub").Return(&userpool.User{
+					Sub:        "existing-sub",
+					Email:      "test@example.com",
+					Attributes: existingAttributes,
+				}, nil)
+				mockUserPool.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+					return u.Sub == "existing-sub" && u.Attributes[userpool.OwnerAttribute] == self
+				})).Return(nil)
+			}
+
+			workspaces, clients := newEmailClaims(t, "root:org:other")
+			if tt.ownerExists {
+				require.NoError(t, clients["root:org:other"].Create(context.Background(), &kcpv1alpha1.User{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-user", Namespace: "default"},
+					Spec:       kcpv1alpha1.UserSpec{Email: "test@example.com"},
+				}))
+			}
+
+			reconciler := &UserReconciler{UserPoolClient: mockUserPool, GetClient: workspaces.GetClient}
+			err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+			condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserCreatedCondition)
+			require.NotNil(t, condition)
+			if tt.expectAdopted {
+				require.NoError(t, err)
+				assert.Equal(t, "existing-sub", user.Status.Sub)
+				assert.Equal(t, metav1.ConditionTrue, condition.Status)
+				assert.Equal(t, "UserAdopted", condition.Reason)
+			} else {
+				require.Error(t, err)
+				assert.Empty(t, user.Status.Sub)
+				assert.Equal(t, metav1.ConditionFalse, condition.Status)
+				assert.Equal(t, "AdoptionRefused", condition.Reason)
+			}
+		})
+	}
+}
+
+func TestUserAdoptionByUsername(t *testing.T) {
+	tests := []struct {
+		name          string
+		existingEmail string
+		expectAdopted bool
+	}{
+		{name: "adopts the identity holding the username", existingEmail: "Test@example.com", expectAdopted: true},
+		{name: "refuses the username of another email", existingEmail: "other@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-user",
+					Namespace:   "default",
+					Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+				},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:          "test@example.com",
+					AdoptionPolicy: kcpv1alpha1.AdoptionPolicyIfUnowned,
+				},
+			}
+			usernames, err := NewUsernames(UsernameStrategyName, "")
+			require.NoError(t, err)
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+				return u.Username == "test-user"
+			})).Return(nil, fmt.Errorf("failed to create user: %w", userpool.ErrUserExists))
+			existing := &userpool.User{Username: "test-user", Sub: "existing-sub", Email: tt.existingEmail}
+			mockUserPool.On("GetUser", mock.Anything, "test-user").Return(existing, nil)
+			if tt.expectAdopted {
+				mockUserPool.On("GetUser", mock.Anything, "existing-sub").Return(existing, nil)
+				mockUserPool.On("UpdateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+					return u.Sub == "existing-sub"
+				})).Return(nil)
+			}
+
+			reconciler := &UserReconciler{UserPoolClient: mockUserPool, Usernames: usernames}
+			err = reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+			condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserCreatedCondition)
+			require.NotNil(t, condition)
+			if tt.expectAdopted {
+				require.NoError(t, err)
+				assert.Equal(t, "existing-sub", user.Status.Sub)
+				assert.Equal(t, "UserAdopted", condition.Reason)
+			} else {
+				require.Error(t, err)
+				assert.Empty(t, user.Status.Sub)
+				assert.Equal(t, "AdoptionRefused", condition.Reason)
+				assert.Contains(t, condition.Message, "Username test-user is taken by a user with another email")
+			}
+		})
+	}
+}
+
+func TestUserDeletionKeepsUnmanagedIdentity(t *testing.T) {
+	ctx := context.Background()
+	api := cognitofake.New()
+	poolID := api.CreateUserPoolWithUsernameAttributes("tenants", nil)
+	server := httptest.NewServer(api.Handler())
+	t.Cleanup(server.Close)
+	poolClient, err := cognito.NewClientFromConfig(ctx, cognito.Config{
+		UserPoolID:      poolID,
+		Region:          cognitofake.Region,
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	})
+	require.NoError(t, err)
+	existing, err := poolClient.CreateUser(ctx, &userpool.User{
+		Username: "test-user",
+		Email:    "someone@example.com",
+		Enabled:  true,
+	})
+	require.NoError(t, err)
+
+	usernames, err := NewUsernames(UsernameStrategyName, "")
+	require.NoError(t, err)
+	reconciler := &UserReconciler{UserPoolClient: poolClient, Usernames: usernames}
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-user",
+			Namespace:   "default",
+			Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+		},
+		Spec: kcpv1alpha1.UserSpec{Email: "test@example.com"},
+	}
+	require.Error(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+	require.Empty(t, user.Status.Sub, "creating the identity is refused because its username is taken")
+
+	require.NoError(t, reconciler.cleanupUserInUserPool(ctx, user, logr.Discard()))
+
+	survivor, err := poolClient.GetUser(ctx, existing.Sub)
+	require.NoError(t, err, "the identity the User never owned must survive its deletion")
+	assert.Equal(t, "someone@example.com", survivor.Email)
+}
+
+func TestUserDeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        kcpv1alpha1.DeletionPolicy
+		defaultPolicy kcpv1alpha1.DeletionPolicy
+		setupMocks    func(*mocks.MockUserPoolClient)
+		expectErr     bool
+	}{
+		{
+			name: "delete by default",
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+				mockUserPool.On("DeleteUser", mock.Anything, "test-sub-123").Return(nil)
+			},
+		},
+		{
+			name:          "controller default disable",
+			defaultPolicy: kcpv1alpha1.DeletionPolicyDisable,
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+				mockUserPool.On("DisableUser", mock.Anything, "test-sub-123").Return(nil)
+			},
+		},
+		{
+			name:          "spec policy overrides controller default",
+			policy:        kcpv1alpha1.DeletionPolicyDelete,
+			defaultPolicy: kcpv1alpha1.DeletionPolicyRetain,
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+				mockUserPool.On("DeleteUser", mock.Anything, "test-sub-123").Return(nil)
+			},
+		},
+		{
+			name:       "retain leaves the user pool untouched",
+			policy:     kcpv1alpha1.DeletionPolicyRetain,
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {},
+		},
+		{
+			name:   "disable failure blocks cleanup",
+			policy: kcpv1alpha1.DeletionPolicyDisable,
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+				mockUserPool.On("DisableUser", mock.Anything, "test-sub-123").Return(errors.New("throttled"))
+			},
+			expectErr: true,
+		},
+		{
+			name:   "delete failure does not block cleanup",
+			policy: kcpv1alpha1.DeletionPolicyDelete,
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+				mockUserPool.On("DeleteUser", mock.Anything, "test-sub-123").Return(errors.New("throttled"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:          "test@example.com",
+					DeletionPolicy: tt.policy,
+				},
+				Status: kcpv1alpha1.UserStatus{Sub: "test-sub-123"},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			tt.setupMocks(mockUserPool)
+
+			reconciler := &UserReconciler{
+				UserPoolClient:        mockUserPool,
+				DefaultDeletionPolicy: tt.defaultPolicy,
+			}
+			err := reconciler.cleanupUserInUserPool(context.Background(), user, logr.Discard())
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserEnabledDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  *bool
+		expected bool
+	}{
+		{name: "unset enabled field", enabled: nil, expected: true},
+		{name: "explicitly enabled", enabled: ptr.To(true), expected: true},
+		{name: "explicitly disabled", enabled: ptr.To(false), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &kcpv1alpha1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+				Spec: kcpv1alpha1.UserSpec{
+					Email:   "test@example.com",
+					Enabled: tt.enabled,
+				},
+			}
+
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+				return u.Enabled == tt.expected
+			})).Return(&userpool.User{Sub: "test-sub-123"}, nil)
+
+			reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+			err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUserInvitation(t *testing.T) {
+	t.Run("create sends invitation and records expiration", func(t *testing.T) {
+		user := &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+			Spec: kcpv1alpha1.UserSpec{
+				Email:   "test@example.com",
+				Enabled: ptr.To(true),
+				Invitation: &kcpv1alpha1.InvitationSpec{
+					DeliveryMediums: []kcpv1alpha1.DeliveryMedium{kcpv1alpha1.DeliveryMediumEmail},
+					ResendToken:     "initial",
+				},
+			},
+		}
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+			return assert.ObjectsAreEqual([]string{"EMAIL"}, u.InvitationDeliveryMediums)
+		})).Return(&userpool.User{Sub: "test-sub-123"}, nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool, TemporaryPasswordValidity: 24 * time.Hour}
+		err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+		require.NoError(t, err)
+		require.NotNil(t, user.Status.InvitationSentTime)
+		require.NotNil(t, user.Status.TemporaryPasswordExpirationTime)
+		assert.Equal(t, 24*time.Hour,
+			user.Status.TemporaryPasswordExpirationTime.Sub(user.Status.InvitationSentTime.Time))
+		assert.Equal(t, "initial", user.Status.InvitationResendToken)
+	})
+
+	t.Run("create without invitation suppresses it", func(t *testing.T) {
+		user := &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+			Spec:       kcpv1alpha1.UserSpec{Email: "test@example.com"},
+		}
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *userpool.User) bool {
+			return len(u.InvitationDeliveryMediums) == 0
+		})).Return(&userpool.User{Sub: "test-sub-123"}, nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Nil(t, user.Status.InvitationSentTime)
+		assert.Nil(t, user.Status.TemporaryPasswordExpirationTime)
+	})
+
+	newExistingUser := func(resendToken, handledToken string) *kcpv1alpha1.User {
+		return &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user"},
+			Spec: kcpv1alpha1.UserSpec{
+				Email: "test@example.com",
+				Invitation: &kcpv1alpha1.InvitationSpec{
+					DeliveryMediums: []kcpv1alpha1.DeliveryMedium{
+						kcpv1alpha1.DeliveryMediumEmail, kcpv1alpha1.DeliveryMediumSMS,
+					},
+					ResendToken: resendToken,
+				},
+			},
+			Status: kcpv1alpha1.UserStatus{Sub: "test-sub-123", InvitationResendToken: handledToken},
+		}
+	}
+	newUpdateMock := func(t *testing.T) *mocks.MockUserPoolClient {
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{Sub: "test-sub-123"}, nil)
+		mockUserPool.On("UpdateUser", mock.Anything, mock.AnythingOfType("*userpool.User")).Return(nil)
+		return mockUserPool
+	}
+
+	t.Run("changed resend token resends invitation", func(t *testing.T) {
+		user := newExistingUser("second", "initial")
+
+		mockUserPool := newUpdateMock(t)
+		mockUserPool.On("ResendInvitation", mock.Anything, "test-sub-123", []string{"EMAIL", "SMS"}).Return(nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, "second", user.Status.InvitationResendToken)
+		require.NotNil(t, user.Status.TemporaryPasswordExpirationTime)
+		assert.Equal(t, defaultTemporaryPasswordValidity,
+			user.Status.TemporaryPasswordExpirationTime.Sub(user.Status.InvitationSentTime.Time))
+	})
+
+	t.Run("handled resend token is ignored", func(t *testing.T) {
+		user := newExistingUser("initial", "initial")
+
+		// ResendInvitation must not be called
+		reconciler := &UserReconciler{UserPoolClient: newUpdateMock(t)}
+		err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Nil(t, user.Status.InvitationSentTime)
+	})
+
+	t.Run("resend failure", func(t *testing.T) {
+		user := newExistingUser("second", "initial")
+
+		mockUserPool := newUpdateMock(t)
+		mockUserPool.On("ResendInvitation", mock.Anything, "test-sub-123", []string{"EMAIL", "SMS"}).
+			Return(errors.New("user is already confirmed"))
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserWithUserPool(context.Background(), user, logr.Discard())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resend invitation")
+		assert.Equal(t, "initial", user.Status.InvitationResendToken)
+		condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserSyncedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	})
+}
+
+func TestSyncUserPassword(t *testing.T) {
+	newUser := func(ref *kcpv1alpha1.PasswordSecretReference, status kcpv1alpha1.UserStatus) *kcpv1alpha1.User {
+		return &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-user", Namespace: "default"},
+			Spec: kcpv1alpha1.UserSpec{
+				Email:             "test@example.com",
+				Enabled:           ptr.To(true),
+				PasswordSecretRef: ref,
+			},
+			Status: status,
+		}
+	}
+	newReader := func() *fake.ClientBuilder {
+		return fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-password", Namespace: "default", ResourceVersion: "42"},
+			Data: map[string][]byte{
+				"password": []byte("Initial#Passw0rd"),
+				"other":    []byte("Other#Passw0rd"),
+			},
+		})
+	}
+
+	t.Run("applies temporary password from secret", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password"},
+			kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("SetUserPassword", mock.Anything, "test-sub-123", "Initial#Passw0rd", false).Return(nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, kcpv1alpha1.PasswordStatusTemporary, user.Status.PasswordStatus)
+		assert.Equal(t, "42", user.Status.PasswordSecretVersion)
+	})
+
+	t.Run("applies permanent password from custom key", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password", Key: "other", Permanent: true},
+			kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("SetUserPassword", mock.Anything, "test-sub-123", "Other#Passw0rd", true).Return(nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, kcpv1alpha1.PasswordStatusPermanent, user.Status.PasswordStatus)
+	})
+
+	t.Run("detects consumed temporary password", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password"}, kcpv1alpha1.UserStatus{
+			Sub:                   "test-sub-123",
+			PasswordStatus:        kcpv1alpha1.PasswordStatusTemporary,
+			PasswordSecretVersion: "42",
+		})
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+			Sub:                    "test-sub-123",
+			PasswordChangeRequired: false,
+		}, nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, kcpv1alpha1.PasswordStatusConsumed, user.Status.PasswordStatus)
+	})
+
+	t.Run("temporary password still pending", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password"}, kcpv1alpha1.UserStatus{
+			Sub:                   "test-sub-123",
+			PasswordStatus:        kcpv1alpha1.PasswordStatusTemporary,
+			PasswordSecretVersion: "42",
+		})
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("GetUser", mock.Anything, "test-sub-123").Return(&userpool.User{
+			Sub:                    "test-sub-123",
+			PasswordChangeRequired: true,
+		}, nil)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, kcpv1alpha1.PasswordStatusTemporary, user.Status.PasswordStatus)
+	})
+
+	t.Run("unchanged secret is not reapplied", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password"}, kcpv1alpha1.UserStatus{
+			Sub:                   "test-sub-123",
+			PasswordStatus:        kcpv1alpha1.PasswordStatusConsumed,
+			PasswordSecretVersion: "42",
+		})
+
+		// No calls expected on the user pool
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+		assert.Equal(t, kcpv1alpha1.PasswordStatusConsumed, user.Status.PasswordStatus)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "missing"},
+			kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		reconciler := &UserReconciler{UserPoolClient: mocks.NewMockUserPoolClient(t)}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read password secret")
+	})
+
+	t.Run("missing secret key", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password", Key: "missing"},
+			kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		reconciler := &UserReconciler{UserPoolClient: mocks.NewMockUserPoolClient(t)}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no key missing")
+	})
+
+	t.Run("set password fails", func(t *testing.T) {
+		user := newUser(&kcpv1alpha1.PasswordSecretReference{Name: "test-password"},
+			kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("SetUserPassword", mock.Anything, "test-sub-123", "Initial#Passw0rd", false).
+			Return(errors.New("password does not conform to policy"))
+
+		reconciler := &UserReconciler{UserPoolClient: mockUserPool}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.Error(t, err)
+		assert.Empty(t, user.Status.PasswordStatus)
+		assert.Empty(t, user.Status.PasswordSecretVersion)
+	})
+
+	t.Run("no password reference", func(t *testing.T) {
+		user := newUser(nil, kcpv1alpha1.UserStatus{Sub: "test-sub-123"})
+
+		reconciler := &UserReconciler{UserPoolClient: mocks.NewMockUserPoolClient(t)}
+		err := reconciler.syncUserPassword(context.Background(), newReader().Build(), user, logr.Discard())
+
+		require.NoError(t, err)
+	})
+}
+
+// fakeManager serves engaged workspaces backed by fake clusters
+type fakeManager struct {
+	mcmanager.Manager
+	clusters map[string]cluster.Cluster
+}
+
+func (m *fakeManager) GetCluster(_ context.Context, name string) (cluster.Cluster, error) {
+	if cl, ok := m.clusters[name]; ok {
+		return cl, nil
+	}
+	return nil, fmt.Errorf("cluster %s not engaged", name)
+}
+
+func TestPasswordSecretRotation(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-password", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("Initial#Passw0rd")},
+	}
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-user",
+			Namespace:  "default",
+			Generation: 1,
+			Finalizers: []string{"kcp.cogniteo.io/user-pool-cleanup"},
+		},
+		Spec: kcpv1alpha1.UserSpec{
+			Email:             "test@example.com",
+			PasswordSecretRef: &kcpv1alpha1.PasswordSecretReference{Name: "test-password", Permanent: true},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, other, user).
+		WithStatusSubresource(&kcpv1alpha1.User{}).Build()
+	require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+	user.Status = kcpv1alpha1.UserStatus{
+		Sub:                   "test-sub-123",
+		ObservedGeneration:    1,
+		PasswordStatus:        kcpv1alpha1.PasswordStatusPermanent,
+		PasswordSecretVersion: secret.ResourceVersion,
+	}
+	require.NoError(t, cl.Status().Update(ctx, user))
+
+	mockUserPool := mocks.NewMockUserPoolClient(t)
+	reconciler := &UserReconciler{
+		Manager:        &fakeManager{clusters: map[string]cluster.Cluster{"root:org": &fakeCluster{client: cl}}},
+		UserPoolClient: mockUserPool,
+	}
+	request := mcreconcile.Request{
+		Request:     reconcile.Request{NamespacedName: client.ObjectKeyFromObject(user)},
+		ClusterName: "root:org",
+	}
+
+	t.Run("unchanged secret is skipped", func(t *testing.T) {
+		_, err := reconciler.Reconcile(ctx, request)
+
+		require.NoError(t, err)
+		mockUserPool.AssertNotCalled(t, "SetUserPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("changed secret enqueues the users referencing it", func(t *testing.T) {
+		secret.Data["password"] = []byte("Rotated#Passw0rd")
+		require.NoError(t, cl.Update(ctx, secret))
+
+		assert.Equal(t, []mcreconcile.Request{request}, usersForSecret(ctx, cl, "root:org", secret))
+		assert.Empty(t, usersForSecret(ctx, cl, "root:org", other))
+	})
+
+	t.Run("changed secret is applied", func(t *testing.T) {
+		mockUserPool.On("SetUserPassword", mock.Anything, "test-sub-123", "Rotated#Passw0rd", true).Return(nil).Once()
+
+		_, err := reconciler.Reconcile(ctx, request)
+
+		require.NoError(t, err)
+		mockUserPool.AssertExpectations(t)
+		require.NoError(t, cl.Get(ctx, client.ObjectKeyFromObject(user), user))
+		assert.Equal(t, secret.ResourceVersion, user.Status.PasswordSecretVersion)
+	})
+}
+
+// scopedUserPoolClient is a mock client of the user pool identified by scope
+type scopedUserPoolClient struct {
+	*mocks.MockUserPoolClient
+	scope string
+}
+
+func (c *scopedUserPoolClient) Scope() string {
+	return c.scope
+}
+
+func TestUserPoolChange(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			Generation:  2,
+			Annotations: map[string]string{"kcp.io/cluster": "team-a"},
+			Finalizers:  []string{"kcp.cogniteo.io/user-pool-cleanup"},
+		},
+		Spec: kcpv1alpha1.UserSpec{
+			Email:          "jane@example.com",
+			DeletionPolicy: kcpv1alpha1.DeletionPolicyDelete,
+		},
+	}
+	workspace := fake.NewClientBuilder().WithScheme(scheme).WithObjects(user).
+		WithStatusSubresource(&kcpv1alpha1.User{}).Build()
+	claimWorkspace := fake.NewClientBuilder().WithScheme(scheme).Build()
+	claims := &EmailClaims{
+		Workspace: "claims",
+		GetClient: func(_ context.Context, clusterName string) (client.Client, error) {
+			if clusterName == "claims" {
+				return claimWorkspace, nil
+			}
+			return workspace, nil
+		},
+	}
+	_, err := claims.Claim(ctx, "eu-west-1/eu-west-1_old", "jane@example.com", "team-a/default/jane", false)
+	require.NoError(t, err)
+	user.Status = kcpv1alpha1.UserStatus{
+		Sub:                "jane-sub",
+		UserPool:           "eu-west-1/eu-west-1_old",
+		ClaimedEmail:       "jane@example.com",
+		ObservedGeneration: 1,
+	}
+	require.NoError(t, workspace.Status().Update(ctx, user))
+
+	// The mock has no expectations, so any call to the new user pool fails the test
+	mockUserPool := mocks.NewMockUserPoolClient(t)
+	reconciler := &UserReconciler{
+		Manager:        &fakeManager{clusters: map[string]cluster.Cluster{"team-a": &fakeCluster{client: workspace}}},
+		UserPoolClient: &scopedUserPoolClient{MockUserPoolClient: mockUserPool, scope: "eu-west-1/eu-west-1_new"},
+		EmailClaims:    claims,
+	}
+	request := mcreconcile.Request{
+		Request:     reconcile.Request{NamespacedName: client.ObjectKeyFromObject(user)},
+		ClusterName: "team-a",
+	}
+
+	t.Run("user is not synced to the new user pool", func(t *testing.T) {
+		_, err := reconciler.Reconcile(ctx, request)
+
+		require.Error(t, err)
+		require.NoError(t, workspace.Get(ctx, client.ObjectKeyFromObject(user), user))
+		assert.Equal(t, "jane-sub", user.Status.Sub)
+		assert.Equal(t, "eu-west-1/eu-west-1_old", user.Status.UserPool)
+		condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserSyncedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, userPoolChangedReason, condition.Reason)
+		assert.Contains(t, condition.Message, "eu-west-1/eu-west-1_new")
+	})
+
+	t.Run("deleting the user releases its claim in the previous user pool", func(t *testing.T) {
+		require.NoError(t, workspace.Delete(ctx, user))
+
+		_, err := reconciler.Reconcile(ctx, request)
+
+		require.NoError(t, err)
+		var leases coordinationv1.LeaseList
+		require.NoError(t, claimWorkspace.List(ctx, &leases))
+		assert.Empty(t, leases.Items)
+		err = workspace.Get(ctx, client.ObjectKeyFromObject(user), user)
+		assert.True(t, apierrors.IsNotFound(err), "the finalizer is removed")
+	})
+}
+
+func TestUserLifecycleWithMemoryUserPool(t *testing.T) {
+	ctx := context.Background()
+	userPool := memory.NewClient(memory.WithCustomAttributes("department"))
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	reconciler := &UserReconciler{UserPoolClient: userPool}
+
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+		},
+		Spec: kcpv1alpha1.UserSpec{
+			Email:            "jane@example.com",
+			GivenName:        "Jane",
+			CustomAttributes: map[string]string{"department": "engineering"},
+			Groups:           []string{"developers"},
+		},
+	}
+
+	// Create
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+	require.NotEmpty(t, user.Status.Sub)
+	poolUser, err := userPool.GetUser(ctx, user.Status.Sub)
+	require.NoError(t, err)
+	assert.True(t, poolUser.Enabled)
+	assert.Equal(t, map[string]string{
+		userpool.AttributeGivenName: "Jane",
+		"custom:department":         "engineering",
+		userpool.OwnerAttribute:     "root:org:team/default/jane",
+	}, poolUser.Attributes)
+	groups, err := userPool.ListGroupsForUser(ctx, user.Status.Sub)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"developers"}, groups)
+
+	// Another workspace cannot take over the identity
+	intruder := user.DeepCopy()
+	intruder.Annotations = map[string]string{"kcp.io/cluster": "root:org:other"}
+	intruder.Status = kcpv1alpha1.UserStatus{}
+	require.Error(t, reconciler.syncUserWithUserPool(ctx, intruder, logr.Discard()))
+	assert.Equal(t, "AdoptionRefused",
+		meta.FindStatusCondition(intruder.Status.Conditions, kcpv1alpha1.UserCreatedCondition).Reason)
+
+	// Update
+	user.Spec.Enabled = ptr.To(false)
+	user.Spec.CustomAttributes = nil
+	user.Spec.Groups = []string{"admins"}
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+	poolUser, err = userPool.GetUser(ctx, user.Status.Sub)
+	require.NoError(t, err)
+	assert.False(t, poolUser.Enabled)
+	assert.NotContains(t, poolUser.Attributes, "custom:department")
+	groups, err = userPool.ListGroupsForUser(ctx, user.Status.Sub)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admins"}, groups)
+
+	// Delete
+	require.NoError(t, reconciler.cleanupUserInUserPool(ctx, user, logr.Discard()))
+	users, err := userPool.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestUserOwnershipMarkers(t *testing.T) {
+	ctx := context.Background()
+
+	// newUser returns a User synced to a fresh memory user pool by the given controller instance
+	newUser := func(t *testing.T, userPool *memory.Client, reconciler *UserReconciler) *kcpv1alpha1.User {
+		user := &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "jane",
+				Namespace:   "default",
+				UID:         "uid-1",
+				Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+			},
+			Spec: kcpv1alpha1.UserSpec{Email: "jane@example.com", DeletionPolicy: kcpv1alpha1.DeletionPolicyDelete},
+		}
+		require.NoError(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+		return user
+	}
+
+	t.Run("markers are stamped", func(t *testing.T) {
+		userPool := memory.NewClient()
+		user := newUser(t, userPool, &UserReconciler{UserPoolClient: userPool, InstanceID: "eu-1"})
+
+		poolUser, err := userPool.GetUser(ctx, user.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			userpool.OwnerAttribute:    "root:org:team/default/jane",
+			userpool.OwnerUIDAttribute: "uid-1",
+			userpool.InstanceAttribute: "eu-1",
+		}, poolUser.Attributes)
+	})
+
+	t.Run("identities of other instances are left untouched", func(t *testing.T) {
+		userPool := memory.NewClient()
+		user := newUser(t, userPool, &UserReconciler{UserPoolClient: userPool, InstanceID: "eu-1"})
+		other := &UserReconciler{UserPoolClient: userPool, InstanceID: "us-1"}
+
+		user.Spec.Enabled = ptr.To(false)
+		err := other.syncUserWithUserPool(ctx, user, logr.Discard())
+		require.ErrorContains(t, err, "is managed by controller instance eu-1")
+		synced := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserSyncedCondition)
+		require.NotNil(t, synced)
+		assert.Equal(t, ownershipConflictReason, synced.Reason)
+
+		require.NoError(t, other.cleanupUserInUserPool(ctx, user, logr.Discard()))
+		user.Spec.DeletionPolicy = kcpv1alpha1.DeletionPolicyDisable
+		require.NoError(t, other.cleanupUserInUserPool(ctx, user, logr.Discard()))
+		poolUser, err := userPool.GetUser(ctx, user.Status.Sub)
+		require.NoError(t, err, "the identity is not deleted")
+		assert.True(t, poolUser.Enabled, "the identity is not disabled")
+	})
+
+	t.Run("recreated Users do not update the previous identity", func(t *testing.T) {
+		userPool := memory.NewClient()
+		reconciler := &UserReconciler{UserPoolClient: userPool}
+		user := newUser(t, userPool, reconciler)
+
+		recreated := user.DeepCopy()
+		recreated.UID = "uid-2"
+		require.ErrorContains(t, reconciler.syncUserWithUserPool(ctx, recreated, logr.Discard()),
+			"is owned by another User with the same name, UID uid-1")
+
+		// Without a recorded sub the User goes through adoption, which reclaims its own identity
+		recreated.Status = kcpv1alpha1.UserStatus{}
+		require.NoError(t, reconciler.syncUserWithUserPool(ctx, recreated, logr.Discard()))
+		poolUser, err := userPool.GetUser(ctx, recreated.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "uid-2", poolUser.Attributes[userpool.OwnerUIDAttribute])
+	})
+
+	t.Run("identities without markers are stamped on update", func(t *testing.T) {
+		userPool := memory.NewClient()
+		created, err := userPool.CreateUser(ctx, &userpool.User{Email: "jane@example.com", Enabled: true})
+		require.NoError(t, err)
+		reconciler := &UserReconciler{UserPoolClient: userPool, InstanceID: "eu-1"}
+		user := &kcpv1alpha1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "jane", Namespace: "default", UID: "uid-1"},
+			Spec:       kcpv1alpha1.UserSpec{Email: "jane@example.com"},
+			Status:     kcpv1alpha1.UserStatus{Sub: created.Sub},
+		}
+
+		require.NoError(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+
+		poolUser, err := userPool.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "uid-1", poolUser.Attributes[userpool.OwnerUIDAttribute])
+		assert.Equal(t, "eu-1", poolUser.Attributes[userpool.InstanceAttribute])
+	})
+}
+
+func TestUserDriftCorrection(t *testing.T) {
+	ctx := context.Background()
+	userPool := memory.NewClient()
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+	require.NoError(t, userPool.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	reconciler := &UserReconciler{UserPoolClient: userPool, ResyncInterval: time.Hour}
+
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+		},
+		Spec: kcpv1alpha1.UserSpec{
+			Email:     "jane@example.com",
+			GivenName: "Jane",
+			Groups:    []string{"developers"},
+		},
+	}
+	require.NoError(t, reconciler.syncUserWithUserPool(ctx, user, logr.Discard()))
+
+	t.Run("no drift", func(t *testing.T) {
+		require.NoError(t, reconciler.correctDrift(ctx, user, logr.Discard()))
+
+		condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserDriftedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "NoDrift", condition.Reason)
+	})
+
+	t.Run("changes made in the user pool are reverted", func(t *testing.T) {
+		require.NoError(t, userPool.UpdateUser(ctx, &userpool.User{
+			Sub:        user.Status.Sub,
+			Email:      "jane.doe@example.com",
+			Enabled:    false,
+			Attributes: map[string]string{userpool.AttributeGivenName: "Janet", userpool.AttributeLocale: "pl-PL"},
+		}))
+		require.NoError(t, userPool.AddUserToGroup(ctx, user.Status.Sub, "admins"))
+
+		require.NoError(t, reconciler.correctDrift(ctx, user, logr.Discard()))
+
+		poolUser, err := userPool.GetUser(ctx, user.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", poolUser.Email)
+		assert.True(t, poolUser.Enabled)
+		assert.Equal(t, "Jane", poolUser.Attributes[userpool.AttributeGivenName])
+		// The locale was never applied from the spec and is left to whoever set it
+		assert.Equal(t, "pl-PL", poolUser.Attributes[userpool.AttributeLocale])
+		groups, err := userPool.ListGroupsForUser(ctx, user.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"developers"}, groups)
+
+		condition := meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserDriftedCondition)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "DriftCorrected", condition.Reason)
+		assert.Equal(t, `User pool diverged from the spec: email is "jane.doe@example.com", want "jane@example.com"; `+
+			`enabled is false, want true; given_name is "Janet", want "Jane"; `+
+			`member of undeclared group admins`, condition.Message)
+	})
+
+	t.Run("deleted users are recreated", func(t *testing.T) {
+		previousSub := user.Status.Sub
+		require.NoError(t, userPool.DeleteUser(ctx, previousSub))
+
+		require.NoError(t, reconciler.correctDrift(ctx, user, logr.Discard()))
+
+		assert.NotEmpty(t, user.Status.Sub)
+		assert.NotEqual(t, previousSub, user.Status.Sub)
+		poolUser, err := userPool.GetUser(ctx, user.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", poolUser.Email)
+		groups, err := userPool.ListGroupsForUser(ctx, user.Status.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"developers"}, groups)
+		assert.Equal(t, "UserRecreated",
+			meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserDriftedCondition).Reason)
+	})
+
+	t.Run("failures are reported", func(t *testing.T) {
+		userPool.InjectError("GetUser", errors.New("TooManyRequestsException"))
+		defer userPool.InjectError("GetUser", nil)
+
+		require.Error(t, reconciler.correctDrift(ctx, user, logr.Discard()))
+
+		assert.Equal(t, "UserSyncFailed",
+			meta.FindStatusCondition(user.Status.Conditions, kcpv1alpha1.UserSyncedCondition).Reason)
+	})
+}
+
+func TestUserResyncSchedule(t *testing.T) {
+	recent := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	stale := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	tests := []struct {
+		name         string
+		interval     time.Duration
+		status       kcpv1alpha1.UserStatus
+		expectDue    bool
+		expectNextAt time.Duration
+	}{
+		{
+			name:     "disabled",
+			interval: 0,
+			status:   kcpv1alpha1.UserStatus{Sub: "sub", LastSyncTime: &stale},
+		},
+		{
+			name:     "user not created",
+			interval: time.Hour,
+		},
+		{
+			name:         "recently synced",
+			interval:     time.Hour,
+			status:       kcpv1alpha1.UserStatus{Sub: "sub", LastSyncTime: &recent},
+			expectNextAt: 50 * time.Minute,
+		},
+		{
+			name:         "interval elapsed",
+			interval:     time.Hour,
+			status:       kcpv1alpha1.UserStatus{Sub: "sub", LastSyncTime: &stale},
+			expectDue:    true,
+			expectNextAt: time.Second,
+		},
+		{
+			name:         "never synced",
+			interval:     time.Hour,
+			status:       kcpv1alpha1.UserStatus{Sub: "sub"},
+			expectDue:    true,
+			expectNextAt: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler := &UserReconciler{ResyncInterval: tt.interval}
+			user := &kcpv1alpha1.User{Status: tt.status}
+
+			assert.Equal(t, tt.expectDue, reconciler.resyncDue(user))
+			assert.InDelta(t, tt.expectNextAt, reconciler.nextResync(user), float64(time.Second))
+		})
+	}
+}
+
 func TestHelperFunctions(t *testing.T) {
 	t.Run("containsFinalizer", func(t *testing.T) {
 		tests := []struct {
@@ -517,4 +1870,120 @@ func TestHelperFunctions(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("desiredAttributes", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			spec     kcpv1alpha1.UserSpec
+			expected map[string]string
+		}{
+			{
+				name:     "no attributes",
+				spec:     kcpv1alpha1.UserSpec{Email: "test@example.com"},
+				expected: nil,
+			},
+			{
+				name: "standard and custom attributes",
+				spec: kcpv1alpha1.UserSpec{
+					Email:             "test@example.com",
+					GivenName:         "Jane",
+					FamilyName:        "Doe",
+					PhoneNumber:       "+48123456789",
+					Locale:            "pl-PL",
+					PreferredUsername: "jdoe",
+					CustomAttributes: map[string]string{
+						"department":  "engineering",
+						"custom:team": "platform",
+					},
+				},
+				expected: map[string]string{
+					"given_name":         "Jane",
+					"family_name":        "Doe",
+					"phone_number":       "+48123456789",
+					"locale":             "pl-PL",
+					"preferred_username": "jdoe",
+					"custom:department":  "engineering",
+					"custom:team":        "platform",
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, desiredAttributes(&tt.spec))
+			})
+		}
+	})
+
+	t.Run("staleAttributes", func(t *testing.T) {
+		current := map[string]string{
+			"given_name":    "Jane",
+			"locale":        "en-US",
+			"custom:team":   "platform",
+			"zoneinfo":      "Europe/Warsaw",
+			"custom:region": "eu",
+			"custom:kcp_id": "reserved",
+		}
+		desired := map[string]string{
+			"given_name":    "Jane",
+			"custom:region": "us",
+		}
+		applied := []string{"custom:kcp_id", "custom:region", "family_name", "given_name", "locale"}
+
+		assert.Equal(t, []string{"locale"}, staleAttributes(current, desired, applied))
+		assert.Empty(t, staleAttributes(current, desired, nil))
+		assert.Empty(t, staleAttributes(nil, desired, applied))
+	})
+
+	t.Run("ownerID", func(t *testing.T) {
+		user := &kcpv1alpha1.User{ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			Annotations: map[string]string{"kcp.io/cluster": "root:org:team"},
+		}}
+		assert.Equal(t, "root:org:team/default/jane", ownerID(user))
+	})
+
+	t.Run("diffGroups", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			current  []string
+			desired  []string
+			toAdd    []string
+			toRemove []string
+		}{
+			{
+				name:    "no current groups",
+				current: nil,
+				desired: []string{"b", "a"},
+				toAdd:   []string{"a", "b"},
+			},
+			{
+				name:     "no desired groups",
+				current:  []string{"a"},
+				desired:  nil,
+				toRemove: []string{"a"},
+			},
+			{
+				name:     "partial overlap",
+				current:  []string{"a", "b"},
+				desired:  []string{"b", "c"},
+				toAdd:    []string{"c"},
+				toRemove: []string{"a"},
+			},
+			{
+				name:    "in sync",
+				current: []string{"a"},
+				desired: []string{"a"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				toAdd, toRemove := diffGroups(tt.current, tt.desired)
+				assert.Equal(t, tt.toAdd, toAdd)
+				assert.Equal(t, tt.toRemove, toRemove)
+			})
+		}
+	})
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+)
+
+// UsernameStrategy decides the usernames of the identities created for Users. It only applies to
+// user pools that keep the username they are given; pools signing users in with their email or
+// phone number generate usernames of their own.
+type UsernameStrategy string
+
+// Supported username strategies
+const (
+	// UsernameStrategyEmail uses the email of the User
+	UsernameStrategyEmail UsernameStrategy = "email"
+	// UsernameStrategyName uses the name of the User resource, which is only unique within its namespace
+	UsernameStrategyName UsernameStrategy = "name"
+	// UsernameStrategyUUID uses the UID of the User resource, a UUID that is never reused
+	UsernameStrategyUUID UsernameStrategy = "uuid"
+	// UsernameStrategyTemplate renders a Go template over UsernameFields
+	UsernameStrategyTemplate UsernameStrategy = "template"
+)
+
+// maxUsernameLength is the longest username Cognito accepts
+const maxUsernameLength = 128
+
+// UsernameFields are the fields of a User available to username templates
+type UsernameFields struct {
+	// Workspace is the logical cluster name of the User's workspace
+	Workspace string
+	// Namespace and Name identify the User resource within its workspace
+	Namespace string
+	Name      string
+	// Email is the User's email
+	Email string
+	// UID is the UID of the User resource
+	UID string
+}
+
+// Usernames renders the usernames of identities following a UsernameStrategy.
+// A nil Usernames uses UsernameStrategyEmail.
+type Usernames struct {
+	strategy UsernameStrategy
+	template *template.Template
+}
+
+// NewUsernames returns Usernames following a strategy. The template is required by, and only used
+// with, UsernameStrategyTemplate; it is rendered against sample fields to catch mistakes early.
+func NewUsernames(strategy UsernameStrategy, tmpl string) (*Usernames, error) {
+	u := &Usernames{strategy: strategy}
+	switch strategy {
+	case UsernameStrategyEmail, UsernameStrategyName, UsernameStrategyUUID:
+		return u, nil
+	case UsernameStrategyTemplate:
+	default:
+		return nil, fmt.Errorf("unknown username strategy %q", strategy)
+	}
+
+	if tmpl == "" {
+		return nil, fmt.Errorf("username strategy %s requires a template", strategy)
+	}
+	parsed, err := template.New("username").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse username template: %w", err)
+	}
+	u.template = parsed
+	if _, err := u.render(UsernameFields{
+		Workspace: "root",
+		Namespace: "default",
+		Name:      "jane",
+		Email:     "jane@example.com",
+		UID:       "6f1c2a3e-0d4b-4f8a-9c1e-2b3d4e5f6a7b",
+	}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Username returns the username of the identity created for a User
+func (u *Usernames) Username(user *kcpv1alpha1.User) (string, error) {
+	strategy := UsernameStrategyEmail
+	if u != nil {
+		strategy = u.strategy
+	}
+
+	switch strategy {
+	case UsernameStrategyName:
+		return user.Name, nil
+	case UsernameStrategyUUID:
+		if user.UID == "" {
+			return "", fmt.Errorf("User %s has no UID to use as username", user.Name)
+		}
+		return string(user.UID), nil
+	case UsernameStrategyTemplate:
+		return u.render(UsernameFields{
+			Workspace: logicalcluster.From(user).String(),
+			Namespace: user.Namespace,
+			Name:      user.Name,
+			Email:     user.Spec.Email,
+			UID:       string(user.UID),
+		})
+	default:
+		return user.Spec.Email, nil
+	}
+}
+
+// render renders the username template and checks the result is a valid username
+func (u *Usernames) render(fields UsernameFields) (string, error) {
+	var b strings.Builder
+	if err := u.template.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("failed to render username template: %w", err)
+	}
+	username := b.String()
+	if username == "" {
+		return "", fmt.Errorf("username template rendered an empty username")
+	}
+	if utf8.RuneCountInString(username) > maxUsernameLength {
+		return "", fmt.Errorf("username template rendered %q, longer than %d characters", username, maxUsernameLength)
+	}
+	if strings.ContainsFunc(username, unicode.IsSpace) {
+		return "", fmt.Errorf("username template rendered %q, which contains whitespace", username)
+	}
+	return username, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+)
+
+func TestUsernames(t *testing.T) {
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "jane",
+			Namespace:   "default",
+			UID:         "6f1c2a3e-0d4b-4f8a-9c1e-2b3d4e5f6a7b",
+			Annotations: map[string]string{"kcp.io/cluster": "2x4kd7ma"},
+		},
+		Spec: kcpv1alpha1.UserSpec{Email: "jane@example.com"},
+	}
+
+	tests := []struct {
+		name     string
+		strategy UsernameStrategy
+		template string
+		expected string
+	}{
+		{name: "email", strategy: UsernameStrategyEmail, expected: "jane@example.com"},
+		{name: "name", strategy: UsernameStrategyName, expected: "jane"},
+		{name: "uuid", strategy: UsernameStrategyUUID, expected: "6f1c2a3e-0d4b-4f8a-9c1e-2b3d4e5f6a7b"},
+		{
+			name:     "template",
+			strategy: UsernameStrategyTemplate,
+			template: "{{ .Workspace }}.{{ .Namespace }}.{{ .Name }}",
+			expected: "2x4kd7ma.default.jane",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usernames, err := NewUsernames(tt.strategy, tt.template)
+			require.NoError(t, err)
+
+			username, err := usernames.Username(user)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, username)
+		})
+	}
+
+	t.Run("nil uses the email", func(t *testing.T) {
+		var usernames *Usernames
+		username, err := usernames.Username(user)
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", username)
+	})
+
+	t.Run("uuid requires a UID", func(t *testing.T) {
+		usernames, err := NewUsernames(UsernameStrategyUUID, "")
+		require.NoError(t, err)
+		_, err = usernames.Username(&kcpv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid strategies and templates", func(t *testing.T) {
+		for _, tt := range []struct {
+			strategy UsernameStrategy
+			template string
+			message  string
+		}{
+			{strategy: "random", message: "unknown username strategy"},
+			{strategy: UsernameStrategyTemplate, message: "requires a template"},
+			{strategy: UsernameStrategyTemplate, template: "{{ .Name", message: "failed to parse"},
+			{strategy: UsernameStrategyTemplate, template: "{{ .Team }}", message: "failed to render"},
+			{strategy: UsernameStrategyTemplate, template: "{{ .Name }} {{ .Namespace }}", message: "whitespace"},
+			{strategy: UsernameStrategyTemplate, template: strings.Repeat("x", 129), message: "longer than"},
+		} {
+			_, err := NewUsernames(tt.strategy, tt.template)
+			require.Error(t, err, tt.template)
+			assert.Contains(t, err.Error(), tt.message)
+		}
+	})
+
+	t.Run("rendered usernames are checked", func(t *testing.T) {
+		usernames, err := NewUsernames(UsernameStrategyTemplate, "{{ .Email }}")
+		require.NoError(t, err)
+		_, err = usernames.Username(&kcpv1alpha1.User{ObjectMeta: metav1.ObjectMeta{Name: "jane"}})
+		assert.ErrorContains(t, err, "empty username")
+	})
+}
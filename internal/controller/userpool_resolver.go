@@ -0,0 +1,208 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"path"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// UserPoolResolver resolves the user pool client of a workspace from its UserPoolBinding.
+// Clients are cached per workspace and rebuilt when the binding or its credentials Secret change.
+type UserPoolResolver struct {
+	// NewClient builds a client for a binding. Defaults to cognito.NewClientFromConfig.
+	NewClient func(ctx context.Context, cfg cognito.Config) (userpool.Client, error)
+	// Defaults holds the region, endpoint and credentials of the controller, which bound user pools
+	// inherit unless their binding overrides them
+	Defaults cognito.Config
+	// DefaultCredentialsUserPools lists the user pools, by ID or name, that bindings without a
+	// credentials Secret may manage with the controller's credentials. Bindings of other user pools
+	// must bring their own credentials.
+	DefaultCredentialsUserPools []string
+	// AssumableRoles lists the ARNs of the roles bindings may assume, as path.Match patterns such as
+	// arn:aws:iam::*:role/kcp-users-controller. Bindings assuming other roles are refused.
+	AssumableRoles []string
+
+	mu      sync.Mutex
+	clients map[string]resolvedClient
+}
+
+// errDefaultCredentialsNotAllowed is returned for bindings that would manage a user pool with the
+// controller's credentials without being allowed to
+var errDefaultCredentialsNotAllowed = stderrors.New("user pool may not be managed with the controller's credentials")
+
+// errRoleNotAllowed is returned for bindings assuming a role the operator does not allow
+var errRoleNotAllowed = stderrors.New("role is not allowed by the controller")
+
+// resolvedClient is a cached client together with the binding state it was built from
+type resolvedClient struct {
+	version string
+	client  userpool.Client
+}
+
+// ClientFor returns the user pool client bound to the workspace, reading the binding through
+// reader. It returns nil when the workspace has no UserPoolBinding, in which case the
+// controller-wide user pool applies.
+func (r *UserPoolResolver) ClientFor(ctx context.Context, clusterName string,
+	reader client.Reader) (userpool.Client, error) {
+	var binding kcpv1alpha1.UserPoolBinding
+	if err := reader.Get(ctx, client.ObjectKey{Name: kcpv1alpha1.UserPoolBindingName}, &binding); err != nil {
+		if errors.IsNotFound(err) {
+			r.forget(clusterName)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get UserPoolBinding: %w", err)
+	}
+
+	cfg := r.Defaults
+	cfg.UserPoolID = binding.Spec.UserPoolID
+	cfg.UserPoolName = binding.Spec.UserPoolName
+	if binding.Spec.Region != "" {
+		cfg.Region = binding.Spec.Region
+	}
+	version := fmt.Sprintf("%s/%d", binding.UID, binding.Generation)
+
+	if role := binding.Spec.AssumeRole; role != nil && !r.allowsRole(role.RoleARN) {
+		r.forget(clusterName)
+		return nil, fmt.Errorf("cannot assume role %s: %w", role.RoleARN, errRoleNotAllowed)
+	}
+	// Allowed roles are assumed with an external ID of the workspace, which their trust policy
+	// checks, so the controller's credentials cannot reach the roles of other workspaces
+	if binding.Spec.CredentialsSecretRef == nil && binding.Spec.AssumeRole == nil &&
+		!r.allowsDefaultCredentials(&binding) {
+		r.forget(clusterName)
+		return nil, fmt.Errorf("%w, credentialsSecretRef is required", errDefaultCredentialsNotAllowed)
+	}
+
+	if ref := binding.Spec.CredentialsSecretRef; ref != nil {
+		var secret corev1.Secret
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get credentials secret %s/%s: %w", ref.Namespace, ref.Name, err)
+		}
+		// Credentials of the binding replace the controller's, including its profile and role
+		cfg.Profile = ""
+		cfg.RoleARN = ""
+		cfg.ExternalID = ""
+		cfg.RoleSessionName = ""
+		cfg.RoleDuration = 0
+		cfg.AccessKeyID = string(secret.Data[kcpv1alpha1.AccessKeyIDSecretKey])
+		cfg.SecretAccessKey = string(secret.Data[kcpv1alpha1.SecretAccessKeySecretKey])
+		cfg.SessionToken = string(secret.Data[kcpv1alpha1.SessionTokenSecretKey])
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, fmt.Errorf("credentials secret %s/%s must contain %s and %s", ref.Namespace, ref.Name,
+				kcpv1alpha1.AccessKeyIDSecretKey, kcpv1alpha1.SecretAccessKeySecretKey)
+		}
+		version += "/" + secret.ResourceVersion
+	}
+
+	if role := binding.Spec.AssumeRole; role != nil {
+		cfg.RoleARN = role.RoleARN
+		cfg.ExternalID = clusterName
+		cfg.RoleSessionName = role.SessionName
+		cfg.RoleDuration = 0
+		if role.Duration != nil {
+			cfg.RoleDuration = role.Duration.Duration
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.clients[clusterName]; ok && cached.version == version {
+		return cached.client, nil
+	}
+
+	newClient := r.NewClient
+	if newClient == nil {
+		newClient = cognito.NewClientFromConfig
+	}
+	poolClient, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user pool client: %w", err)
+	}
+
+	if r.clients == nil {
+		r.clients = make(map[string]resolvedClient)
+	}
+	r.clients[clusterName] = resolvedClient{version: version, client: poolClient}
+	return poolClient, nil
+}
+
+// allowsDefaultCredentials reports whether the bound user pool may be managed with the controller's
+// credentials
+func (r *UserPoolResolver) allowsDefaultCredentials(binding *kcpv1alpha1.UserPoolBinding) bool {
+	for _, pool := range r.DefaultCredentialsUserPools {
+		if pool != "" && (pool == binding.Spec.UserPoolID || pool == binding.Spec.UserPoolName) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsRole reports whether bindings may assume the role
+func (r *UserPoolResolver) allowsRole(roleARN string) bool {
+	for _, pattern := range r.AssumableRoles {
+		if ok, err := path.Match(pattern, roleARN); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// forget drops the cached client of a workspace
+func (r *UserPoolResolver) forget(clusterName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, clusterName)
+}
+
+// resolveUserPoolClient returns the client bound to the workspace, or fallback when the
+// workspace has no UserPoolBinding or no resolver is configured
+func resolveUserPoolClient(ctx context.Context, resolver *UserPoolResolver, clusterName string,
+	reader client.Reader, fallback userpool.Client) (userpool.Client, error) {
+	if resolver == nil {
+		return fallback, nil
+	}
+	poolClient, err := resolver.ClientFor(ctx, clusterName, reader)
+	if err != nil {
+		return nil, err
+	}
+	if poolClient == nil {
+		return fallback, nil
+	}
+	return poolClient, nil
+}
+
+// userPoolScope identifies the user pool of a client for email claims. It is empty for backends
+// with a single user pool.
+func userPoolScope(poolClient userpool.Client) string {
+	if scoped, ok := poolClient.(userpool.Scoped); ok {
+		return scoped.Scope()
+	}
+	return ""
+}
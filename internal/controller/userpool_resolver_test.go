@@ -0,0 +1,494 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/internal/controller/mocks"
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito"
+	cognitofake "github.com/cogniteo/kcp-users-controller/pkg/cognito/fake"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+func newBindingScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func newBinding() *kcpv1alpha1.UserPoolBinding {
+	return &kcpv1alpha1.UserPoolBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: kcpv1alpha1.UserPoolBindingName, UID: "binding-uid", Generation: 1},
+		Spec: kcpv1alpha1.UserPoolBindingSpec{
+			UserPoolID: "eu-west-1_tenant",
+			Region:     "eu-west-1",
+			CredentialsSecretRef: &corev1.SecretReference{
+				Namespace: "kcp-system",
+				Name:      "user-pool-credentials",
+			},
+		},
+	}
+}
+
+func newCredentialsSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kcp-system", Name: "user-pool-credentials"},
+		Data: map[string][]byte{
+			kcpv1alpha1.AccessKeyIDSecretKey:     []byte("AKIDEXAMPLE"),
+			kcpv1alpha1.SecretAccessKeySecretKey: []byte("secret"),
+		},
+	}
+}
+
+// recordingFactory returns a NewClient func that hands out a fresh mock per call and records
+// the configs it was called with
+func recordingFactory(t *testing.T, configs *[]cognito.Config) func(context.Context, cognito.Config) (userpool.Client, error) {
+	return func(_ context.Context, cfg cognito.Config) (userpool.Client, error) {
+		*configs = append(*configs, cfg)
+		return mocks.NewMockUserPoolClient(t), nil
+	}
+}
+
+func TestUserPoolResolver(t *testing.T) {
+	ctx := context.Background()
+	scheme := newBindingScheme(t)
+
+	t.Run("workspace without binding", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{NewClient: recordingFactory(t, &configs)}
+		reader := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		poolClient, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		assert.Nil(t, poolClient)
+		assert.Empty(t, configs)
+	})
+
+	t.Run("binding with credentials", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{NewClient: recordingFactory(t, &configs)}
+		reader := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		poolClient, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		require.NotNil(t, poolClient)
+		require.Len(t, configs, 1)
+		assert.Equal(t, cognito.Config{
+			UserPoolID:      "eu-west-1_tenant",
+			Region:          "eu-west-1",
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+		}, configs[0])
+	})
+
+	t.Run("controller defaults", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient: recordingFactory(t, &configs),
+			Defaults: cognito.Config{
+				Region:   "us-east-1",
+				Endpoint: "http://localhost:4566",
+				Profile:  "tenants",
+				RoleARN:  "arn:aws:iam::123456789012:role/tenants",
+			},
+			DefaultCredentialsUserPools: []string{"eu-west-1_tenant"},
+		}
+		binding := newBinding()
+		binding.Spec.CredentialsSecretRef = nil
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, cognito.Config{
+			UserPoolID: "eu-west-1_tenant",
+			Region:     "eu-west-1",
+			Endpoint:   "http://localhost:4566",
+			Profile:    "tenants",
+			RoleARN:    "arn:aws:iam::123456789012:role/tenants",
+		}, configs[0])
+	})
+
+	t.Run("controller credentials are refused for other user pools", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient:                   recordingFactory(t, &configs),
+			Defaults:                    cognito.Config{Profile: "tenants"},
+			DefaultCredentialsUserPools: []string{"eu-west-1_shared", "shared-users"},
+		}
+		binding := newBinding()
+		binding.Spec.CredentialsSecretRef = nil
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.ErrorIs(t, err, errDefaultCredentialsNotAllowed)
+		assert.Equal(t, credentialsRequiredReason, failureReason(err, "UserPoolUnavailable"))
+		assert.Empty(t, configs)
+	})
+
+	t.Run("controller credentials are allowed by user pool name", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient:                   recordingFactory(t, &configs),
+			DefaultCredentialsUserPools: []string{"shared-users"},
+		}
+		binding := newBinding()
+		binding.Spec = kcpv1alpha1.UserPoolBindingSpec{UserPoolName: "shared-users"}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		assert.Len(t, configs, 1)
+	})
+
+	t.Run("binding credentials replace controller credentials", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient: recordingFactory(t, &configs),
+			Defaults: cognito.Config{
+				Endpoint: "http://localhost:4566",
+				Profile:  "tenants",
+				RoleARN:  "arn:aws:iam::123456789012:role/tenants",
+			},
+		}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, cognito.Config{
+			UserPoolID:      "eu-west-1_tenant",
+			Region:          "eu-west-1",
+			Endpoint:        "http://localhost:4566",
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+		}, configs[0])
+	})
+
+	t.Run("binding assumes role", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient: recordingFactory(t, &configs),
+			Defaults: cognito.Config{
+				Profile:         "controller",
+				RoleARN:         "arn:aws:iam::123456789012:role/controller",
+				RoleSessionName: "controller",
+			},
+			AssumableRoles: []string{"arn:aws:iam::*:role/tenant-*"},
+		}
+		binding := newBinding()
+		binding.Spec.CredentialsSecretRef = nil
+		binding.Spec.AssumeRole = &kcpv1alpha1.AssumeRoleSpec{
+			RoleARN:  "arn:aws:iam::210987654321:role/tenant-a",
+			Duration: &metav1.Duration{Duration: time.Hour},
+		}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, cognito.Config{
+			UserPoolID:   "eu-west-1_tenant",
+			Region:       "eu-west-1",
+			Profile:      "controller",
+			RoleARN:      "arn:aws:iam::210987654321:role/tenant-a",
+			ExternalID:   "root:team-a",
+			RoleDuration: time.Hour,
+		}, configs[0], "the role is assumed with the controller's credentials and the workspace as external ID")
+	})
+
+	t.Run("role not allowed", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{
+			NewClient:      recordingFactory(t, &configs),
+			AssumableRoles: []string{"arn:aws:iam::*:role/tenant-*"},
+		}
+		binding := newBinding()
+		binding.Spec.AssumeRole = &kcpv1alpha1.AssumeRoleSpec{RoleARN: "arn:aws:iam::123456789012:role/controller"}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding, newCredentialsSecret()).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.ErrorIs(t, err, errRoleNotAllowed)
+		assert.Equal(t, roleNotAllowedReason, failureReason(err, "UserPoolUnavailable"))
+		assert.Empty(t, configs, "no role may be assumed without being allowed")
+	})
+
+	t.Run("client is cached per workspace", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{NewClient: recordingFactory(t, &configs)}
+		reader := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		first, err := resolver.ClientFor(ctx, "root:team-a", reader)
+		require.NoError(t, err)
+		second, err := resolver.ClientFor(ctx, "root:team-a", reader)
+		require.NoError(t, err)
+		other, err := resolver.ClientFor(ctx, "root:team-b", reader)
+		require.NoError(t, err)
+
+		assert.Same(t, first, second)
+		assert.NotSame(t, first, other)
+		assert.Len(t, configs, 2)
+	})
+
+	t.Run("client is rebuilt when credentials rotate", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{NewClient: recordingFactory(t, &configs)}
+		reader := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		first, err := resolver.ClientFor(ctx, "root:team-a", reader)
+		require.NoError(t, err)
+
+		secret := newCredentialsSecret()
+		require.NoError(t, reader.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+		secret.Data[kcpv1alpha1.SecretAccessKeySecretKey] = []byte("rotated")
+		require.NoError(t, reader.Update(ctx, secret))
+
+		second, err := resolver.ClientFor(ctx, "root:team-a", reader)
+		require.NoError(t, err)
+
+		assert.NotSame(t, first, second)
+		require.Len(t, configs, 2)
+		assert.Equal(t, "rotated", configs[1].SecretAccessKey)
+	})
+
+	t.Run("incomplete credentials", func(t *testing.T) {
+		var configs []cognito.Config
+		resolver := &UserPoolResolver{NewClient: recordingFactory(t, &configs)}
+		secret := newCredentialsSecret()
+		delete(secret.Data, kcpv1alpha1.SecretAccessKeySecretKey)
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newBinding(), secret).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must contain AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		assert.Empty(t, configs)
+	})
+
+	t.Run("missing credentials secret", func(t *testing.T) {
+		resolver := &UserPoolResolver{}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newBinding()).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get credentials secret kcp-system/user-pool-credentials")
+	})
+
+	t.Run("client creation fails", func(t *testing.T) {
+		resolver := &UserPoolResolver{NewClient: func(context.Context, cognito.Config) (userpool.Client, error) {
+			return nil, errors.New("user pool not found")
+		}}
+		reader := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		_, err := resolver.ClientFor(ctx, "root:team-a", reader)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to create user pool client")
+	})
+}
+
+func TestResolveUserPoolClient(t *testing.T) {
+	ctx := context.Background()
+	scheme := newBindingScheme(t)
+	fallback := mocks.NewMockUserPoolClient(t)
+
+	t.Run("no resolver", func(t *testing.T) {
+		poolClient, err := resolveUserPoolClient(ctx, nil, "root:team-a", nil, fallback)
+
+		require.NoError(t, err)
+		assert.Same(t, fallback, poolClient)
+	})
+
+	t.Run("workspace without binding", func(t *testing.T) {
+		reader := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		poolClient, err := resolveUserPoolClient(ctx, &UserPoolResolver{}, "root:team-a", reader, fallback)
+
+		require.NoError(t, err)
+		assert.Same(t, fallback, poolClient)
+	})
+
+	t.Run("bound workspace", func(t *testing.T) {
+		bound := mocks.NewMockUserPoolClient(t)
+		resolver := &UserPoolResolver{NewClient: func(context.Context, cognito.Config) (userpool.Client, error) {
+			return bound, nil
+		}}
+		reader := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(newBinding(), newCredentialsSecret()).Build()
+
+		poolClient, err := resolveUserPoolClient(ctx, resolver, "root:team-a", reader, fallback)
+
+		require.NoError(t, err)
+		assert.Same(t, bound, poolClient)
+	})
+}
+
+func TestUserPoolScope(t *testing.T) {
+	ctx := context.Background()
+	scheme := newBindingScheme(t)
+	api := cognitofake.New()
+	poolID := api.CreateUserPool("tenants")
+	server := httptest.NewServer(api.Handler())
+	t.Cleanup(server.Close)
+	defaults := cognito.Config{
+		Region:          cognitofake.Region,
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	}
+	resolver := &UserPoolResolver{Defaults: defaults, DefaultCredentialsUserPools: []string{poolID, "tenants"}}
+
+	assert.Empty(t, userPoolScope(mocks.NewMockUserPoolClient(t)), "backends with a single user pool have no scope")
+
+	defaultConfig := defaults
+	defaultConfig.UserPoolID = poolID
+	defaultClient, err := cognito.NewClientFromConfig(ctx, defaultConfig)
+	require.NoError(t, err)
+	scope := userPoolScope(defaultClient)
+	assert.Equal(t, cognitofake.Region+"/"+poolID, scope)
+
+	byID := newBinding()
+	byID.Spec = kcpv1alpha1.UserPoolBindingSpec{UserPoolID: poolID}
+	byName := newBinding()
+	byName.Spec = kcpv1alpha1.UserPoolBindingSpec{UserPoolName: "tenants"}
+	for workspace, binding := range map[string]*kcpv1alpha1.UserPoolBinding{"root:team-a": byID, "root:team-b": byName} {
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(binding).Build()
+		poolClient, err := resolveUserPoolClient(ctx, resolver, workspace, reader, defaultClient)
+		require.NoError(t, err)
+		assert.NotSame(t, defaultClient, poolClient)
+		assert.Equal(t, scope, userPoolScope(poolClient),
+			"workspaces reaching the user pool by ID, by name or by default share its scope")
+	}
+}
+
+func TestUserPoolBindingReconciler_checkUserPool(t *testing.T) {
+	ctx := context.Background()
+	scheme := newBindingScheme(t)
+
+	tests := []struct {
+		name           string
+		setupMocks     func(*mocks.MockUserPoolClient)
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "user pool reachable",
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).Return([]string{"email"}, nil)
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "UserPoolAvailable",
+		},
+		{
+			name: "user pool not reachable",
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).
+					Return(nil, errors.New("AccessDeniedException"))
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "UserPoolUnavailable",
+		},
+		{
+			name: "role cannot be assumed",
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).
+					Return(nil, fmt.Errorf("failed to list schema: %w: AccessDenied", userpool.ErrCredentials))
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "CredentialsFailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			tt.setupMocks(mockUserPool)
+			reconciler := &UserPoolBindingReconciler{
+				UserPools: &UserPoolResolver{NewClient: func(context.Context, cognito.Config) (userpool.Client, error) {
+					return mockUserPool, nil
+				}},
+			}
+			binding := newBinding()
+			reader := fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(binding.DeepCopy(), newCredentialsSecret()).Build()
+
+			_, err := reconciler.checkUserPool(ctx, "root:team-a", reader, binding)
+
+			if tt.expectedStatus == metav1.ConditionTrue {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+			condition := meta.FindStatusCondition(binding.Status.Conditions, kcpv1alpha1.UserPoolBindingReadyCondition)
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.expectedStatus, condition.Status)
+			assert.Equal(t, tt.expectedReason, condition.Reason)
+		})
+	}
+}
+
+func TestUserPoolBindingReconciler_checkUserPoolWithoutCredentials(t *testing.T) {
+	reconciler := &UserPoolBindingReconciler{
+		UserPools: &UserPoolResolver{NewClient: func(context.Context, cognito.Config) (userpool.Client, error) {
+			t.Fatal("no client may be built with the controller's credentials")
+			return nil, nil
+		}},
+	}
+	binding := newBinding()
+	binding.Spec.CredentialsSecretRef = nil
+	reader := fake.NewClientBuilder().WithScheme(newBindingScheme(t)).WithObjects(binding.DeepCopy()).Build()
+
+	_, err := reconciler.checkUserPool(context.Background(), "root:team-a", reader, binding)
+
+	require.Error(t, err)
+	condition := meta.FindStatusCondition(binding.Status.Conditions, kcpv1alpha1.UserPoolBindingReadyCondition)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "CredentialsRequired", condition.Reason)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	mcbuilder "sigs.k8s.io/multicluster-runtime/pkg/builder"
+	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
+	mcreconcile "sigs.k8s.io/multicluster-runtime/pkg/reconcile"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+)
+
+// UserPoolBindingReconciler reports whether the user pool a workspace is bound to is reachable
+type UserPoolBindingReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Manager   mcmanager.Manager
+	UserPools *UserPoolResolver
+}
+
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=userpoolbindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kcp.cogniteo.io,resources=userpoolbindings/status,verbs=get;update;patch
+
+// Reconcile resolves the user pool of a UserPoolBinding and records the outcome in its Ready condition.
+func (r *UserPoolBindingReconciler) Reconcile(ctx context.Context, req mcreconcile.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx).WithValues("cluster", req.ClusterName)
+	log.Info("Reconciling UserPoolBinding")
+
+	var binding kcpv1alpha1.UserPoolBinding
+	cl, err := r.Manager.GetCluster(ctx, req.ClusterName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	clusterClient := cl.GetClient()
+	if err := clusterClient.Get(ctx, req.NamespacedName, &binding); err != nil {
+		if errors.IsNotFound(err) {
+			// Binding was deleted, the workspace falls back to the default user pool
+			r.UserPools.forget(req.ClusterName)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	result, checkErr := r.checkUserPool(ctx, req.ClusterName, clusterClient, &binding)
+	binding.Status.ObservedGeneration = binding.Generation
+	if err := clusterClient.Status().Update(ctx, &binding); err != nil {
+		log.Error(err, "Failed to update UserPoolBinding status")
+		return ctrl.Result{}, err
+	}
+	if checkErr != nil {
+		log.Error(checkErr, "User pool of binding is not reachable")
+	}
+	return result, checkErr
+}
+
+// checkUserPool resolves the bound user pool and reads its schema to verify the credentials
+func (r *UserPoolBindingReconciler) checkUserPool(ctx context.Context, clusterName string,
+	reader client.Reader, binding *kcpv1alpha1.UserPoolBinding) (ctrl.Result, error) {
+	poolClient, err := r.UserPools.ClientFor(ctx, clusterName, reader)
+	if err == nil && poolClient == nil {
+		// The binding was deleted between reads
+		err = fmt.Errorf("UserPoolBinding %s not found", kcpv1alpha1.UserPoolBindingName)
+	}
+	if err == nil {
+		_, err = poolClient.ListSchemaAttributes(ctx)
+	}
+	if err != nil {
+		setCondition(&binding.Status.Conditions, kcpv1alpha1.UserPoolBindingReadyCondition, metav1.ConditionFalse,
+			failureReason(err, "UserPoolUnavailable"), fmt.Sprintf("Failed to reach user pool: %v", err))
+		return ctrl.Result{RequeueAfter: time.Minute * 5}, err
+	}
+
+	setCondition(&binding.Status.Conditions, kcpv1alpha1.UserPoolBindingReadyCondition, metav1.ConditionTrue,
+		"UserPoolAvailable", "User pool is reachable")
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UserPoolBindingReconciler) SetupWithManager(mgr mcmanager.Manager) error {
+	return mcbuilder.ControllerManagedBy(mgr).
+		For(&kcpv1alpha1.UserPoolBinding{}).
+		Named("userpoolbinding").
+		Complete(mcreconcile.Func(r.Reconcile))
+}
@@ -0,0 +1,407 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer brings identities that already exist in a user pool under kcp management. It
+// lists the pool, maps every user to a workspace and creates a User there with status.sub set, so
+// the controller updates the existing identity instead of creating a new one.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// DefaultNamespace is the namespace imported Users are created in when Mapping.Namespace is empty
+const DefaultNamespace = "default"
+
+// ImportedAnnotation is set on imported Users to the time they were imported
+const ImportedAnnotation = "kcp.cogniteo.io/imported-at"
+
+// Outcome is the result of importing a single user pool user
+type Outcome string
+
+const (
+	// OutcomeImported means a User was created for the identity
+	OutcomeImported Outcome = "Imported"
+	// OutcomeSkipped means the identity needs no User, e.g. it is already managed or matches no workspace
+	OutcomeSkipped Outcome = "Skipped"
+	// OutcomeConflict means the identity clashes with an existing User and must be resolved by hand
+	OutcomeConflict Outcome = "Conflict"
+	// OutcomeFailed means the import failed and can be retried
+	OutcomeFailed Outcome = "Failed"
+)
+
+// Mapping selects the workspace and namespace each user pool user is imported into
+type Mapping struct {
+	// Attribute is the user pool attribute whose value selects the workspace, e.g. custom:tenant
+	Attribute string
+	// Workspaces maps values of Attribute to workspaces
+	Workspaces map[string]string
+	// DefaultWorkspace receives users that no entry of Workspaces matches. Empty skips them.
+	DefaultWorkspace string
+	// Namespace is the namespace Users are created in. Defaults to DefaultNamespace.
+	Namespace string
+}
+
+// workspaceFor returns the workspace of a user pool user, or an empty string when it has none
+func (m Mapping) workspaceFor(poolUser *userpool.User) string {
+	if m.Attribute != "" {
+		if workspace, ok := m.Workspaces[poolUser.Attributes[m.Attribute]]; ok {
+			return workspace
+		}
+	}
+	return m.DefaultWorkspace
+}
+
+// namespace returns the namespace Users are created in
+func (m Mapping) namespace() string {
+	if m.Namespace == "" {
+		return DefaultNamespace
+	}
+	return m.Namespace
+}
+
+// Entry reports what happened to one user pool user
+type Entry struct {
+	Email     string  `json:"email"`
+	Sub       string  `json:"sub"`
+	Workspace string  `json:"workspace,omitempty"`
+	Namespace string  `json:"namespace,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Outcome   Outcome `json:"outcome"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// Report lists the outcome of an import for every user pool user, ordered by email
+type Report struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Count returns the number of entries with the given outcome
+func (r *Report) Count(outcome Outcome) int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary returns the number of entries per outcome in a human readable form
+func (r *Report) Summary() string {
+	return fmt.Sprintf("%d imported, %d skipped, %d conflicts, %d failed", r.Count(OutcomeImported),
+		r.Count(OutcomeSkipped), r.Count(OutcomeConflict), r.Count(OutcomeFailed))
+}
+
+// Report formats supported by Report.Write
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Write writes the report as a table or as JSON
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "EMAIL\tWORKSPACE\tUSER\tOUTCOME\tREASON")
+		for _, entry := range r.Entries {
+			user := ""
+			if entry.Name != "" {
+				user = entry.Namespace + "/" + entry.Name
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.Email, entry.Workspace, user, entry.Outcome,
+				entry.Reason)
+		}
+		_, _ = fmt.Fprintln(tw, r.Summary())
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// Importer creates User objects for the users of a user pool
+type Importer struct {
+	// UserPool is the user pool users are imported from
+	UserPool userpool.Client
+	// GetClient returns a client for the workspace a user is mapped to
+	GetClient func(ctx context.Context, workspace string) (client.Client, error)
+	// Mapping selects the workspace and namespace of every user
+	Mapping Mapping
+	// DryRun reports what would be imported without creating any User
+	DryRun bool
+}
+
+// workspaceUsers indexes the Users of a workspace
+type workspaceUsers struct {
+	client  client.Client
+	byEmail map[string]*kcpv1alpha1.User
+	byName  map[string]*kcpv1alpha1.User
+	err     error
+}
+
+// Import imports every user of the user pool and reports the outcome per user. It only returns an
+// error when the user pool cannot be listed; failures of single users are part of the report.
+func (i *Importer) Import(ctx context.Context) (*Report, error) {
+	poolUsers, err := i.UserPool.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users of user pool: %w", err)
+	}
+	sort.Slice(poolUsers, func(a, b int) bool { return poolUsers[a].Email < poolUsers[b].Email })
+
+	report := &Report{Entries: make([]Entry, 0, len(poolUsers))}
+	workspaces := map[string]*workspaceUsers{}
+	for _, poolUser := range poolUsers {
+		report.Entries = append(report.Entries, i.importUser(ctx, poolUser, workspaces))
+	}
+	return report, nil
+}
+
+// importUser imports a single user pool user
+func (i *Importer) importUser(ctx context.Context, poolUser *userpool.User,
+	workspaces map[string]*workspaceUsers) Entry {
+	entry := Entry{Email: poolUser.Email, Sub: poolUser.Sub}
+	if owner := poolUser.Attributes[userpool.OwnerAttribute]; owner != "" {
+		entry.Outcome, entry.Reason = OutcomeSkipped, "already managed by "+owner
+		return entry
+	}
+	entry.Workspace = i.Mapping.workspaceFor(poolUser)
+	if entry.Workspace == "" {
+		entry.Outcome, entry.Reason = OutcomeSkipped, "no workspace matches the user"
+		return entry
+	}
+	entry.Namespace = i.Mapping.namespace()
+	entry.Name = userName(poolUser.Email)
+	if entry.Name == "" {
+		entry.Outcome, entry.Reason = OutcomeConflict, "no User name can be derived from the email"
+		return entry
+	}
+
+	users, ok := workspaces[entry.Workspace]
+	if !ok {
+		users = i.listUsers(ctx, entry.Workspace)
+		workspaces[entry.Workspace] = users
+	}
+	if users.err != nil {
+		entry.Outcome, entry.Reason = OutcomeFailed, users.err.Error()
+		return entry
+	}
+
+	if existing, ok := users.byEmail[strings.ToLower(poolUser.Email)]; ok {
+		if existing.Status.Sub == poolUser.Sub {
+			entry.Name, entry.Namespace = existing.Name, existing.Namespace
+			entry.Outcome, entry.Reason = OutcomeSkipped, "already imported"
+			return entry
+		}
+		entry.Outcome = OutcomeConflict
+		entry.Reason = fmt.Sprintf("email is used by User %s/%s", existing.Namespace, existing.Name)
+		return entry
+	}
+	if existing, ok := users.byName[entry.Namespace+"/"+entry.Name]; ok {
+		entry.Outcome = OutcomeConflict
+		entry.Reason = fmt.Sprintf("User %s/%s exists with email %s", existing.Namespace, existing.Name,
+			existing.Spec.Email)
+		return entry
+	}
+
+	groups, err := i.UserPool.ListGroupsForUser(ctx, poolUser.Sub)
+	if err != nil {
+		entry.Outcome, entry.Reason = OutcomeFailed, fmt.Sprintf("failed to list groups: %v", err)
+		return entry
+	}
+	user := newUser(poolUser, groups, entry.Namespace, entry.Name)
+	if i.DryRun {
+		entry.Outcome, entry.Reason = OutcomeImported, "dry run"
+		return entry
+	}
+
+	if err := users.client.Create(ctx, user); err != nil {
+		entry.Outcome, entry.Reason = OutcomeFailed, fmt.Sprintf("failed to create User: %v", err)
+		if apierrors.IsAlreadyExists(err) {
+			entry.Outcome = OutcomeConflict
+		}
+		return entry
+	}
+	// The controller updates the existing identity rather than creating one once the sub is known
+	user.Status.Sub = poolUser.Sub
+	if err := users.client.Status().Update(ctx, user); err != nil {
+		entry.Outcome = OutcomeFailed
+		entry.Reason = fmt.Sprintf("User created but failed to record its sub, it will be adopted: %v", err)
+		return entry
+	}
+	users.add(user)
+	entry.Outcome = OutcomeImported
+	return entry
+}
+
+// listUsers lists and indexes the Users of a workspace
+func (i *Importer) listUsers(ctx context.Context, workspace string) *workspaceUsers {
+	users := &workspaceUsers{byEmail: map[string]*kcpv1alpha1.User{}, byName: map[string]*kcpv1alpha1.User{}}
+	users.client, users.err = i.GetClient(ctx, workspace)
+	if users.err != nil {
+		users.err = fmt.Errorf("failed to get client for workspace %s: %w", workspace, users.err)
+		return users
+	}
+
+	var list kcpv1alpha1.UserList
+	if err := users.client.List(ctx, &list); err != nil {
+		users.err = fmt.Errorf("failed to list Users of workspace %s: %w", workspace, err)
+		return users
+	}
+	for idx := range list.Items {
+		users.add(&list.Items[idx])
+	}
+	return users
+}
+
+// add indexes a User
+func (w *workspaceUsers) add(user *kcpv1alpha1.User) {
+	w.byEmail[strings.ToLower(user.Spec.Email)] = user
+	w.byName[user.Namespace+"/"+user.Name] = user
+}
+
+// newUser returns the User that manages an existing user pool identity
+func newUser(poolUser *userpool.User, groups []string, namespace, name string) *kcpv1alpha1.User {
+	attributes := poolUser.Attributes
+	user := &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: map[string]string{ImportedAnnotation: time.Now().UTC().Format(time.RFC3339)},
+		},
+		Spec: kcpv1alpha1.UserSpec{
+			Email:             poolUser.Email,
+			Enabled:           ptr.To(poolUser.Enabled),
+			GivenName:         attributes[userpool.AttributeGivenName],
+			FamilyName:        attributes[userpool.AttributeFamilyName],
+			PhoneNumber:       attributes[userpool.AttributePhoneNumber],
+			Locale:            attributes[userpool.AttributeLocale],
+			PreferredUsername: attributes[userpool.AttributePreferredUsername],
+			// Should the controller see the User before its sub is recorded, it adopts the identity
+			AdoptionPolicy: kcpv1alpha1.AdoptionPolicyIfUnowned,
+		},
+	}
+	for attribute, value := range attributes {
+		if strings.HasPrefix(attribute, userpool.CustomAttributePrefix) && userpool.IsManagedAttribute(attribute) {
+			if user.Spec.CustomAttributes == nil {
+				user.Spec.CustomAttributes = map[string]string{}
+			}
+			user.Spec.CustomAttributes[strings.TrimPrefix(attribute, userpool.CustomAttributePrefix)] = value
+		}
+	}
+	if len(groups) > 0 {
+		user.Spec.Groups = append([]string{}, groups...)
+		sort.Strings(user.Spec.Groups)
+	}
+	return user
+}
+
+// invalidNameCharacters matches characters not allowed in User names
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// userName derives a User name from an email, e.g. jane.doe-example.com for jane.doe@example.com
+func userName(email string) string {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(email), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, ".-")
+}
+
+// NewWorkspaceClientFunc returns a func connecting to workspaces by path through the kcp server at
+// the host of cfg, e.g. https://kcp.example.com/clusters/root:acme for workspace root:acme. Any
+// /clusters/ suffix of the host is replaced.
+func NewWorkspaceClientFunc(cfg *rest.Config, scheme *runtime.Scheme) func(context.Context, string) (client.Client, error) {
+	base := strings.TrimSuffix(cfg.Host, "/")
+	if i := strings.Index(base, "/clusters/"); i >= 0 {
+		base = base[:i]
+	}
+	return func(_ context.Context, workspace string) (client.Client, error) {
+		workspaceCfg := rest.CopyConfig(cfg)
+		workspaceCfg.Host = base + "/clusters/" + workspace
+		return client.New(workspaceCfg, client.Options{Scheme: scheme})
+	}
+}
+
+// Runner imports users periodically while the manager runs, so that identities created outside kcp
+// are brought under management. Only the leader imports.
+type Runner struct {
+	Importer *Importer
+	// Interval is the time between imports
+	Interval time.Duration
+}
+
+var _ manager.Runnable = &Runner{}
+var _ manager.LeaderElectionRunnable = &Runner{}
+
+// Start imports users right away and then every Interval until ctx is done
+func (r *Runner) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("importer")
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := r.Importer.Import(ctx)
+		if err != nil {
+			log.Error(err, "Failed to import users from user pool")
+		} else {
+			log.Info("Imported users from user pool", "imported", report.Count(OutcomeImported),
+				"skipped", report.Count(OutcomeSkipped), "conflicts", report.Count(OutcomeConflict),
+				"failed", report.Count(OutcomeFailed))
+			for _, entry := range report.Entries {
+				if entry.Outcome == OutcomeConflict || entry.Outcome == OutcomeFailed {
+					log.Info("User not imported", "email", entry.Email, "sub", entry.Sub,
+						"workspace", entry.Workspace, "outcome", entry.Outcome, "reason", entry.Reason)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (r *Runner) NeedLeaderElection() bool {
+	return true
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/memory"
+)
+
+// newWorkspaces returns fake clients for the given workspaces and a GetClient func serving them
+func newWorkspaces(t *testing.T, names ...string) (map[string]client.Client,
+	func(context.Context, string) (client.Client, error)) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+
+	clients := map[string]client.Client{}
+	for _, name := range names {
+		clients[name] = fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&kcpv1alpha1.User{}).Build()
+	}
+	return clients, func(_ context.Context, workspace string) (client.Client, error) {
+		if cl, ok := clients[workspace]; ok {
+			return cl, nil
+		}
+		return nil, errors.New("workspace not found")
+	}
+}
+
+func createPoolUser(t *testing.T, pool *memory.Client, email string, attributes map[string]string) *userpool.User {
+	t.Helper()
+	created, err := pool.CreateUser(context.Background(), &userpool.User{
+		Username:   email,
+		Email:      email,
+		Enabled:    true,
+		Attributes: attributes,
+	})
+	require.NoError(t, err)
+	return created
+}
+
+// entryFor returns the report entry of an email
+func entryFor(t *testing.T, report *Report, email string) Entry {
+	t.Helper()
+	for _, entry := range report.Entries {
+		if entry.Email == email {
+			return entry
+		}
+	}
+	require.Failf(t, "missing report entry", "no entry for %s", email)
+	return Entry{}
+}
+
+func TestImporter_Import(t *testing.T) {
+	ctx := context.Background()
+	pool := memory.NewClient(memory.WithCustomAttributes("tenant", "department"))
+	require.NoError(t, pool.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+
+	jane := createPoolUser(t, pool, "Jane.Doe@example.com", map[string]string{
+		"custom:tenant":              "acme",
+		"custom:department":          "R&D",
+		userpool.AttributeGivenName:  "Jane",
+		userpool.AttributeFamilyName: "Doe",
+	})
+	require.NoError(t, pool.AddUserToGroup(ctx, jane.Sub, "developers"))
+	require.NoError(t, pool.DisableUser(ctx, createPoolUser(t, pool, "john@example.com",
+		map[string]string{"custom:tenant": "globex"}).Sub))
+	createPoolUser(t, pool, "managed@example.com", map[string]string{userpool.OwnerAttribute: "root:acme/default/managed"})
+	createPoolUser(t, pool, "stray@example.com", map[string]string{"custom:tenant": "unknown"})
+	createPoolUser(t, pool, "taken@example.com", map[string]string{"custom:tenant": "acme"})
+	createPoolUser(t, pool, "clash@example.com", map[string]string{"custom:tenant": "acme"})
+
+	clients, getClient := newWorkspaces(t, "root:acme", "root:globex")
+	require.NoError(t, clients["root:acme"].Create(ctx, &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: "taken"},
+		Spec:       kcpv1alpha1.UserSpec{Email: "taken@example.com"},
+	}))
+	require.NoError(t, clients["root:acme"].Create(ctx, &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: "clash-example.com"},
+		Spec:       kcpv1alpha1.UserSpec{Email: "other@example.com"},
+	}))
+
+	importer := &Importer{
+		UserPool:  pool,
+		GetClient: getClient,
+		Mapping: Mapping{
+			Attribute:  "custom:tenant",
+			Workspaces: map[string]string{"acme": "root:acme", "globex": "root:globex", "unknown": "root:missing"},
+		},
+	}
+	report, err := importer.Import(ctx)
+	require.NoError(t, err)
+	assert.Len(t, report.Entries, 6)
+	assert.Equal(t, "2 imported, 1 skipped, 2 conflicts, 1 failed", report.Summary())
+
+	t.Run("imported users", func(t *testing.T) {
+		entry := entryFor(t, report, "Jane.Doe@example.com")
+		assert.Equal(t, OutcomeImported, entry.Outcome)
+		assert.Equal(t, "root:acme", entry.Workspace)
+
+		var user kcpv1alpha1.User
+		require.NoError(t, clients["root:acme"].Get(ctx,
+			types.NamespacedName{Namespace: DefaultNamespace, Name: "jane.doe-example.com"}, &user))
+		assert.Equal(t, jane.Sub, user.Status.Sub, "the sub is recorded so the identity is not recreated")
+		assert.Equal(t, kcpv1alpha1.UserSpec{
+			Email:            "Jane.Doe@example.com",
+			Enabled:          ptr.To(true),
+			GivenName:        "Jane",
+			FamilyName:       "Doe",
+			CustomAttributes: map[string]string{"tenant": "acme", "department": "R&D"},
+			Groups:           []string{"developers"},
+			AdoptionPolicy:   kcpv1alpha1.AdoptionPolicyIfUnowned,
+		}, user.Spec)
+		assert.Contains(t, user.Annotations, ImportedAnnotation)
+
+		require.NoError(t, clients["root:globex"].Get(ctx,
+			types.NamespacedName{Namespace: DefaultNamespace, Name: "john-example.com"}, &user))
+		assert.Equal(t, ptr.To(false), user.Spec.Enabled)
+	})
+
+	t.Run("skipped and conflicting users", func(t *testing.T) {
+		assert.Equal(t, OutcomeSkipped, entryFor(t, report, "managed@example.com").Outcome)
+		assert.Equal(t, OutcomeFailed, entryFor(t, report, "stray@example.com").Outcome)
+
+		taken := entryFor(t, report, "taken@example.com")
+		assert.Equal(t, OutcomeConflict, taken.Outcome)
+		assert.Equal(t, "email is used by User default/taken", taken.Reason)
+
+		clash := entryFor(t, report, "clash@example.com")
+		assert.Equal(t, OutcomeConflict, clash.Outcome)
+		assert.Equal(t, "User default/clash-example.com exists with email other@example.com", clash.Reason)
+	})
+
+	t.Run("importing again is a no-op", func(t *testing.T) {
+		report, err := importer.Import(ctx)
+		require.NoError(t, err)
+
+		entry := entryFor(t, report, "Jane.Doe@example.com")
+		assert.Equal(t, OutcomeSkipped, entry.Outcome)
+		assert.Equal(t, "already imported", entry.Reason)
+		assert.Zero(t, report.Count(OutcomeImported))
+	})
+}
+
+func TestImporter_DryRun(t *testing.T) {
+	ctx := context.Background()
+	pool := memory.NewClient()
+	createPoolUser(t, pool, "jane@example.com", nil)
+	clients, getClient := newWorkspaces(t, "root:acme")
+
+	importer := &Importer{
+		UserPool:  pool,
+		GetClient: getClient,
+		Mapping:   Mapping{DefaultWorkspace: "root:acme", Namespace: "users"},
+		DryRun:    true,
+	}
+	report, err := importer.Import(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 1)
+	assert.Equal(t, Entry{
+		Email:     "jane@example.com",
+		Sub:       report.Entries[0].Sub,
+		Workspace: "root:acme",
+		Namespace: "users",
+		Name:      "jane-example.com",
+		Outcome:   OutcomeImported,
+		Reason:    "dry run",
+	}, report.Entries[0])
+
+	var users kcpv1alpha1.UserList
+	require.NoError(t, clients["root:acme"].List(ctx, &users))
+	assert.Empty(t, users.Items)
+}
+
+func TestImporter_ListFailure(t *testing.T) {
+	pool := memory.NewClient()
+	pool.InjectError("ListUsers", errors.New("throttled"))
+
+	_, err := (&Importer{UserPool: pool}).Import(context.Background())
+
+	assert.ErrorContains(t, err, "failed to list users of user pool: throttled")
+}
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{Entries: []Entry{
+		{Email: "jane@example.com", Sub: "1", Workspace: "root:acme", Namespace: "default",
+			Name: "jane-example.com", Outcome: OutcomeImported},
+		{Email: "john@example.com", Sub: "2", Outcome: OutcomeSkipped, Reason: "no workspace matches the user"},
+	}}
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.Write(&out, FormatTable))
+		assert.Equal(t, ""+
+			"EMAIL             WORKSPACE  USER                      OUTCOME   REASON\n"+
+			"jane@example.com  root:acme  default/jane-example.com  Imported  \n"+
+			"john@example.com                                       Skipped   no workspace matches the user\n"+
+			"1 imported, 1 skipped, 0 conflicts, 0 failed\n", out.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, report.Write(&out, FormatJSON))
+		var decoded Report
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, report, &decoded)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.ErrorContains(t, report.Write(&bytes.Buffer{}, "yaml"), `unsupported report format "yaml"`)
+	})
+}
+
+func TestUserName(t *testing.T) {
+	for email, name := range map[string]string{
+		"jane@example.com":         "jane-example.com",
+		"Jane.Doe+kcp@Example.com": "jane.doe-kcp-example.com",
+		"_jane_@example.com_":      "jane-example.com",
+		"@@@":                      "",
+	} {
+		assert.Equal(t, name, userName(email), email)
+	}
+}
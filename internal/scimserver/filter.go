@@ -0,0 +1,453 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// caseExactAttributes are compared case-sensitively, all other string attributes are not
+var caseExactAttributes = map[string]bool{
+	"id":           true,
+	"externalid":   true,
+	"meta.version": true,
+}
+
+// filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2) evaluated against the
+// JSON representation of a resource
+type filter interface {
+	matches(resource map[string]any) bool
+}
+
+// logicalFilter joins two filters with "and" or "or"
+type logicalFilter struct {
+	and         bool
+	left, right filter
+}
+
+func (f logicalFilter) matches(resource map[string]any) bool {
+	if f.and {
+		return f.left.matches(resource) && f.right.matches(resource)
+	}
+	return f.left.matches(resource) || f.right.matches(resource)
+}
+
+// notFilter negates a filter
+type notFilter struct {
+	filter filter
+}
+
+func (f notFilter) matches(resource map[string]any) bool {
+	return !f.filter.matches(resource)
+}
+
+// presentFilter matches resources that have a non-empty value for the attribute
+type presentFilter struct {
+	path string
+}
+
+func (f presentFilter) matches(resource map[string]any) bool {
+	for _, value := range attributeValues(resource, f.path) {
+		if value != nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// compareFilter compares the attribute with a value
+type compareFilter struct {
+	path  string
+	op    string
+	value any
+}
+
+func (f compareFilter) matches(resource map[string]any) bool {
+	values := attributeValues(resource, f.path)
+	if f.op == "ne" {
+		return !compareFilter{path: f.path, op: "eq", value: f.value}.matches(resource)
+	}
+	if f.value == nil {
+		return len(values) == 0
+	}
+
+	caseExact := caseExactAttributes[strings.ToLower(f.path)]
+	for _, value := range values {
+		if compare(value, f.op, f.value, caseExact) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePathFilter matches resources with an element of a multi-valued attribute matching the
+// nested filter, e.g. emails[type eq "work"]
+type valuePathFilter struct {
+	path   string
+	filter filter
+}
+
+func (f valuePathFilter) matches(resource map[string]any) bool {
+	for _, value := range attributeValues(resource, f.path) {
+		if element, ok := value.(map[string]any); ok && f.filter.matches(element) {
+			return true
+		}
+	}
+	return false
+}
+
+// compare applies a comparison operator to an attribute value. Complex values are compared by
+// their value sub-attribute.
+func compare(actual any, op string, expected any, caseExact bool) bool {
+	if element, ok := actual.(map[string]any); ok {
+		actual, _ = lookup(element, "value")
+	}
+
+	switch expected := expected.(type) {
+	case string:
+		value, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		if !caseExact {
+			value, expected = strings.ToLower(value), strings.ToLower(expected)
+		}
+		switch op {
+		case "eq":
+			return value == expected
+		case "co":
+			return strings.Contains(value, expected)
+		case "sw":
+			return strings.HasPrefix(value, expected)
+		case "ew":
+			return strings.HasSuffix(value, expected)
+		case "gt":
+			return value > expected
+		case "ge":
+			return value >= expected
+		case "lt":
+			return value < expected
+		case "le":
+			return value <= expected
+		}
+	case bool:
+		value, ok := actual.(bool)
+		return ok && op == "eq" && value == expected
+	case float64:
+		value, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return value == expected
+		case "gt":
+			return value > expected
+		case "ge":
+			return value >= expected
+		case "lt":
+			return value < expected
+		case "le":
+			return value <= expected
+		}
+	}
+	return false
+}
+
+// attributeValues returns the values of an attribute path such as userName, name.givenName,
+// emails.value or a schema-qualified path. Multi-valued attributes contribute all their elements.
+func attributeValues(resource map[string]any, path string) []any {
+	container, path := schemaContainer(resource, path)
+	if container == nil {
+		return nil
+	}
+
+	current := []any{container}
+	for _, name := range strings.Split(path, ".") {
+		var next []any
+		for _, value := range current {
+			object, ok := value.(map[string]any)
+			if !ok {
+				continue
+			}
+			value, ok := lookup(object, name)
+			if !ok || value == nil {
+				continue
+			}
+			if values, ok := value.([]any); ok {
+				next = append(next, values...)
+			} else {
+				next = append(next, value)
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// schemaContainer resolves a schema-qualified attribute path to the object holding the attribute
+// and the path relative to it. Core schema attributes live at the top level of the resource.
+func schemaContainer(resource map[string]any, path string) (map[string]any, string) {
+	if !strings.HasPrefix(strings.ToLower(path), "urn:") {
+		return resource, path
+	}
+	end := len(path)
+	if i := strings.Index(path, "["); i >= 0 {
+		end = i
+	}
+	i := strings.LastIndex(path[:end], ":")
+	schema, attribute := path[:i], path[i+1:]
+
+	value, ok := lookup(resource, schema)
+	if !ok {
+		if isCoreSchema(schema) {
+			return resource, attribute
+		}
+		return nil, attribute
+	}
+	extension, _ := value.(map[string]any)
+	return extension, attribute
+}
+
+// lookup returns the value of a key, matched case-insensitively as attribute names are
+func lookup(object map[string]any, name string) (any, bool) {
+	key := keyFor(object, name)
+	value, ok := object[key]
+	return value, ok
+}
+
+// keyFor returns the existing key of the object matching name case-insensitively, or name itself
+func keyFor(object map[string]any, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+// parseFilter parses a SCIM filter expression
+func parseFilter(expression string) (filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+// tokenize splits a filter expression into parentheses, brackets, quoted strings and words
+func tokenize(expression string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(expression) && expression[j] != '"'; j++ {
+				if expression[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(expression) {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, expression[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(expression) && !unicode.IsSpace(rune(expression[j])) &&
+				strings.IndexByte("()[]\"", expression[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, expression[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser over filter tokens
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) expect(token string) error {
+	next, err := p.next()
+	if err != nil {
+		return err
+	}
+	if next != token {
+		return fmt.Errorf("expected %q, got %q", token, next)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filter, error) {
+	if !strings.EqualFold(p.peek(), "not") {
+		return p.parseAttribute()
+	}
+	p.pos++
+	if p.peek() != "(" {
+		return nil, fmt.Errorf("expected \"(\" after not")
+	}
+	f, err := p.parseAttribute()
+	if err != nil {
+		return nil, err
+	}
+	return notFilter{filter: f}, nil
+}
+
+func (p *filterParser) parseAttribute() (filter, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if token == "(" {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	}
+	if strings.ContainsAny(token, ")]\"") {
+		return nil, fmt.Errorf("expected attribute path, got %q", token)
+	}
+	path := token
+
+	if p.peek() == "[" {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return valuePathFilter{path: path, filter: f}, p.expect("]")
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op = strings.ToLower(op)
+	switch op {
+	case "pr":
+		return presentFilter{path: path}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+		token, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		value, err := parseValue(token)
+		if err != nil {
+			return nil, err
+		}
+		return compareFilter{path: path, op: op, value: value}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", op)
+	}
+}
+
+// parseValue parses a comparison value: a JSON string, true, false, null or a number
+func parseValue(token string) (any, error) {
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(token, "\"") {
+		var value string
+		if err := json.Unmarshal([]byte(token), &value); err != nil {
+			return nil, fmt.Errorf("invalid string %s", token)
+		}
+		return value, nil
+	}
+	number, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", token)
+	}
+	return number, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResource() map[string]any {
+	return map[string]any{
+		"id":         "alice-example.com",
+		"externalId": "00u1",
+		"userName":   "Alice@example.com",
+		"active":     true,
+		"name":       map[string]any{"givenName": "Alice", "familyName": "Smith"},
+		"emails": []any{
+			map[string]any{"value": "alice@example.com", "type": "work", "primary": true},
+		},
+		"meta": map[string]any{"lastModified": "2025-03-01T10:00:00Z"},
+		"urn:ietf:params:scim:schemas:extension:kcp:2.0:User": map[string]any{"department": "R&D"},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		filter   string
+		expected bool
+	}{
+		{`userName eq "alice@example.com"`, true},
+		{`USERNAME Eq "ALICE@EXAMPLE.COM"`, true},
+		{`userName eq "bob@example.com"`, false},
+		{`userName ne "bob@example.com"`, true},
+		{`externalId eq "00U1"`, false},
+		{`userName sw "alice"`, true},
+		{`userName ew "@example.com"`, true},
+		{`userName co "example"`, true},
+		{`name.givenName eq "Alice" and name.familyName eq "Smith"`, true},
+		{`name.givenName eq "Bob" or active eq true`, true},
+		{`not (active eq true)`, false},
+		{`(userName eq "bob" or userName sw "alice") and active eq true`, true},
+		{`emails.value eq "alice@example.com"`, true},
+		{`emails eq "alice@example.com"`, true},
+		{`emails[type eq "work" and primary eq true]`, true},
+		{`emails[type eq "home"]`, false},
+		{`phoneNumbers pr`, false},
+		{`name pr`, true},
+		{`nickName eq null`, true},
+		{`meta.lastModified gt "2025-01-01T00:00:00Z"`, true},
+		{`meta.lastModified lt "2025-01-01T00:00:00Z"`, false},
+		{`urn:ietf:params:scim:schemas:extension:kcp:2.0:User:department eq "r&d"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice@example.com"`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department pr`, false},
+		{`userName eq "say \"hi\""`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := parseFilter(tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f.matches(testResource()))
+		})
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	for _, expression := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName foo "x"`,
+		`userName eq "unterminated`,
+		`userName eq alice`,
+		`(userName eq "x"`,
+		`emails[type eq "work"`,
+		`userName eq "x" and`,
+		`userName eq "x" )`,
+		`not userName eq "x"`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := parseFilter(expression)
+			assert.Error(t, err)
+		})
+	}
+}
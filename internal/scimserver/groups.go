@@ -0,0 +1,261 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+// listGroups returns the Groups of the workspace matching the filter
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	params, err := parseListParameters(r)
+	if err != nil {
+		return err
+	}
+	var groups kcpv1alpha1.GroupList
+	if err := cl.List(r.Context(), &groups, client.InNamespace(s.namespace())); err != nil {
+		return err
+	}
+	sort.Slice(groups.Items, func(i, j int) bool { return groups.Items[i].Name < groups.Items[j].Name })
+	users, err := s.users(r, cl)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]map[string]any, 0, len(groups.Items))
+	for i := range groups.Items {
+		resource, err := toMap(toSCIMGroup(r, &groups.Items[i], users))
+		if err != nil {
+			return err
+		}
+		resources = append(resources, resource)
+	}
+	writeList(w, r, params, resources)
+	return nil
+}
+
+// createGroup creates a Group named after the displayName and adds the members to it
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var resource scim.Group
+	if err := decode(r, &resource); err != nil {
+		return err
+	}
+	if errs := validation.IsDNS1123Subdomain(resource.DisplayName); len(errs) > 0 {
+		return badRequest("invalidValue", "displayName %q cannot be used as resource name: %s",
+			resource.DisplayName, strings.Join(errs, ", "))
+	}
+
+	group := &kcpv1alpha1.Group{}
+	group.Namespace, group.Name = s.namespace(), resource.DisplayName
+	setAnnotation(group, ExternalIDAnnotation, resource.ExternalID)
+	if err := cl.Create(r.Context(), group); err != nil {
+		return err
+	}
+	if err := s.setMembers(r, cl, group.Name, resource.Members); err != nil {
+		return err
+	}
+	return s.writeGroup(w, r, cl, http.StatusCreated, group)
+}
+
+// getGroup returns a single Group
+func (s *Server) getGroup(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	group := &kcpv1alpha1.Group{}
+	if err := cl.Get(r.Context(), s.key(r), group); err != nil {
+		return err
+	}
+	if notModified(w, r, group) {
+		return nil
+	}
+	return s.writeGroup(w, r, cl, http.StatusOK, group)
+}
+
+// replaceGroup replaces the externalId and members of a Group
+func (s *Server) replaceGroup(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var resource scim.Group
+	if err := decode(r, &resource); err != nil {
+		return err
+	}
+
+	group := &kcpv1alpha1.Group{}
+	group.Namespace, group.Name = s.namespace(), r.PathValue("id")
+	err := update(r, cl, group, func() error {
+		return applyGroup(&resource, group)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.setMembers(r, cl, group.Name, resource.Members); err != nil {
+		return err
+	}
+	return s.writeGroup(w, r, cl, http.StatusOK, group)
+}
+
+// patchGroup applies a PatchOp message to a Group, typically adding or removing members
+func (s *Server) patchGroup(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var request scim.PatchOp
+	if err := decode(r, &request); err != nil {
+		return err
+	}
+
+	var resource scim.Group
+	group := &kcpv1alpha1.Group{}
+	group.Namespace, group.Name = s.namespace(), r.PathValue("id")
+	err := update(r, cl, group, func() error {
+		users, err := s.users(r, cl)
+		if err != nil {
+			return err
+		}
+		object, err := toMap(toSCIMGroup(r, group, users))
+		if err != nil {
+			return err
+		}
+		if err := applyPatch(object, request.Operations); err != nil {
+			return err
+		}
+		resource = scim.Group{}
+		if err := fromMap(object, &resource); err != nil {
+			return err
+		}
+		return applyGroup(&resource, group)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.setMembers(r, cl, group.Name, resource.Members); err != nil {
+		return err
+	}
+	return s.writeGroup(w, r, cl, http.StatusOK, group)
+}
+
+// deleteGroup removes all members from a Group and deletes it
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	group := &kcpv1alpha1.Group{}
+	if err := cl.Get(r.Context(), s.key(r), group); err != nil {
+		return err
+	}
+	if err := checkIfMatch(r, group); err != nil {
+		return err
+	}
+	if err := s.setMembers(r, cl, group.Name, nil); err != nil {
+		return err
+	}
+	if err := cl.Delete(r.Context(), group, client.Preconditions{ResourceVersion: ptr.To(group.ResourceVersion)}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// setMembers converges the Users that list the group in spec.groups to the given members
+func (s *Server) setMembers(r *http.Request, cl client.Client, group string, members []scim.MultiValue) error {
+	users, err := s.users(r, cl)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool, len(members))
+	for _, member := range members {
+		wanted[member.Value] = true
+	}
+	for name := range wanted {
+		if !slices.ContainsFunc(users, func(user kcpv1alpha1.User) bool { return user.Name == name }) {
+			return badRequest("invalidValue", "member %s is not a User", name)
+		}
+	}
+
+	for i := range users {
+		user := &users[i]
+		if wanted[user.Name] == slices.Contains(user.Spec.Groups, group) {
+			continue
+		}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if err := cl.Get(r.Context(), client.ObjectKeyFromObject(user), user); err != nil {
+				return err
+			}
+			index := slices.Index(user.Spec.Groups, group)
+			switch {
+			case wanted[user.Name] && index < 0:
+				user.Spec.Groups = append(user.Spec.Groups, group)
+			case !wanted[user.Name] && index >= 0:
+				user.Spec.Groups = slices.Delete(user.Spec.Groups, index, index+1)
+			default:
+				return nil
+			}
+			return cl.Update(r.Context(), user)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeGroup writes the SCIM representation of a Group
+func (s *Server) writeGroup(w http.ResponseWriter, r *http.Request, cl client.Client, status int,
+	group *kcpv1alpha1.Group) error {
+	users, err := s.users(r, cl)
+	if err != nil {
+		return err
+	}
+	resource, err := toMap(toSCIMGroup(r, group, users))
+	if err != nil {
+		return err
+	}
+	writeResource(w, r, status, resource, group)
+	return nil
+}
+
+// toSCIMGroup converts a Group into a SCIM Group resource. Its members are the Users listing
+// the group in spec.groups.
+func toSCIMGroup(r *http.Request, group *kcpv1alpha1.Group, users []kcpv1alpha1.User) *scim.Group {
+	resource := &scim.Group{
+		Schemas:     []string{scim.GroupSchema},
+		ID:          group.Name,
+		ExternalID:  group.Annotations[ExternalIDAnnotation],
+		DisplayName: group.Name,
+		Meta:        meta(r, "Group", group),
+	}
+	for i := range users {
+		if slices.Contains(users[i].Spec.Groups, group.Name) {
+			resource.Members = append(resource.Members, scim.MultiValue{
+				Value:   users[i].Name,
+				Display: scimUserName(&users[i]),
+			})
+		}
+	}
+	return resource
+}
+
+// applyGroup applies the attributes of a SCIM Group resource to a Group. Groups cannot be
+// renamed as the displayName is the name of the user pool group.
+func applyGroup(resource *scim.Group, group *kcpv1alpha1.Group) error {
+	if resource.DisplayName != group.Name {
+		return badRequest("mutability", "displayName cannot be changed")
+	}
+	setAnnotation(group, ExternalIDAnnotation, resource.ExternalID)
+	return nil
+}
@@ -0,0 +1,342 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+// attributePath is a parsed PATCH path (RFC 7644 section 3.5.2), e.g. name.givenName,
+// emails[type eq "work"].value or a schema-qualified extension attribute
+type attributePath struct {
+	schema    string
+	attribute string
+	filter    filter
+	sub       string
+}
+
+// applyPatch applies the operations of a PatchOp message to the JSON representation of a resource
+func applyPatch(resource map[string]any, operations []scim.PatchOperation) error {
+	for _, operation := range operations {
+		if err := applyOperation(resource, strings.ToLower(operation.Op), operation.Path, operation.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyOperation applies a single add, replace or remove operation
+func applyOperation(resource map[string]any, op, path string, value any) error {
+	if op != "add" && op != "replace" && op != "remove" {
+		return badRequest("invalidSyntax", "unsupported operation %q", op)
+	}
+
+	if path == "" {
+		if op == "remove" {
+			return badRequest("noTarget", "remove operations require a path")
+		}
+		values, ok := value.(map[string]any)
+		if !ok {
+			return badRequest("invalidValue", "operations without a path require an object value")
+		}
+		for name, value := range values {
+			if err := applyOperation(resource, op, name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// An extension schema URN with an object value sets several extension attributes at once
+	if values, ok := value.(map[string]any); ok && strings.HasPrefix(strings.ToLower(path), "urn:") &&
+		!strings.Contains(path, "[") && !isCoreSchema(path) {
+		for name, value := range values {
+			if err := applyOperation(resource, op, path+":"+name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	target, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	container := resource
+	if target.schema != "" {
+		key := keyFor(resource, target.schema)
+		extension, ok := resource[key].(map[string]any)
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			extension = map[string]any{}
+			resource[key] = extension
+		}
+		container = extension
+	}
+	return target.apply(container, op, value)
+}
+
+// parsePath parses a PATCH path
+func parsePath(path string) (attributePath, error) {
+	var target attributePath
+	container, relative := schemaContainer(map[string]any{}, path)
+	if container == nil {
+		target.schema = path[:len(path)-len(relative)-1]
+	}
+
+	if i := strings.Index(relative, "["); i >= 0 {
+		j := strings.LastIndex(relative, "]")
+		if j < i {
+			return target, badRequest("invalidPath", "invalid path %q", path)
+		}
+		f, err := parseFilter(relative[i+1 : j])
+		if err != nil {
+			return target, badRequest("invalidPath", "invalid path %q: %v", path, err)
+		}
+		target.attribute, target.filter = relative[:i], f
+		if rest := relative[j+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return target, badRequest("invalidPath", "invalid path %q", path)
+			}
+			target.sub = rest[1:]
+		}
+	} else {
+		target.attribute, target.sub, _ = strings.Cut(relative, ".")
+	}
+
+	if target.attribute == "" {
+		return target, badRequest("invalidPath", "invalid path %q", path)
+	}
+	return target, nil
+}
+
+// apply applies the operation to the attribute in its containing object
+func (p attributePath) apply(container map[string]any, op string, value any) error {
+	key := keyFor(container, p.attribute)
+	current := container[key]
+
+	if p.filter == nil {
+		if p.sub != "" {
+			return p.applySub(container, key, op, value)
+		}
+		switch op {
+		case "remove":
+			values, ok := current.([]any)
+			if !ok || value == nil {
+				delete(container, key)
+				return nil
+			}
+			if remaining := removeValues(values, value); len(remaining) > 0 {
+				container[key] = remaining
+			} else {
+				delete(container, key)
+			}
+		case "add":
+			if values, ok := current.([]any); ok {
+				container[key] = appendValues(values, value)
+			} else if merged, ok := merge(current, value); ok {
+				container[key] = merged
+			} else {
+				container[key] = value
+			}
+		case "replace":
+			if merged, ok := merge(current, value); ok {
+				container[key] = merged
+			} else {
+				container[key] = value
+			}
+		}
+		return nil
+	}
+
+	values, _ := current.([]any)
+	var result []any
+	matched := false
+	for _, element := range values {
+		object, ok := element.(map[string]any)
+		if !ok || !p.filter.matches(object) {
+			result = append(result, element)
+			continue
+		}
+		matched = true
+		switch {
+		case op == "remove" && p.sub == "":
+			continue
+		case op == "remove":
+			delete(object, keyFor(object, p.sub))
+		case p.sub != "":
+			object[keyFor(object, p.sub)] = value
+		default:
+			if merged, ok := merge(object, value); ok {
+				element = merged
+			}
+		}
+		result = append(result, element)
+	}
+
+	// Adding or replacing a sub-attribute of an element that does not exist yet creates the element
+	// from the equality comparisons of the filter, e.g. emails[type eq "work"].value
+	if !matched && op != "remove" {
+		element := filterAttributes(p.filter)
+		if p.sub != "" {
+			element[p.sub] = value
+		} else if object, ok := value.(map[string]any); ok {
+			for name, value := range object {
+				element[name] = value
+			}
+		} else {
+			return badRequest("noTarget", "no value matches path %q", p.attribute)
+		}
+		result = append(result, element)
+	}
+
+	if len(result) > 0 {
+		container[key] = result
+	} else {
+		delete(container, key)
+	}
+	return nil
+}
+
+// applySub applies the operation to a sub-attribute of a complex attribute, e.g. name.givenName
+func (p attributePath) applySub(container map[string]any, key, op string, value any) error {
+	var objects []map[string]any
+	switch current := container[key].(type) {
+	case map[string]any:
+		objects = append(objects, current)
+	case []any:
+		for _, element := range current {
+			if object, ok := element.(map[string]any); ok {
+				objects = append(objects, object)
+			}
+		}
+	case nil:
+		if op == "remove" {
+			return nil
+		}
+		object := map[string]any{}
+		container[key] = object
+		objects = append(objects, object)
+	default:
+		return badRequest("invalidPath", "%s has no sub-attributes", p.attribute)
+	}
+
+	for _, object := range objects {
+		if op == "remove" {
+			delete(object, keyFor(object, p.sub))
+		} else {
+			object[keyFor(object, p.sub)] = value
+		}
+	}
+	return nil
+}
+
+// merge merges the sub-attributes of value into the complex attribute current
+func merge(current, value any) (map[string]any, bool) {
+	object, ok := current.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	values, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	for name, value := range values {
+		object[keyFor(object, name)] = value
+	}
+	return object, true
+}
+
+// appendValues adds elements to a multi-valued attribute, skipping elements whose value is present
+func appendValues(values []any, value any) []any {
+	added, ok := value.([]any)
+	if !ok {
+		added = []any{value}
+	}
+	for _, element := range added {
+		if !containsValue(values, elementValue(element)) {
+			values = append(values, element)
+		}
+	}
+	return values
+}
+
+// removeValues removes the elements of a multi-valued attribute whose value is listed in value,
+// as sent by providers that remove group members with {"op":"remove","path":"members","value":[...]}
+func removeValues(values []any, value any) []any {
+	removed, ok := value.([]any)
+	if !ok {
+		removed = []any{value}
+	}
+	var result []any
+	for _, element := range values {
+		if !containsValue(removed, elementValue(element)) {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
+// containsValue reports whether one of the elements has the given value
+func containsValue(elements []any, value any) bool {
+	for _, element := range elements {
+		if elementValue(element) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// elementValue returns the value sub-attribute of a complex element, or the element itself
+func elementValue(element any) any {
+	if object, ok := element.(map[string]any); ok {
+		value, _ := lookup(object, "value")
+		return value
+	}
+	return element
+}
+
+// filterAttributes returns the attributes a filter requires to be equal to a value
+func filterAttributes(f filter) map[string]any {
+	attributes := map[string]any{}
+	switch f := f.(type) {
+	case compareFilter:
+		if f.op == "eq" {
+			attributes[f.path] = f.value
+		}
+	case logicalFilter:
+		if f.and {
+			for name, value := range filterAttributes(f.left) {
+				attributes[name] = value
+			}
+			for name, value := range filterAttributes(f.right) {
+				attributes[name] = value
+			}
+		}
+	}
+	return attributes
+}
+
+// badRequest returns a 400 SCIM error with the given scimType
+func badRequest(scimType, format string, args ...any) *apiError {
+	return newAPIError(http.StatusBadRequest, scimType, format, args...)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+const testExtension = "urn:ietf:params:scim:schemas:extension:kcp:2.0:User"
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []scim.PatchOperation
+		check      func(t *testing.T, resource map[string]any)
+	}{
+		{
+			name:       "replace simple attribute",
+			operations: []scim.PatchOperation{{Op: "replace", Path: "active", Value: false}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, false, resource["active"])
+			},
+		},
+		{
+			name:       "operation names are case-insensitive",
+			operations: []scim.PatchOperation{{Op: "Replace", Path: "userName", Value: "alice@example.org"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, "alice@example.org", resource["userName"])
+			},
+		},
+		{
+			name:       "replace sub-attribute",
+			operations: []scim.PatchOperation{{Op: "replace", Path: "name.familyName", Value: "Jones"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, map[string]any{"givenName": "Alice", "familyName": "Jones"}, resource["name"])
+			},
+		},
+		{
+			name:       "remove sub-attribute",
+			operations: []scim.PatchOperation{{Op: "remove", Path: "name.givenName"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, map[string]any{"familyName": "Smith"}, resource["name"])
+			},
+		},
+		{
+			name:       "replace complex attribute merges sub-attributes",
+			operations: []scim.PatchOperation{{Op: "replace", Path: "name", Value: map[string]any{"givenName": "Alicia"}}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, map[string]any{"givenName": "Alicia", "familyName": "Smith"}, resource["name"])
+			},
+		},
+		{
+			name: "replace without path",
+			operations: []scim.PatchOperation{{Op: "replace", Value: map[string]any{
+				"active":           false,
+				"name.givenName":   "Alicia",
+				testExtension:      map[string]any{"costCenter": "42"},
+				"nickName":         "ali",
+				"unknownAttribute": "kept",
+			}}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, false, resource["active"])
+				assert.Equal(t, "ali", resource["nickName"])
+				assert.Equal(t, "Alicia", resource["name"].(map[string]any)["givenName"])
+				assert.Equal(t, map[string]any{"department": "R&D", "costCenter": "42"}, resource[testExtension])
+			},
+		},
+		{
+			name:       "replace value of filtered element",
+			operations: []scim.PatchOperation{{Op: "replace", Path: `emails[type eq "work"].value`, Value: "alice@example.org"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, []any{
+					map[string]any{"value": "alice@example.org", "type": "work", "primary": true},
+				}, resource["emails"])
+			},
+		},
+		{
+			name:       "replace value of missing filtered element creates it",
+			operations: []scim.PatchOperation{{Op: "replace", Path: `phoneNumbers[type eq "mobile"].value`, Value: "+4912345"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, []any{map[string]any{"type": "mobile", "value": "+4912345"}}, resource["phoneNumbers"])
+			},
+		},
+		{
+			name:       "add to multi-valued attribute",
+			operations: []scim.PatchOperation{{Op: "add", Path: "emails", Value: []any{map[string]any{"value": "alice@home.example"}}}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Len(t, resource["emails"], 2)
+			},
+		},
+		{
+			name:       "remove filtered element",
+			operations: []scim.PatchOperation{{Op: "remove", Path: `emails[value eq "alice@example.com"]`}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.NotContains(t, resource, "emails")
+			},
+		},
+		{
+			name: "remove members by value",
+			operations: []scim.PatchOperation{{Op: "remove", Path: "members", Value: []any{
+				map[string]any{"value": "bob"},
+			}}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, []any{map[string]any{"value": "carol"}}, resource["members"])
+			},
+		},
+		{
+			name:       "add extension attribute",
+			operations: []scim.PatchOperation{{Op: "add", Path: testExtension + ":costCenter", Value: "42"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, map[string]any{"department": "R&D", "costCenter": "42"}, resource[testExtension])
+			},
+		},
+		{
+			name:       "remove extension attribute",
+			operations: []scim.PatchOperation{{Op: "remove", Path: testExtension + ":department"}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, map[string]any{}, resource[testExtension])
+			},
+		},
+		{
+			name: "core schema prefix",
+			operations: []scim.PatchOperation{{
+				Op: "replace", Path: "urn:ietf:params:scim:schemas:core:2.0:User:locale", Value: "de-DE",
+			}},
+			check: func(t *testing.T, resource map[string]any) {
+				assert.Equal(t, "de-DE", resource["locale"])
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := testResource()
+			resource["members"] = []any{map[string]any{"value": "bob"}, map[string]any{"value": "carol"}}
+
+			require.NoError(t, applyPatch(resource, tt.operations))
+			tt.check(t, resource)
+		})
+	}
+}
+
+func TestApplyPatch_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation scim.PatchOperation
+		scimType  string
+	}{
+		{"unknown operation", scim.PatchOperation{Op: "move", Path: "active"}, "invalidSyntax"},
+		{"remove without path", scim.PatchOperation{Op: "remove"}, "noTarget"},
+		{"add without path or object", scim.PatchOperation{Op: "add", Value: "x"}, "invalidValue"},
+		{"invalid filter", scim.PatchOperation{Op: "replace", Path: `emails[type eq].value`, Value: "x"}, "invalidPath"},
+		{"unbalanced brackets", scim.PatchOperation{Op: "replace", Path: `emails]type eq "work"[`, Value: "x"}, "invalidPath"},
+		{"sub-attribute of simple attribute", scim.PatchOperation{Op: "replace", Path: "userName.value", Value: "x"}, "invalidPath"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyPatch(testResource(), []scim.PatchOperation{tt.operation})
+
+			var apiErr *apiError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.scimType, apiErr.scimType)
+		})
+	}
+}
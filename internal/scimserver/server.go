@@ -0,0 +1,507 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scimserver implements an inbound SCIM 2.0 service provider. Identity providers such as
+// Okta or Entra ID push users and groups to it, and it stores them as User and Group objects in
+// the addressed kcp workspace, where the controllers provision them into the user pool.
+package scimserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+const (
+	// DefaultNamespace is the namespace User and Group objects are stored in by default
+	DefaultNamespace = "default"
+
+	// TokenSecretKey is the key of the token Secret holding the bearer token
+	TokenSecretKey = "token"
+
+	// UserNameAnnotation records the SCIM userName of a User when it differs from its email
+	UserNameAnnotation = "kcp.cogniteo.io/scim-username"
+	// ExternalIDAnnotation records the identity provider's externalId of a User or Group
+	ExternalIDAnnotation = "kcp.cogniteo.io/scim-external-id"
+
+	// maxRequestSize limits the size of request bodies
+	maxRequestSize = 1 << 20
+	// maxResults is the largest page returned by list requests
+	maxResults = 200
+)
+
+var log = logf.Log.WithName("scim-server")
+
+// Server serves the SCIM 2.0 /Users and /Groups endpoints of every workspace under
+// /clusters/{cluster}/scim/v2 and translates them into User and Group objects
+type Server struct {
+	// Addr is the address the server listens on
+	Addr string
+	// CertDir contains tls.crt and tls.key. The server uses plain HTTP when empty.
+	CertDir string
+	// Namespace holds the User and Group objects and the token Secret in each workspace.
+	// Defaults to DefaultNamespace.
+	Namespace string
+	// TokenSecretName is the name of the Secret in each workspace holding the bearer token
+	// identity providers authenticate with. Workspaces without the Secret reject all requests.
+	TokenSecretName string
+	// ExtensionSchema is the schema extension custom attributes are exchanged in.
+	// Defaults to scim.DefaultExtensionSchema.
+	ExtensionSchema string
+	// GetClient returns a client for the workspace with the given logical cluster name
+	GetClient func(ctx context.Context, clusterName string) (client.Client, error)
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// apiError is returned by handlers to respond with a SCIM error message
+type apiError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+// Error implements the error interface
+func (e *apiError) Error() string {
+	return e.detail
+}
+
+// newAPIError returns an apiError with a formatted detail message
+func newAPIError(status int, scimType, format string, args ...any) *apiError {
+	return &apiError{status: status, scimType: scimType, detail: fmt.Sprintf(format, args...)}
+}
+
+// handlerFunc serves a request against the client of the authenticated workspace
+type handlerFunc func(w http.ResponseWriter, r *http.Request, cl client.Client) error
+
+// Start serves requests until the context is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("Starting SCIM server", "addr", s.Addr)
+		var err error
+		if s.CertDir != "" {
+			err = server.ListenAndServeTLS(filepath.Join(s.CertDir, "tls.crt"), filepath.Join(s.CertDir, "tls.key"))
+		} else {
+			err = server.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("SCIM server failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica serves requests.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the HTTP handler serving the SCIM endpoints
+func (s *Server) Handler() http.Handler {
+	const prefix = "/clusters/{cluster}/scim/v2"
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+prefix+"/ServiceProviderConfig", s.handle(s.serviceProviderConfig))
+	mux.HandleFunc("GET "+prefix+"/Users", s.handle(s.listUsers))
+	mux.HandleFunc("POST "+prefix+"/Users", s.handle(s.createUser))
+	mux.HandleFunc("GET "+prefix+"/Users/{id}", s.handle(s.getUser))
+	mux.HandleFunc("PUT "+prefix+"/Users/{id}", s.handle(s.replaceUser))
+	mux.HandleFunc("PATCH "+prefix+"/Users/{id}", s.handle(s.patchUser))
+	mux.HandleFunc("DELETE "+prefix+"/Users/{id}", s.handle(s.deleteUser))
+	mux.HandleFunc("GET "+prefix+"/Groups", s.handle(s.listGroups))
+	mux.HandleFunc("POST "+prefix+"/Groups", s.handle(s.createGroup))
+	mux.HandleFunc("GET "+prefix+"/Groups/{id}", s.handle(s.getGroup))
+	mux.HandleFunc("PUT "+prefix+"/Groups/{id}", s.handle(s.replaceGroup))
+	mux.HandleFunc("PATCH "+prefix+"/Groups/{id}", s.handle(s.patchGroup))
+	mux.HandleFunc("DELETE "+prefix+"/Groups/{id}", s.handle(s.deleteGroup))
+	return mux
+}
+
+// handle authenticates the request against the token Secret of the addressed workspace and
+// serves it with the workspace's client
+func (s *Server) handle(handler handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clusterName := r.PathValue("cluster")
+		cl, err := s.authenticate(r, clusterName)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		if err := handler(w, r, cl); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) && !apierrors.IsNotFound(err) && !apierrors.IsInvalid(err) {
+				log.Error(err, "Failed to serve SCIM request", "cluster", clusterName,
+					"method", r.Method, "path", r.URL.Path)
+			}
+			writeError(w, err)
+		}
+	}
+}
+
+// authenticate compares the bearer token of the request with the workspace's token Secret
+func (s *Server) authenticate(r *http.Request, clusterName string) (client.Client, error) {
+	unauthorized := newAPIError(http.StatusUnauthorized, "", "invalid bearer token")
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, unauthorized
+	}
+	cl, err := s.GetClient(r.Context(), clusterName)
+	if err != nil {
+		log.V(1).Info("Rejecting SCIM request for unknown workspace", "cluster", clusterName, "error", err.Error())
+		return nil, unauthorized
+	}
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Namespace: s.namespace(), Name: s.TokenSecretName}
+	if err := cl.Get(r.Context(), key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, unauthorized
+		}
+		return nil, fmt.Errorf("failed to get token secret %s: %w", key, err)
+	}
+	expected := secret.Data[TokenSecretKey]
+	if len(expected) == 0 || subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+		return nil, unauthorized
+	}
+	return cl, nil
+}
+
+// serviceProviderConfig describes the supported SCIM features
+func (s *Server) serviceProviderConfig(w http.ResponseWriter, _ *http.Request, _ client.Client) error {
+	supported := func(supported bool) map[string]any { return map[string]any{"supported": supported} }
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          supported(true),
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxResults},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(true),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "Authentication with the token stored in the workspace's SCIM token Secret",
+		}},
+	})
+	return nil
+}
+
+// namespace returns the namespace objects are stored in
+func (s *Server) namespace() string {
+	if s.Namespace == "" {
+		return DefaultNamespace
+	}
+	return s.Namespace
+}
+
+// extension returns the schema extension custom attributes are exchanged in
+func (s *Server) extension() string {
+	if s.ExtensionSchema == "" {
+		return scim.DefaultExtensionSchema
+	}
+	return s.ExtensionSchema
+}
+
+// update applies mutate to a fresh copy of the object and updates it, retrying on conflicts.
+// Requests with If-Match fail with 412 once the object changed.
+func update(r *http.Request, cl client.Client, obj client.Object, mutate func() error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := cl.Get(r.Context(), client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, obj); err != nil {
+			return err
+		}
+		if err := mutate(); err != nil {
+			return err
+		}
+		return cl.Update(r.Context(), obj)
+	})
+}
+
+// checkIfMatch fails with 412 when the If-Match header does not match the object's version
+func checkIfMatch(r *http.Request, obj client.Object) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+	current := version(obj)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == current {
+			return nil
+		}
+	}
+	return newAPIError(http.StatusPreconditionFailed, "", "resource version %s does not match %s", current, ifMatch)
+}
+
+// notModified responds with 304 when the If-None-Match header matches the object's version
+func notModified(w http.ResponseWriter, r *http.Request, obj client.Object) bool {
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch == "" || ifNoneMatch != version(obj) {
+		return false
+	}
+	w.Header().Set("ETag", version(obj))
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// version returns the weak ETag of an object, derived from its resourceVersion
+func version(obj client.Object) string {
+	return `W/"` + obj.GetResourceVersion() + `"`
+}
+
+// meta returns the meta attribute of a resource backed by obj
+func meta(r *http.Request, resourceType string, obj client.Object) *scim.Meta {
+	created := obj.GetCreationTimestamp().UTC()
+	lastModified := created
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(lastModified) {
+			lastModified = entry.Time.UTC()
+		}
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return &scim.Meta{
+		ResourceType: resourceType,
+		Created:      created,
+		LastModified: lastModified,
+		Location: fmt.Sprintf("%s://%s/clusters/%s/scim/v2/%ss/%s",
+			scheme, r.Host, r.PathValue("cluster"), resourceType, obj.GetName()),
+		Version: version(obj),
+	}
+}
+
+// setAnnotation sets an annotation, removing it when the value is empty
+func setAnnotation(obj client.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if value == "" {
+		delete(annotations, key)
+		obj.SetAnnotations(annotations)
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// isCoreSchema reports whether the URN is one of the core resource schemas
+func isCoreSchema(urn string) bool {
+	return strings.EqualFold(urn, scim.UserSchema) || strings.EqualFold(urn, scim.GroupSchema)
+}
+
+// decode reads a JSON request body
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return badRequest("invalidSyntax", "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// toMap returns the JSON representation of a resource
+func toMap(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	return object, json.Unmarshal(data, &object)
+}
+
+// fromMap decodes the JSON representation of a resource
+func fromMap(object map[string]any, resource any) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, resource); err != nil {
+		return badRequest("invalidValue", "invalid attribute value: %v", err)
+	}
+	return nil
+}
+
+// listParameters are the query parameters of list requests
+type listParameters struct {
+	filter     filter
+	startIndex int
+	count      int
+}
+
+// parseListParameters parses the filter, startIndex and count query parameters
+func parseListParameters(r *http.Request) (listParameters, error) {
+	query := r.URL.Query()
+	params := listParameters{startIndex: 1, count: maxResults}
+
+	if expression := query.Get("filter"); expression != "" {
+		f, err := parseFilter(expression)
+		if err != nil {
+			return params, badRequest("invalidFilter", "invalid filter: %v", err)
+		}
+		params.filter = f
+	}
+	if value := query.Get("startIndex"); value != "" {
+		startIndex, err := strconv.Atoi(value)
+		if err != nil {
+			return params, badRequest("invalidValue", "invalid startIndex %q", value)
+		}
+		params.startIndex = max(startIndex, 1)
+	}
+	if value := query.Get("count"); value != "" {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return params, badRequest("invalidValue", "invalid count %q", value)
+		}
+		params.count = min(max(count, 0), maxResults)
+	}
+	return params, nil
+}
+
+// writeList filters and pages resources and writes them as ListResponse
+func writeList(w http.ResponseWriter, r *http.Request, params listParameters, resources []map[string]any) {
+	var matches []map[string]any
+	for _, resource := range resources {
+		if params.filter == nil || params.filter.matches(resource) {
+			matches = append(matches, project(r, resource))
+		}
+	}
+
+	first := min(params.startIndex-1, len(matches))
+	end := min(first+params.count, len(matches))
+	writeJSON(w, http.StatusOK, scim.ListResponse[map[string]any]{
+		Schemas:      []string{scim.ListResponseSchema},
+		TotalResults: len(matches),
+		StartIndex:   params.startIndex,
+		ItemsPerPage: end - first,
+		Resources:    append([]map[string]any{}, matches[first:end]...),
+	})
+}
+
+// writeResource writes a single resource with its ETag
+func writeResource(w http.ResponseWriter, r *http.Request, status int, resource map[string]any,
+	obj client.Object) {
+	w.Header().Set("ETag", version(obj))
+	if status == http.StatusCreated {
+		if m, ok := resource["meta"].(map[string]any); ok {
+			w.Header().Set("Location", fmt.Sprint(m["location"]))
+		}
+	}
+	writeJSON(w, status, project(r, resource))
+}
+
+// project applies the attributes and excludedAttributes query parameters to a resource.
+// id, schemas and meta are always returned.
+func project(r *http.Request, resource map[string]any) map[string]any {
+	query := r.URL.Query()
+	if attributes := query.Get("attributes"); attributes != "" {
+		projected := map[string]any{}
+		for _, name := range append(strings.Split(attributes, ","), "id", "schemas", "meta") {
+			name, _, _ = strings.Cut(strings.TrimSpace(name), ".")
+			key := keyFor(resource, name)
+			if value, ok := resource[key]; ok {
+				projected[key] = value
+			}
+		}
+		return projected
+	}
+	for _, name := range strings.Split(query.Get("excludedAttributes"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.EqualFold(name, "id") || strings.EqualFold(name, "schemas") {
+			continue
+		}
+		delete(resource, keyFor(resource, name))
+	}
+	return resource
+}
+
+// writeJSON writes a SCIM response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", scim.ContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error(err, "Failed to write SCIM response")
+	}
+}
+
+// writeError writes a SCIM error message for err
+func writeError(w http.ResponseWriter, err error) {
+	status, scimType, detail := http.StatusInternalServerError, "", "internal error"
+	var apiErr *apiError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &apiErr):
+		status, scimType, detail = apiErr.status, apiErr.scimType, apiErr.detail
+	case errors.As(err, &maxBytesErr):
+		status, detail = http.StatusRequestEntityTooLarge, "request body too large"
+	case apierrors.IsNotFound(err):
+		status, detail = http.StatusNotFound, "resource not found"
+	case apierrors.IsAlreadyExists(err):
+		status, scimType, detail = http.StatusConflict, "uniqueness", "resource already exists"
+	case apierrors.IsConflict(err):
+		status, detail = http.StatusPreconditionFailed, "resource was modified concurrently"
+	case apierrors.IsInvalid(err):
+		status, scimType, detail = http.StatusBadRequest, "invalidValue", err.Error()
+	}
+	writeJSON(w, status, scim.ErrorResponse{
+		Schemas:  []string{scim.ErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
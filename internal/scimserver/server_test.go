@@ -0,0 +1,515 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+const (
+	testCluster = "root:team-a"
+	testToken   = "s3cr3t"
+	baseURL     = "/clusters/root:team-a/scim/v2"
+)
+
+// testServer serves the SCIM endpoints of a single fake workspace
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+	client  client.Client
+}
+
+func newTestServer(t *testing.T, objects ...client.Object) *testServer {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: "scim-token"},
+		Data:       map[string][]byte{TokenSecretKey: []byte(testToken)},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, tokenSecret)...).Build()
+	server := &Server{
+		TokenSecretName: "scim-token",
+		GetClient: func(_ context.Context, clusterName string) (client.Client, error) {
+			if clusterName != testCluster {
+				return nil, errors.New("cluster not found")
+			}
+			return cl, nil
+		},
+	}
+	return &testServer{t: t, handler: server.Handler(), client: cl}
+}
+
+// do sends a request with the test token and decodes the JSON response into out
+func (s *testServer) do(method, path string, body any, out any, headers ...string) *httptest.ResponseRecorder {
+	var reader *strings.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(s.t, err)
+		reader = strings.NewReader(string(data))
+	} else {
+		reader = strings.NewReader("")
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", scim.ContentType)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	if out != nil && rec.Body.Len() > 0 {
+		require.NoError(s.t, json.Unmarshal(rec.Body.Bytes(), out))
+	}
+	return rec
+}
+
+func (s *testServer) user(name string) *kcpv1alpha1.User {
+	user := &kcpv1alpha1.User{}
+	require.NoError(s.t, s.client.Get(context.Background(), types.NamespacedName{Namespace: DefaultNamespace, Name: name}, user))
+	return user
+}
+
+func newUser(name, email string, groups ...string) *kcpv1alpha1.User {
+	return &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: name},
+		Spec:       kcpv1alpha1.UserSpec{Email: email, Groups: groups},
+	}
+}
+
+func newGroup(name string) *kcpv1alpha1.Group {
+	return &kcpv1alpha1.Group{ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: name}}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+	}{
+		{"missing token", baseURL + "/Users", ""},
+		{"wrong token", baseURL + "/Users", "Bearer wrong"},
+		{"basic auth", baseURL + "/Users", "Basic " + testToken},
+		{"unknown workspace", "/clusters/root:team-b/scim/v2/Users", "Bearer " + testToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			server.handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			var errResp scim.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+			assert.Equal(t, []string{scim.ErrorSchema}, errResp.Schemas)
+			assert.Equal(t, "401", errResp.Status)
+		})
+	}
+
+	t.Run("workspace without token secret", func(t *testing.T) {
+		require.NoError(t, server.client.Delete(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: DefaultNamespace, Name: "scim-token"},
+		}))
+		rec := server.do(http.MethodGet, baseURL+"/Users", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestServer_CreateUser(t *testing.T) {
+	server := newTestServer(t)
+
+	var created scim.User
+	rec := server.do(http.MethodPost, baseURL+"/Users", map[string]any{
+		"schemas":    []string{scim.UserSchema, testExtension},
+		"userName":   "Alice@Example.com",
+		"externalId": "00u1",
+		"name":       map[string]any{"givenName": "Alice", "familyName": "Smith"},
+		"emails":     []any{map[string]any{"value": "alice@example.com", "primary": true}},
+		"phoneNumbers": []any{
+			map[string]any{"value": "+4912345", "type": "mobile"},
+		},
+		"locale":      "en-US",
+		"nickName":    "ali",
+		"active":      true,
+		testExtension: map[string]any{"department": "R&D"},
+	}, &created)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, scim.ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "alice-example.com", created.ID)
+	assert.Equal(t, "Alice@Example.com", created.UserName)
+	assert.Equal(t, "00u1", created.ExternalID)
+	assert.Equal(t, "Alice Smith", created.DisplayName)
+	assert.Equal(t, map[string]string{testExtension + ":department": "R&D"}, created.Extension)
+	require.NotNil(t, created.Meta)
+	assert.Equal(t, "User", created.Meta.ResourceType)
+	assert.Equal(t, "http://example.com"+baseURL+"/Users/alice-example.com", created.Meta.Location)
+	assert.Equal(t, created.Meta.Location, rec.Header().Get("Location"))
+	assert.Equal(t, created.Meta.Version, rec.Header().Get("ETag"))
+
+	user := server.user("alice-example.com")
+	assert.Equal(t, kcpv1alpha1.UserSpec{
+		Email:             "alice@example.com",
+		Enabled:           ptrTo(true),
+		GivenName:         "Alice",
+		FamilyName:        "Smith",
+		PhoneNumber:       "+4912345",
+		Locale:            "en-US",
+		PreferredUsername: "ali",
+		CustomAttributes:  map[string]string{"department": "R&D"},
+	}, user.Spec)
+	assert.Equal(t, "00u1", user.Annotations[ExternalIDAnnotation])
+	assert.Equal(t, "Alice@Example.com", user.Annotations[UserNameAnnotation])
+	assert.Equal(t, `W/"`+user.ResourceVersion+`"`, created.Meta.Version)
+
+	t.Run("duplicate userName", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPost, baseURL+"/Users", map[string]any{
+			"userName": "alice@example.com",
+		}, &errResp)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "uniqueness", errResp.SCIMType)
+	})
+
+	t.Run("userName that is not an email", func(t *testing.T) {
+		var resource scim.User
+		rec := server.do(http.MethodPost, baseURL+"/Users", map[string]any{
+			"userName": "bob",
+			"emails":   []any{map[string]any{"value": "bob@example.com"}},
+		}, &resource)
+
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		assert.Equal(t, "bob", resource.UserName)
+		assert.Equal(t, "bob", server.user("bob").Annotations[UserNameAnnotation])
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPost, baseURL+"/Users", map[string]any{"userName": "carol"}, &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidValue", errResp.SCIMType)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPost, baseURL+"/Users", "not an object", &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidSyntax", errResp.SCIMType)
+	})
+}
+
+func TestServer_ListUsers(t *testing.T) {
+	server := newTestServer(t,
+		newUser("alice", "alice@example.com"),
+		newUser("bob", "bob@example.com"),
+		newUser("carol", "carol@example.org"),
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		total    int
+		expected []string
+	}{
+		{"all", "", 3, []string{"alice", "bob", "carol"}},
+		{"filter by userName", `?filter=userName+eq+"BOB@example.com"`, 1, []string{"bob"}},
+		{"filter by email domain", `?filter=emails.value+ew+"example.com"`, 2, []string{"alice", "bob"}},
+		{"pagination", "?startIndex=2&count=1", 3, []string{"bob"}},
+		{"no matches", `?filter=userName+eq+"dave@example.com"`, 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list scim.ListResponse[scim.User]
+			rec := server.do(http.MethodGet, baseURL+"/Users"+tt.query, nil, &list)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, []string{scim.ListResponseSchema}, list.Schemas)
+			assert.Equal(t, tt.total, list.TotalResults)
+			ids := []string{}
+			for _, resource := range list.Resources {
+				ids = append(ids, resource.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+
+	t.Run("invalid filter", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodGet, baseURL+`/Users?filter=userName+eq`, nil, &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidFilter", errResp.SCIMType)
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		var list scim.ListResponse[map[string]any]
+		server.do(http.MethodGet, baseURL+"/Users?attributes=userName&count=1", nil, &list)
+
+		require.Len(t, list.Resources, 1)
+		assert.ElementsMatch(t, []string{"id", "schemas", "meta", "userName"}, keys(list.Resources[0]))
+	})
+}
+
+func TestServer_GetUser(t *testing.T) {
+	server := newTestServer(t, newUser("alice", "alice@example.com"))
+
+	var resource scim.User
+	rec := server.do(http.MethodGet, baseURL+"/Users/alice", nil, &resource)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "alice@example.com", resource.UserName)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rec = server.do(http.MethodGet, baseURL+"/Users/alice", nil, nil, "If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = server.do(http.MethodGet, baseURL+"/Users/missing", nil, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_PatchUser(t *testing.T) {
+	alice := newUser("alice", "alice@example.com", "admins")
+	alice.Spec.GivenName = "Alice"
+	alice.Spec.CustomAttributes = map[string]string{"department": "R&D"}
+	server := newTestServer(t, alice)
+
+	var resource scim.User
+	rec := server.do(http.MethodPatch, baseURL+"/Users/alice", scim.PatchOp{
+		Schemas: []string{scim.PatchOpSchema},
+		Operations: []scim.PatchOperation{
+			{Op: "Replace", Path: "active", Value: "False"},
+			{Op: "Replace", Path: `emails[type eq "work"].value`, Value: "alice@example.org"},
+			{Op: "Add", Path: "name.familyName", Value: "Smith"},
+			{Op: "Remove", Path: testExtension + ":department"},
+			{Op: "Add", Value: map[string]any{testExtension + ":costCenter": "42"}},
+		},
+	}, &resource)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "alice@example.com", resource.UserName, "userName is kept when the email changes")
+	user := server.user("alice")
+	assert.Equal(t, "alice@example.org", user.Spec.Email)
+	assert.Equal(t, "alice@example.com", user.Annotations[UserNameAnnotation])
+	assert.False(t, user.Spec.IsEnabled())
+	assert.Equal(t, "Smith", user.Spec.FamilyName)
+	assert.Equal(t, map[string]string{"costCenter": "42"}, user.Spec.CustomAttributes)
+	assert.Equal(t, []string{"admins"}, user.Spec.Groups, "groups are read-only on users")
+	assert.Equal(t, `W/"`+user.ResourceVersion+`"`, rec.Header().Get("ETag"))
+
+	t.Run("stale If-Match", func(t *testing.T) {
+		rec := server.do(http.MethodPatch, baseURL+"/Users/alice", scim.PatchOp{
+			Operations: []scim.PatchOperation{{Op: "replace", Path: "locale", Value: "de-DE"}},
+		}, nil, "If-Match", `W/"1"`)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Empty(t, server.user("alice").Spec.Locale)
+	})
+
+	t.Run("current If-Match", func(t *testing.T) {
+		rec := server.do(http.MethodPatch, baseURL+"/Users/alice", scim.PatchOp{
+			Operations: []scim.PatchOperation{{Op: "replace", Path: "locale", Value: "de-DE"}},
+		}, nil, "If-Match", version(server.user("alice")))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "de-DE", server.user("alice").Spec.Locale)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPatch, baseURL+"/Users/alice", scim.PatchOp{
+			Operations: []scim.PatchOperation{{Op: "replace", Path: `emails[type eq].value`, Value: "x"}},
+		}, &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidPath", errResp.SCIMType)
+	})
+}
+
+func TestServer_ReplaceUser(t *testing.T) {
+	alice := newUser("alice", "alice@example.com", "admins")
+	alice.Spec.Locale = "en-US"
+	server := newTestServer(t, alice, newUser("bob", "bob@example.com"))
+
+	rec := server.do(http.MethodPut, baseURL+"/Users/alice", map[string]any{
+		"userName": "alice@example.com",
+		"name":     map[string]any{"givenName": "Alice"},
+		"active":   false,
+	}, nil)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	user := server.user("alice")
+	assert.Equal(t, "Alice", user.Spec.GivenName)
+	assert.Empty(t, user.Spec.Locale)
+	assert.False(t, user.Spec.IsEnabled())
+	assert.Equal(t, []string{"admins"}, user.Spec.Groups)
+
+	t.Run("userName of another user", func(t *testing.T) {
+		rec := server.do(http.MethodPut, baseURL+"/Users/alice", map[string]any{"userName": "bob@example.com"}, nil)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+}
+
+func TestServer_DeleteUser(t *testing.T) {
+	server := newTestServer(t, newUser("alice", "alice@example.com"))
+
+	rec := server.do(http.MethodDelete, baseURL+"/Users/alice", nil, nil, "If-Match", `W/"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = server.do(http.MethodDelete, baseURL+"/Users/alice", nil, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	err := server.client.Get(context.Background(), types.NamespacedName{Namespace: DefaultNamespace, Name: "alice"},
+		&kcpv1alpha1.User{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestServer_Groups(t *testing.T) {
+	server := newTestServer(t,
+		newUser("alice", "alice@example.com"),
+		newUser("bob", "bob@example.com", "developers"),
+		newUser("carol", "carol@example.com"),
+	)
+
+	var group scim.Group
+	rec := server.do(http.MethodPost, baseURL+"/Groups", map[string]any{
+		"displayName": "developers",
+		"externalId":  "g-1",
+		"members":     []any{map[string]any{"value": "alice"}},
+	}, &group)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "developers", group.ID)
+	assert.Equal(t, "g-1", group.ExternalID)
+	assert.Equal(t, []scim.MultiValue{{Value: "alice", Display: "alice@example.com"}}, group.Members)
+	assert.Equal(t, []string{"developers"}, server.user("alice").Spec.Groups)
+	assert.Empty(t, server.user("bob").Spec.Groups, "members not listed are removed")
+
+	t.Run("list by displayName", func(t *testing.T) {
+		var list scim.ListResponse[map[string]any]
+		rec := server.do(http.MethodGet, baseURL+`/Groups?filter=displayName+eq+"developers"&excludedAttributes=members`,
+			nil, &list)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.Len(t, list.Resources, 1)
+		assert.NotContains(t, list.Resources[0], "members")
+	})
+
+	t.Run("patch members", func(t *testing.T) {
+		rec := server.do(http.MethodPatch, baseURL+"/Groups/developers", scim.PatchOp{
+			Operations: []scim.PatchOperation{
+				{Op: "add", Path: "members", Value: []any{map[string]any{"value": "bob"}, map[string]any{"value": "carol"}}},
+				{Op: "remove", Path: `members[value eq "alice"]`},
+			},
+		}, &group)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Len(t, group.Members, 2)
+		assert.Empty(t, server.user("alice").Spec.Groups)
+		assert.Equal(t, []string{"developers"}, server.user("bob").Spec.Groups)
+		assert.Equal(t, []string{"developers"}, server.user("carol").Spec.Groups)
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPatch, baseURL+"/Groups/developers", scim.PatchOp{
+			Operations: []scim.PatchOperation{{Op: "add", Path: "members", Value: []any{map[string]any{"value": "dave"}}}},
+		}, &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalidValue", errResp.SCIMType)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		var errResp scim.ErrorResponse
+		rec := server.do(http.MethodPatch, baseURL+"/Groups/developers", scim.PatchOp{
+			Operations: []scim.PatchOperation{{Op: "replace", Path: "displayName", Value: "engineers"}},
+		}, &errResp)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "mutability", errResp.SCIMType)
+	})
+
+	t.Run("invalid displayName", func(t *testing.T) {
+		rec := server.do(http.MethodPost, baseURL+"/Groups", map[string]any{"displayName": "Team Leads"}, nil)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("delete removes memberships", func(t *testing.T) {
+		rec := server.do(http.MethodDelete, baseURL+"/Groups/developers", nil, nil)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, server.user("bob").Spec.Groups)
+		assert.Empty(t, server.user("carol").Spec.Groups)
+		rec = server.do(http.MethodGet, baseURL+"/Groups/developers", nil, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestServer_GetGroup(t *testing.T) {
+	server := newTestServer(t, newGroup("admins"), newUser("alice", "alice@example.com", "admins"))
+
+	var group scim.Group
+	rec := server.do(http.MethodGet, baseURL+"/Groups/admins", nil, &group)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "admins", group.DisplayName)
+	assert.Equal(t, []scim.MultiValue{{Value: "alice", Display: "alice@example.com"}}, group.Members)
+	assert.Equal(t, "Group", group.Meta.ResourceType)
+}
+
+func keys(object map[string]any) []string {
+	var result []string
+	for key := range object {
+		result = append(result, key)
+	}
+	return result
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scimserver
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/pkg/scim"
+)
+
+// invalidNameCharacters matches runs of characters that are not allowed in object names
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// listUsers returns the Users of the workspace matching the filter
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	params, err := parseListParameters(r)
+	if err != nil {
+		return err
+	}
+	users, err := s.users(r, cl)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]map[string]any, 0, len(users))
+	for i := range users {
+		resource, err := toMap(s.toSCIMUser(r, &users[i]))
+		if err != nil {
+			return err
+		}
+		resources = append(resources, resource)
+	}
+	writeList(w, r, params, resources)
+	return nil
+}
+
+// createUser creates a User named after the userName
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var resource scim.User
+	if err := decode(r, &resource); err != nil {
+		return err
+	}
+	name, err := objectName(resource.UserName)
+	if err != nil {
+		return err
+	}
+	if err := s.checkUniqueUserName(r, cl, resource.UserName, ""); err != nil {
+		return err
+	}
+
+	user := &kcpv1alpha1.User{}
+	user.Namespace, user.Name = s.namespace(), name
+	if err := s.fromSCIMUser(&resource, user); err != nil {
+		return err
+	}
+	if err := cl.Create(r.Context(), user); err != nil {
+		return err
+	}
+	return s.writeUser(w, r, http.StatusCreated, user)
+}
+
+// getUser returns a single User
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	user := &kcpv1alpha1.User{}
+	if err := cl.Get(r.Context(), s.key(r), user); err != nil {
+		return err
+	}
+	if notModified(w, r, user) {
+		return nil
+	}
+	return s.writeUser(w, r, http.StatusOK, user)
+}
+
+// replaceUser replaces all SCIM attributes of a User
+func (s *Server) replaceUser(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var resource scim.User
+	if err := decode(r, &resource); err != nil {
+		return err
+	}
+
+	user := &kcpv1alpha1.User{}
+	user.Namespace, user.Name = s.namespace(), r.PathValue("id")
+	err := update(r, cl, user, func() error {
+		if err := s.checkUniqueUserName(r, cl, resource.UserName, user.Name); err != nil {
+			return err
+		}
+		return s.fromSCIMUser(&resource, user)
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeUser(w, r, http.StatusOK, user)
+}
+
+// patchUser applies a PatchOp message to a User
+func (s *Server) patchUser(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	var request scim.PatchOp
+	if err := decode(r, &request); err != nil {
+		return err
+	}
+
+	user := &kcpv1alpha1.User{}
+	user.Namespace, user.Name = s.namespace(), r.PathValue("id")
+	err := update(r, cl, user, func() error {
+		object, err := toMap(s.toSCIMUser(r, user))
+		if err != nil {
+			return err
+		}
+		if err := applyPatch(object, request.Operations); err != nil {
+			return err
+		}
+		// Some providers send booleans as strings, e.g. {"op":"Replace","path":"active","value":"False"}
+		key := keyFor(object, "active")
+		if active, ok := object[key].(string); ok {
+			if object[key], err = strconv.ParseBool(active); err != nil {
+				return badRequest("invalidValue", "invalid active value %q", active)
+			}
+		}
+
+		var resource scim.User
+		if err := fromMap(object, &resource); err != nil {
+			return err
+		}
+		if err := s.checkUniqueUserName(r, cl, resource.UserName, user.Name); err != nil {
+			return err
+		}
+		return s.fromSCIMUser(&resource, user)
+	})
+	if err != nil {
+		return err
+	}
+	return s.writeUser(w, r, http.StatusOK, user)
+}
+
+// deleteUser deletes a User. Its deletion policy decides what happens to the user pool identity.
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request, cl client.Client) error {
+	user := &kcpv1alpha1.User{}
+	if err := cl.Get(r.Context(), s.key(r), user); err != nil {
+		return err
+	}
+	if err := checkIfMatch(r, user); err != nil {
+		return err
+	}
+	if err := cl.Delete(r.Context(), user, client.Preconditions{ResourceVersion: ptr.To(user.ResourceVersion)}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// users lists the Users of the workspace ordered by name
+func (s *Server) users(r *http.Request, cl client.Client) ([]kcpv1alpha1.User, error) {
+	var users kcpv1alpha1.UserList
+	if err := cl.List(r.Context(), &users, client.InNamespace(s.namespace())); err != nil {
+		return nil, err
+	}
+	sort.Slice(users.Items, func(i, j int) bool { return users.Items[i].Name < users.Items[j].Name })
+	return users.Items, nil
+}
+
+// checkUniqueUserName fails with 409 when another User has the userName
+func (s *Server) checkUniqueUserName(r *http.Request, cl client.Client, userName, self string) error {
+	users, err := s.users(r, cl)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.Name != self && strings.EqualFold(scimUserName(&user), userName) {
+			return newAPIError(http.StatusConflict, "uniqueness", "userName %s is already taken", userName)
+		}
+	}
+	return nil
+}
+
+// key returns the object key of the resource addressed by the request
+func (s *Server) key(r *http.Request) types.NamespacedName {
+	return types.NamespacedName{Namespace: s.namespace(), Name: r.PathValue("id")}
+}
+
+// writeUser writes the SCIM representation of a User
+func (s *Server) writeUser(w http.ResponseWriter, r *http.Request, status int, user *kcpv1alpha1.User) error {
+	resource, err := toMap(s.toSCIMUser(r, user))
+	if err != nil {
+		return err
+	}
+	writeResource(w, r, status, resource, user)
+	return nil
+}
+
+// toSCIMUser converts a User into a SCIM User resource
+func (s *Server) toSCIMUser(r *http.Request, user *kcpv1alpha1.User) *scim.User {
+	resource := &scim.User{
+		Schemas:    []string{scim.UserSchema},
+		ID:         user.Name,
+		ExternalID: user.Annotations[ExternalIDAnnotation],
+		UserName:   scimUserName(user),
+		NickName:   user.Spec.PreferredUsername,
+		Locale:     user.Spec.Locale,
+		Active:     ptr.To(user.Spec.IsEnabled()),
+		Meta:       meta(r, "User", user),
+	}
+	if user.Spec.GivenName != "" || user.Spec.FamilyName != "" {
+		resource.Name = &scim.Name{GivenName: user.Spec.GivenName, FamilyName: user.Spec.FamilyName}
+		resource.DisplayName = strings.TrimSpace(user.Spec.GivenName + " " + user.Spec.FamilyName)
+	}
+	if user.Spec.Email != "" {
+		resource.Emails = []scim.MultiValue{{Value: user.Spec.Email, Type: "work", Primary: true}}
+	}
+	if user.Spec.PhoneNumber != "" {
+		resource.PhoneNumbers = []scim.MultiValue{{Value: user.Spec.PhoneNumber, Type: "work", Primary: true}}
+	}
+	for _, group := range user.Spec.Groups {
+		resource.Groups = append(resource.Groups, scim.MultiValue{Value: group, Display: group})
+	}
+	if len(user.Spec.CustomAttributes) > 0 {
+		resource.Schemas = append(resource.Schemas, s.extension())
+		resource.Extension = make(map[string]string, len(user.Spec.CustomAttributes))
+		for name, value := range user.Spec.CustomAttributes {
+			resource.Extension[s.extension()+":"+name] = value
+		}
+	}
+	return resource
+}
+
+// fromSCIMUser applies the attributes of a SCIM User resource to a User. The read-only groups
+// attribute is ignored; memberships are managed through /Groups.
+func (s *Server) fromSCIMUser(resource *scim.User, user *kcpv1alpha1.User) error {
+	if resource.UserName == "" {
+		return badRequest("invalidValue", "userName is required")
+	}
+	email := primaryValue(resource.Emails)
+	if email == "" && strings.Contains(resource.UserName, "@") {
+		email = resource.UserName
+	}
+	if email == "" {
+		return badRequest("invalidValue", "an email address is required")
+	}
+
+	userName := resource.UserName
+	if userName == email {
+		userName = ""
+	}
+	setAnnotation(user, UserNameAnnotation, userName)
+	setAnnotation(user, ExternalIDAnnotation, resource.ExternalID)
+
+	user.Spec.Email = email
+	user.Spec.Enabled = resource.Active
+	user.Spec.GivenName, user.Spec.FamilyName = "", ""
+	if resource.Name != nil {
+		user.Spec.GivenName, user.Spec.FamilyName = resource.Name.GivenName, resource.Name.FamilyName
+	}
+	user.Spec.PhoneNumber = primaryValue(resource.PhoneNumbers)
+	user.Spec.Locale = resource.Locale
+	user.Spec.PreferredUsername = resource.NickName
+
+	user.Spec.CustomAttributes = nil
+	prefix := s.extension() + ":"
+	for key, value := range resource.Extension {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if user.Spec.CustomAttributes == nil {
+			user.Spec.CustomAttributes = map[string]string{}
+		}
+		user.Spec.CustomAttributes[name] = value
+	}
+	return nil
+}
+
+// scimUserName returns the SCIM userName of a User, which is its email unless recorded otherwise
+func scimUserName(user *kcpv1alpha1.User) string {
+	if userName := user.Annotations[UserNameAnnotation]; userName != "" {
+		return userName
+	}
+	return user.Spec.Email
+}
+
+// objectName derives the name of a new User from its userName, e.g. alice@example.com becomes
+// alice-example.com
+func objectName(userName string) (string, error) {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(userName), "-")
+	name = strings.Trim(name, ".-")
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength], ".-")
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", badRequest("invalidValue", "userName %q cannot be used as resource name: %s",
+			userName, strings.Join(errs, ", "))
+	}
+	return name, nil
+}
+
+// primaryValue returns the primary value of a multi-valued attribute, or its first value
+func primaryValue(values []scim.MultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
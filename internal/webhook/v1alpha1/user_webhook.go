@@ -0,0 +1,324 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mcmanager "sigs.k8s.io/multicluster-runtime/pkg/manager"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/internal/controller"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// schemaCacheTTL is how long the user pool schema is cached between admission requests
+const schemaCacheTTL = 5 * time.Minute
+
+// userlog is for logging in this package.
+var userlog = logf.Log.WithName("user-resource")
+
+// SetupUserWebhookWithManager registers the webhook for User in the manager.
+func SetupUserWebhookWithManager(mgr mcmanager.Manager, userPoolClient userpool.Client,
+	userPools *controller.UserPoolResolver) error {
+	return ctrl.NewWebhookManagedBy(mgr.GetLocalManager()).For(&kcpv1alpha1.User{}).
+		WithValidator(&UserCustomValidator{
+			Manager:        mgr,
+			UserPoolClient: userPoolClient,
+			UserPools:      userPools,
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-kcp-cogniteo-io-v1alpha1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=kcp.cogniteo.io,resources=users,verbs=create;update,versions=v1alpha1,name=vuser-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// UserCustomValidator validates User resources when they are created or updated.
+type UserCustomValidator struct {
+	// Manager resolves the workspace of a User to look up the other Users in it.
+	// The duplicate email check is skipped when it is nil.
+	Manager mcmanager.Manager
+
+	// UserPoolClient reads the user pool schema. The attribute check is skipped when it is nil.
+	UserPoolClient userpool.Client
+
+	// UserPools resolves the user pool of workspaces that have a UserPoolBinding.
+	// UserPoolClient is used for all other workspaces.
+	UserPools *controller.UserPoolResolver
+
+	schemaMu sync.Mutex
+	schemas  map[string]cachedSchema
+}
+
+// cachedSchema holds the schema attribute names read from a workspace's user pool
+type cachedSchema struct {
+	client  userpool.Client
+	names   map[string]bool
+	fetched time.Time
+}
+
+var _ webhook.CustomValidator = &UserCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type User.
+func (v *UserCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	user, ok := obj.(*kcpv1alpha1.User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User object but got %T", obj)
+	}
+	userlog.Info("Validation for User upon creation", "name", user.GetName())
+
+	return nil, v.validateUser(ctx, user, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type User.
+func (v *UserCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	user, ok := newObj.(*kcpv1alpha1.User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User object for the newObj but got %T", newObj)
+	}
+	oldUser, ok := oldObj.(*kcpv1alpha1.User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User object for the oldObj but got %T", oldObj)
+	}
+	userlog.Info("Validation for User upon update", "name", user.GetName())
+
+	// Users being deleted only lose their finalizer, which must never be blocked
+	if user.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return nil, v.validateUser(ctx, user, oldUser)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type User.
+func (v *UserCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateUser runs all checks and aggregates their errors into a single Invalid error. On update,
+// only fields that changed are checked, so that existing Users, e.g. admitted before a check was
+// tightened, can still be updated by the controller.
+func (v *UserCustomValidator) validateUser(ctx context.Context, user, oldUser *kcpv1alpha1.User) error {
+	var allErrs field.ErrorList
+	if oldUser == nil || user.Spec.Email != oldUser.Spec.Email {
+		allErrs = append(allErrs, validateEmail(user)...)
+	}
+	if oldUser != nil {
+		allErrs = append(allErrs, validateImmutableFields(user, oldUser)...)
+	}
+
+	attrErrs, err := v.validateAttributes(ctx, user, oldUser)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	allErrs = append(allErrs, attrErrs...)
+
+	// Only check for duplicates when the email itself is valid and has changed
+	if len(allErrs) == 0 && (oldUser == nil || !strings.EqualFold(oldUser.Spec.Email, user.Spec.Email)) {
+		reader, err := v.workspaceReader(ctx, user)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		if reader != nil {
+			dupErrs, err := validateUniqueEmail(ctx, reader, user)
+			if err != nil {
+				return apierrors.NewInternalError(err)
+			}
+			allErrs = append(allErrs, dupErrs...)
+		}
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(kcpv1alpha1.GroupVersion.WithKind("User").GroupKind(), user.Name, allErrs)
+}
+
+// validateEmail checks that the email is a bare address such as jane@example.com
+func validateEmail(user *kcpv1alpha1.User) field.ErrorList {
+	emailPath := field.NewPath("spec", "email")
+	if user.Spec.Email == "" {
+		return field.ErrorList{field.Required(emailPath, "email is required")}
+	}
+
+	addr, err := mail.ParseAddress(user.Spec.Email)
+	if err != nil {
+		return field.ErrorList{field.Invalid(emailPath, user.Spec.Email, err.Error())}
+	}
+	if addr.Address != user.Spec.Email {
+		return field.ErrorList{field.Invalid(emailPath, user.Spec.Email, "must be a bare email address without a display name")}
+	}
+	return nil
+}
+
+// validateImmutableFields rejects changes to the user's identity once it exists in the user pool
+func validateImmutableFields(user, oldUser *kcpv1alpha1.User) field.ErrorList {
+	if oldUser.Status.Sub == "" || user.Spec.Email == oldUser.Spec.Email {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(field.NewPath("spec", "email"),
+		"email cannot be changed once the user has been created in the user pool")}
+}
+
+// validateAttributes checks custom attribute names against the user pool schema. On update, only
+// attributes added since oldUser are checked.
+func (v *UserCustomValidator) validateAttributes(ctx context.Context,
+	user, oldUser *kcpv1alpha1.User) (field.ErrorList, error) {
+	names := make([]string, 0, len(user.Spec.CustomAttributes))
+	for name := range user.Spec.CustomAttributes {
+		if oldUser != nil {
+			if _, ok := oldUser.Spec.CustomAttributes[name]; ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	var allErrs field.ErrorList
+	attrsPath := field.NewPath("spec", "customAttributes")
+	for _, name := range names {
+		switch {
+		case strings.HasPrefix(name, userpool.CustomAttributePrefix):
+			allErrs = append(allErrs, field.Invalid(attrsPath.Key(name), name,
+				fmt.Sprintf("must be given without the %q prefix", userpool.CustomAttributePrefix)))
+		case strings.HasPrefix(userpool.CustomAttributePrefix+name, userpool.ReservedAttributePrefix):
+			allErrs = append(allErrs, field.Forbidden(attrsPath.Key(name),
+				"attributes starting with kcp_ are reserved for the controller"))
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs, nil
+	}
+
+	clusterName := logicalcluster.From(user).String()
+	poolClient, err := v.userPoolClient(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if poolClient == nil {
+		return nil, nil
+	}
+
+	schema, err := v.schemaAttributes(ctx, clusterName, poolClient)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !schema[userpool.CustomAttributePrefix+name] {
+			allErrs = append(allErrs, field.NotFound(attrsPath.Key(name), name))
+		}
+	}
+	return allErrs, nil
+}
+
+// userPoolClient returns the client of the user pool the workspace is bound to
+func (v *UserCustomValidator) userPoolClient(ctx context.Context, clusterName string) (userpool.Client, error) {
+	if v.UserPools == nil || v.Manager == nil || clusterName == "" {
+		return v.UserPoolClient, nil
+	}
+
+	cl, err := v.Manager.GetCluster(ctx, clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", clusterName, err)
+	}
+	poolClient, err := v.UserPools.ClientFor(ctx, clusterName, cl.GetClient())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve user pool: %w", err)
+	}
+	if poolClient == nil {
+		return v.UserPoolClient, nil
+	}
+	return poolClient, nil
+}
+
+// schemaAttributes returns the attribute names of the workspace's user pool schema, refreshing
+// them after schemaCacheTTL or when the workspace switches to another user pool
+func (v *UserCustomValidator) schemaAttributes(ctx context.Context, clusterName string,
+	poolClient userpool.Client) (map[string]bool, error) {
+	v.schemaMu.Lock()
+	defer v.schemaMu.Unlock()
+
+	cached, ok := v.schemas[clusterName]
+	if ok && cached.client == poolClient && time.Since(cached.fetched) < schemaCacheTTL {
+		return cached.names, nil
+	}
+
+	names, err := poolClient.ListSchemaAttributes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user pool schema: %w", err)
+	}
+	schema := make(map[string]bool, len(names))
+	for _, name := range names {
+		schema[name] = true
+	}
+	if v.schemas == nil {
+		v.schemas = make(map[string]cachedSchema)
+	}
+	v.schemas[clusterName] = cachedSchema{client: poolClient, names: schema, fetched: time.Now()}
+	return schema, nil
+}
+
+// workspaceReader returns a reader for the workspace the User belongs to, or nil when the
+// workspace cannot be determined
+func (v *UserCustomValidator) workspaceReader(ctx context.Context, user *kcpv1alpha1.User) (client.Reader, error) {
+	clusterName := logicalcluster.From(user)
+	if v.Manager == nil || clusterName.Empty() {
+		return nil, nil
+	}
+
+	cl, err := v.Manager.GetCluster(ctx, clusterName.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", clusterName, err)
+	}
+	return cl.GetClient(), nil
+}
+
+// validateUniqueEmail rejects the User when another User in the workspace has the same email
+func validateUniqueEmail(ctx context.Context, reader client.Reader, user *kcpv1alpha1.User) (field.ErrorList, error) {
+	var users kcpv1alpha1.UserList
+	if err := reader.List(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, other := range users.Items {
+		if other.Namespace == user.Namespace && other.Name == user.Name {
+			continue
+		}
+		if strings.EqualFold(other.Spec.Email, user.Spec.Email) {
+			return field.ErrorList{field.Duplicate(field.NewPath("spec", "email"), user.Spec.Email)}, nil
+		}
+	}
+	return nil, nil
+}
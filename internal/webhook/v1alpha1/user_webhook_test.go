@@ -0,0 +1,305 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kcpv1alpha1 "github.com/cogniteo/kcp-users-controller/api/v1alpha1"
+	"github.com/cogniteo/kcp-users-controller/internal/controller/mocks"
+)
+
+func newUser(name, email string) *kcpv1alpha1.User {
+	return &kcpv1alpha1.User{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       kcpv1alpha1.UserSpec{Email: email},
+	}
+}
+
+func TestUserCustomValidator_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *kcpv1alpha1.User
+		setupMocks func(*mocks.MockUserPoolClient)
+		expectErr  string
+	}{
+		{
+			name: "valid user",
+			user: newUser("jane", "jane@example.com"),
+		},
+		{
+			name:      "missing email",
+			user:      newUser("jane", ""),
+			expectErr: "email is required",
+		},
+		{
+			name:      "malformed email",
+			user:      newUser("jane", "jane.example.com"),
+			expectErr: "spec.email: Invalid value",
+		},
+		{
+			name:      "email with display name",
+			user:      newUser("jane", "Jane <jane@example.com>"),
+			expectErr: "must be a bare email address",
+		},
+		{
+			name: "custom attribute with prefix",
+			user: func() *kcpv1alpha1.User {
+				user := newUser("jane", "jane@example.com")
+				user.Spec.CustomAttributes = map[string]string{"custom:department": "engineering"}
+				return user
+			}(),
+			expectErr: `must be given without the "custom:" prefix`,
+		},
+		{
+			name: "reserved custom attribute",
+			user: func() *kcpv1alpha1.User {
+				user := newUser("jane", "jane@example.com")
+				user.Spec.CustomAttributes = map[string]string{"kcp_owner": "root/default/john"}
+				return user
+			}(),
+			expectErr: "reserved for the controller",
+		},
+		{
+			name: "custom attributes declared in schema",
+			user: func() *kcpv1alpha1.User {
+				user := newUser("jane", "jane@example.com")
+				user.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+				return user
+			}(),
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).
+					Return([]string{"email", "custom:department"}, nil)
+			},
+		},
+		{
+			name: "custom attribute missing from schema",
+			user: func() *kcpv1alpha1.User {
+				user := newUser("jane", "jane@example.com")
+				user.Spec.CustomAttributes = map[string]string{"department": "engineering", "team": "platform"}
+				return user
+			}(),
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).
+					Return([]string{"email", "custom:department"}, nil)
+			},
+			expectErr: "spec.customAttributes[team]: Not found",
+		},
+		{
+			name: "schema cannot be read",
+			user: func() *kcpv1alpha1.User {
+				user := newUser("jane", "jane@example.com")
+				user.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+				return user
+			}(),
+			setupMocks: func(mockUserPool *mocks.MockUserPoolClient) {
+				mockUserPool.On("ListSchemaAttributes", mock.Anything).
+					Return(nil, errors.New("user pool service unavailable"))
+			},
+			expectErr: "failed to read user pool schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserPool := mocks.NewMockUserPoolClient(t)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockUserPool)
+			}
+
+			validator := &UserCustomValidator{UserPoolClient: mockUserPool}
+			_, err := validator.ValidateCreate(context.Background(), tt.user)
+
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserCustomValidator_ValidateUpdate(t *testing.T) {
+	t.Run("email change rejected once created", func(t *testing.T) {
+		oldUser := newUser("jane", "jane@example.com")
+		oldUser.Status.Sub = "test-sub-123"
+		user := oldUser.DeepCopy()
+		user.Spec.Email = "jane.doe@example.com"
+
+		validator := &UserCustomValidator{}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "email cannot be changed")
+	})
+
+	t.Run("email change allowed before creation", func(t *testing.T) {
+		oldUser := newUser("jane", "jane@example.com")
+		user := oldUser.DeepCopy()
+		user.Spec.Email = "jane.doe@example.com"
+
+		validator := &UserCustomValidator{}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("other fields can change once created", func(t *testing.T) {
+		oldUser := newUser("jane", "jane@example.com")
+		oldUser.Status.Sub = "test-sub-123"
+		user := oldUser.DeepCopy()
+		user.Spec.GivenName = "Jane"
+
+		validator := &UserCustomValidator{}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("users being deleted are not validated", func(t *testing.T) {
+		oldUser := newUser("jane", "Jane <jane@example.com>")
+		oldUser.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+		oldUser.Finalizers = []string{"kcp.cogniteo.io/finalizer"}
+		oldUser.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		user := oldUser.DeepCopy()
+		user.Finalizers = nil
+
+		// The user pool is not consulted, so an unreachable one cannot block the finalizer
+		validator := &UserCustomValidator{UserPoolClient: mocks.NewMockUserPoolClient(t)}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("unchanged fields are not revalidated", func(t *testing.T) {
+		oldUser := newUser("jane", "Jane <jane@example.com>")
+		oldUser.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+		user := oldUser.DeepCopy()
+		user.Finalizers = []string{"kcp.cogniteo.io/finalizer"}
+		user.Spec.CustomAttributes["department"] = "research"
+
+		validator := &UserCustomValidator{UserPoolClient: mocks.NewMockUserPoolClient(t)}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("added attributes are checked against the schema", func(t *testing.T) {
+		mockUserPool := mocks.NewMockUserPoolClient(t)
+		mockUserPool.On("ListSchemaAttributes", mock.Anything).Return([]string{"custom:department"}, nil)
+		oldUser := newUser("jane", "jane@example.com")
+		oldUser.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+		user := oldUser.DeepCopy()
+		user.Spec.CustomAttributes["team"] = "platform"
+
+		validator := &UserCustomValidator{UserPoolClient: mockUserPool}
+		_, err := validator.ValidateUpdate(context.Background(), oldUser, user)
+
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "spec.customAttributes[team]")
+	})
+}
+
+func TestUserCustomValidator_SchemaCache(t *testing.T) {
+	mockUserPool := mocks.NewMockUserPoolClient(t)
+	mockUserPool.On("ListSchemaAttributes", mock.Anything).
+		Return([]string{"custom:department"}, nil).Once()
+
+	validator := &UserCustomValidator{UserPoolClient: mockUserPool}
+	user := newUser("jane", "jane@example.com")
+	user.Spec.CustomAttributes = map[string]string{"department": "engineering"}
+
+	for range 3 {
+		_, err := validator.ValidateCreate(context.Background(), user)
+		require.NoError(t, err)
+	}
+}
+
+func TestUserCustomValidator_SchemaCachePerUserPool(t *testing.T) {
+	defaultPool := mocks.NewMockUserPoolClient(t)
+	defaultPool.On("ListSchemaAttributes", mock.Anything).
+		Return([]string{"custom:department"}, nil).Once()
+	boundPool := mocks.NewMockUserPoolClient(t)
+	boundPool.On("ListSchemaAttributes", mock.Anything).
+		Return([]string{"custom:team"}, nil).Once()
+
+	validator := &UserCustomValidator{}
+
+	schema, err := validator.schemaAttributes(context.Background(), "root:team-a", defaultPool)
+	require.NoError(t, err)
+	assert.True(t, schema["custom:department"])
+
+	// The workspace was bound to another user pool in the meantime
+	schema, err = validator.schemaAttributes(context.Background(), "root:team-a", boundPool)
+	require.NoError(t, err)
+	assert.True(t, schema["custom:team"])
+	assert.False(t, schema["custom:department"])
+
+	schema, err = validator.schemaAttributes(context.Background(), "root:team-a", boundPool)
+	require.NoError(t, err)
+	assert.True(t, schema["custom:team"])
+}
+
+func TestValidateUniqueEmail(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kcpv1alpha1.AddToScheme(scheme))
+
+	existing := newUser("jane", "jane@example.com")
+	other := newUser("john", "john@example.com")
+	other.Namespace = "team-b"
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing, other).Build()
+
+	tests := []struct {
+		name      string
+		user      *kcpv1alpha1.User
+		duplicate bool
+	}{
+		{name: "unique email", user: newUser("alice", "alice@example.com")},
+		{name: "same object", user: newUser("jane", "jane@example.com")},
+		{name: "duplicate email", user: newUser("jane-2", "jane@example.com"), duplicate: true},
+		{name: "duplicate email in other case", user: newUser("jane-2", "Jane@Example.com"), duplicate: true},
+		{name: "duplicate email in other namespace", user: newUser("john", "john@example.com"), duplicate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := validateUniqueEmail(context.Background(), reader, tt.user)
+
+			require.NoError(t, err)
+			if tt.duplicate {
+				require.Len(t, errs, 1)
+				assert.Equal(t, "spec.email", errs[0].Field)
+			} else {
+				assert.Empty(t, errs)
+			}
+		})
+	}
+}
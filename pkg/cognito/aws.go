@@ -20,68 +20,200 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
 )
 
 const (
-	emailAttribute = "email"
+	emailAttribute         = "email"
+	emailVerifiedAttribute = "email_verified"
+	subAttribute           = "sub"
+
+	// smsMFAMethod is the MFA method name Cognito uses for SMS text message MFA
+	smsMFAMethod = "SMS_MFA"
+
+	// groupOwnerPrefix starts the last line of a group description, which records the owner of the
+	// group since Cognito groups have no attributes
+	groupOwnerPrefix = "kcp_owner: "
 )
 
-// AWSClient implements the userpool.Client interface for AWS Cognito
+// AWSClient implements the userpool.Client interface for AWS Cognito.
+// Users are addressed by sub or email and resolved to their Cognito username, which depends on
+// whether the user pool signs users in with their email.
 type AWSClient struct {
 	cognito    CognitoAPI
 	userPoolID string
+	region     string
+
+	mu sync.Mutex
+	// mode tells how the user pool names its users. The zero value assumes the pool signs users in
+	// with their email.
+	mode usernameMode
+	// usernames caches the usernames of subs in pools that do not use the sub as username
+	usernames map[string]string
 }
 
+// Config selects the user pool an AWSClient talks to and how it authenticates
+type Config struct {
+	// UserPoolID is the ID of the user pool. Takes precedence over UserPoolName.
+	UserPoolID string
+	// UserPoolName is the name of the user pool, resolved to its ID
+	UserPoolName string
+	// Region overrides the region from the default AWS configuration
+	Region string
+	// Endpoint overrides the Cognito endpoint URL, e.g. to use LocalStack, cognito-local or a local fake
+	Endpoint string
+	// Profile selects a profile of the shared AWS configuration and credentials files
+	Profile string
+	// AccessKeyID, SecretAccessKey and SessionToken are static credentials used instead of the
+	// default credential chain when AccessKeyID is set
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// RoleARN is a role assumed with the credentials above before calling Cognito
+	RoleARN string
+	// ExternalID is passed when assuming RoleARN, as required by roles that third parties assume
+	ExternalID string
+	// RoleSessionName names the session of the assumed role. Defaults to DefaultRoleSessionName.
+	RoleSessionName string
+	// RoleDuration is the lifetime of assumed role credentials. Defaults to 15 minutes.
+	RoleDuration time.Duration
+}
+
+// DefaultRoleSessionName is the session name used when assuming a role without Config.RoleSessionName
+const DefaultRoleSessionName = "kcp-users-controller"
+
+// roleExpiryWindow is how long before their expiration assumed role credentials are refreshed
+const roleExpiryWindow = time.Minute
+
 // NewAWSClient creates a new AWS Cognito client with Pod Identity authentication
 func NewAWSClient(ctx context.Context, userPoolID string) (*AWSClient, error) {
 	if userPoolID == "" {
 		return nil, fmt.Errorf("userPoolID cannot be empty")
 	}
+	return NewAWSClientFromConfig(ctx, Config{UserPoolID: userPoolID})
+}
+
+// NewAWSClientByName creates a new AWS Cognito client by finding user pool ID from name
+func NewAWSClientByName(ctx context.Context, userPoolName string) (*AWSClient, error) {
+	if userPoolName == "" {
+		return nil, fmt.Errorf("userPoolName cannot be empty")
+	}
+	return NewAWSClientFromConfig(ctx, Config{UserPoolName: userPoolName})
+}
 
-	// Load AWS configuration with Pod Identity (IRSA)
-	cfg, err := config.LoadDefaultConfig(ctx)
+// NewAWSClientFromConfig creates a new AWS Cognito client for the user pool, region and
+// credentials in cfg, falling back to the default AWS configuration (Pod Identity) for
+// anything left empty
+func NewAWSClientFromConfig(ctx context.Context, cfg Config) (*AWSClient, error) {
+	if cfg.UserPoolID == "" && cfg.UserPoolName == "" {
+		return nil, fmt.Errorf("either userPoolID or userPoolName must be set")
+	}
+
+	cognito, err := newCognitoAPI(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, err
+	}
+
+	userPoolID := cfg.UserPoolID
+	if userPoolID == "" {
+		userPoolID, err = findUserPoolIDByName(ctx, cognito, cfg.UserPoolName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user pool by name %s: %w", cfg.UserPoolName, err)
+		}
 	}
 
 	return &AWSClient{
-		cognito:    cognitoidentityprovider.NewFromConfig(cfg),
+		cognito:    cognito,
 		userPoolID: userPoolID,
+		region:     cognito.Options().Region,
+		mode:       detectUsernames,
 	}, nil
 }
 
-// NewAWSClientByName creates a new AWS Cognito client by finding user pool ID from name
-func NewAWSClientByName(ctx context.Context, userPoolName string) (*AWSClient, error) {
-	if userPoolName == "" {
-		return nil, fmt.Errorf("userPoolName cannot be empty")
+// Scope identifies the user pool as region/ID, whether it was selected by ID or by name
+func (c *AWSClient) Scope() string {
+	return c.region + "/" + c.userPoolID
+}
+
+// newCognitoAPI creates a Cognito API client from the default AWS configuration, with the
+// region, endpoint and credentials in cfg applied through its options
+func newCognitoAPI(ctx context.Context, cfg Config) (*cognitoidentityprovider.Client, error) {
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("a secret access key must be set with access key ID %s", cfg.AccessKeyID)
 	}
 
-	// Load AWS configuration with Pod Identity (IRSA)
-	cfg, err := config.LoadDefaultConfig(ctx)
+	var opts []func(*config.LoadOptions) error
+	if cfg.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(cfg.Profile))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	if cfg.Region != "" {
+		awsCfg.Region = cfg.Region
+	}
 
-	cognito := cognitoidentityprovider.NewFromConfig(cfg)
+	provider := awsCfg.Credentials
+	if cfg.AccessKeyID != "" {
+		provider = credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)
+	}
+	if cfg.RoleARN != "" {
+		stsClient := sts.NewFromConfig(awsCfg, func(o *sts.Options) {
+			o.Credentials = provider
+		})
+		assumeRole := stscreds.NewAssumeRoleProvider(stsClient, cfg.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = cfg.RoleSessionName
+			if o.RoleSessionName == "" {
+				o.RoleSessionName = DefaultRoleSessionName
+			}
+			o.Duration = cfg.RoleDuration
+			if cfg.ExternalID != "" {
+				o.ExternalID = aws.String(cfg.ExternalID)
+			}
+		})
+		// Credentials are cached by the client and refreshed shortly before they expire
+		provider = aws.NewCredentialsCache(assumeRole, func(o *aws.CredentialsCacheOptions) {
+			o.ExpiryWindow = roleExpiryWindow
+		})
+	}
 
-	// Find user pool ID by name
-	userPoolID, err := findUserPoolIDByName(ctx, cognito, userPoolName)
+	return cognitoidentityprovider.NewFromConfig(awsCfg, func(o *cognitoidentityprovider.Options) {
+		o.Region = awsCfg.Region
+		if provider != nil {
+			o.Credentials = credentialsProvider{provider}
+		}
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	}), nil
+}
+
+// credentialsProvider marks failures to retrieve credentials with userpool.ErrCredentials
+type credentialsProvider struct {
+	aws.CredentialsProvider
+}
+
+// Retrieve returns the credentials of the wrapped provider
+func (p credentialsProvider) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.CredentialsProvider.Retrieve(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find user pool by name %s: %w", userPoolName, err)
+		return aws.Credentials{}, fmt.Errorf("%w: %w", userpool.ErrCredentials, err)
 	}
-
-	return &AWSClient{
-		cognito:    cognito,
-		userPoolID: userPoolID,
-	}, nil
+	return creds, nil
 }
 
 // findUserPoolIDByName finds a user pool ID by its name
@@ -126,156 +258,299 @@ func (c *AWSClient) CreateUser(ctx context.Context, user *userpool.User) (*userp
 		return nil, fmt.Errorf("email cannot be empty")
 	}
 
-	attributes := []types.AttributeType{
+	attributes := append([]types.AttributeType{
 		{
 			Name:  aws.String(emailAttribute),
 			Value: aws.String(user.Email),
 		},
 		{
-			Name:  aws.String("email_verified"),
+			Name:  aws.String(emailVerifiedAttribute),
 			Value: aws.String("true"),
 		},
+	}, toAttributeTypes(user.Attributes)...)
+
+	username, err := c.newUsername(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user %s: %w", user.Email, err)
 	}
 
 	input := &cognitoidentityprovider.AdminCreateUserInput{
 		UserPoolId:     aws.String(c.userPoolID),
-		Username:       aws.String(user.Email),
+		Username:       aws.String(username),
 		UserAttributes: attributes,
-		MessageAction:  types.MessageActionTypeSuppress, // Don't send welcome email
+	}
+	if len(user.InvitationDeliveryMediums) > 0 {
+		input.DesiredDeliveryMediums = toDeliveryMediums(user.InvitationDeliveryMediums)
+	} else {
+		input.MessageAction = types.MessageActionTypeSuppress // Don't send welcome email
 	}
 
 	resp, err := c.cognito.AdminCreateUser(ctx, input)
 	if err != nil {
-		// Check if the error is due to user already existing
+		// Report existing users so the caller can decide whether to adopt them
 		var userExistsErr *types.UsernameExistsException
 		if errors.As(err, &userExistsErr) {
-			// User already exists, get the existing user info
-			return c.GetUser(ctx, user.Email)
+			return nil, fmt.Errorf("failed to create user %s: %w", user.Email, userpool.ErrUserExists)
 		}
 		return nil, fmt.Errorf("failed to create user %s: %w", user.Email, err)
 	}
+	if resp.User == nil {
+		return nil, fmt.Errorf("failed to create user %s: no user returned", user.Email)
+	}
+
+	// Cognito creates users enabled
+	if !user.Enabled {
+		disableInput := &cognitoidentityprovider.AdminDisableUserInput{
+			UserPoolId: aws.String(c.userPoolID),
+			Username:   resp.User.Username,
+		}
+		if _, err := c.cognito.AdminDisableUser(ctx, disableInput); err != nil {
+			return nil, fmt.Errorf("failed to disable created user %s: %w", user.Email, err)
+		}
+	}
 
 	// Extract the user information from the response
 	createdUser := &userpool.User{
-		Username: user.Username,
+		Username: aws.ToString(resp.User.Username),
 		Enabled:  user.Enabled,
-		Sub:      *resp.User.Username, // AWS returns the sub as Username
+		Sub:      subOf(resp.User.Username, resp.User.Attributes),
 	}
+	c.rememberUsername(createdUser.Sub, createdUser.Username)
 
 	// Extract email and other attributes from the response
-	if resp.User != nil && len(resp.User.Attributes) > 0 {
-		for _, attr := range resp.User.Attributes {
-			if attr.Name != nil && attr.Value != nil {
-				switch *attr.Name {
-				case emailAttribute:
-					createdUser.Email = *attr.Value
-				}
-			}
-		}
-	}
+	createdUser.Email, createdUser.Attributes = fromAttributeTypes(resp.User.Attributes)
+	createdUser.PasswordChangeRequired = resp.User.UserStatus == types.UserStatusTypeForceChangePassword
+	createdUser.Status = string(resp.User.UserStatus)
+	createdUser.CreatedAt = aws.ToTime(resp.User.UserCreateDate)
+	createdUser.LastModifiedAt = aws.ToTime(resp.User.UserLastModifiedDate)
 
 	return createdUser, nil
 }
 
-// GetUser retrieves a user from the Cognito user pool by username
+// GetUser retrieves a user from the Cognito user pool by sub or email
 func (c *AWSClient) GetUser(ctx context.Context, username string) (*userpool.User, error) {
 	if username == "" {
 		return nil, fmt.Errorf("username cannot be empty")
 	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
 
 	input := &cognitoidentityprovider.AdminGetUserInput{
 		UserPoolId: aws.String(c.userPoolID),
-		Username:   aws.String(username),
+		Username:   aws.String(cognitoUsername),
 	}
 
 	output, err := c.cognito.AdminGetUser(ctx, input)
 	if err != nil {
+		var userNotFoundErr *types.UserNotFoundException
+		if errors.As(err, &userNotFoundErr) {
+			return nil, fmt.Errorf("failed to get user %s: %w: %w", username, userpool.ErrUserNotFound, err)
+		}
 		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
 	}
 
 	user := &userpool.User{
-		Username: username,
-		Enabled:  output.Enabled,
-		Sub:      *output.Username, // AWS returns the sub as Username
+		Username:               aws.ToString(output.Username),
+		Enabled:                output.Enabled,
+		Sub:                    subOf(output.Username, output.UserAttributes),
+		PasswordChangeRequired: output.UserStatus == types.UserStatusTypeForceChangePassword,
+		Status:                 string(output.UserStatus),
+		CreatedAt:              aws.ToTime(output.UserCreateDate),
+		LastModifiedAt:         aws.ToTime(output.UserLastModifiedDate),
+		MFAMethods:             output.UserMFASettingList,
 	}
-
-	// Extract email from user attributes
-	for _, attr := range output.UserAttributes {
-		if attr.Name != nil && attr.Value != nil {
-			switch *attr.Name {
-			case emailAttribute:
-				user.Email = *attr.Value
-			}
-		}
+	if len(user.MFAMethods) == 0 {
+		user.MFAMethods = fromMFAOptions(output.MFAOptions)
 	}
 
+	// Extract email and other attributes from user attributes
+	user.Email, user.Attributes = fromAttributeTypes(output.UserAttributes)
+	c.rememberUsername(user.Sub, user.Username)
+
 	return user, nil
 }
 
-// UpdateUser updates an existing user in the Cognito user pool
+// UpdateUser updates an existing user in the Cognito user pool, identified by its sub or email
 func (c *AWSClient) UpdateUser(ctx context.Context, user *userpool.User) error {
 	if user == nil {
 		return fmt.Errorf("user cannot be nil")
 	}
-	if user.Username == "" {
-		return fmt.Errorf("username cannot be empty")
+	identifier := user.Sub
+	if identifier == "" {
+		identifier = user.Email
+	}
+	if identifier == "" {
+		return fmt.Errorf("user sub or email must be set")
+	}
+	username, err := c.username(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to update user %s: %w", identifier, err)
 	}
 
 	// Update user attributes
-	attributes := []types.AttributeType{
+	attributes := append([]types.AttributeType{
 		{
 			Name:  aws.String(emailAttribute),
 			Value: aws.String(user.Email),
 		},
-	}
+	}, toAttributeTypes(user.Attributes)...)
 
 	updateInput := &cognitoidentityprovider.AdminUpdateUserAttributesInput{
 		UserPoolId:     aws.String(c.userPoolID),
-		Username:       aws.String(user.Sub),
+		Username:       aws.String(username),
 		UserAttributes: attributes,
 	}
 
-	_, err := c.cognito.AdminUpdateUserAttributes(ctx, updateInput)
+	_, err = c.cognito.AdminUpdateUserAttributes(ctx, updateInput)
 	if err != nil {
-		return fmt.Errorf("failed to update user attributes for %s: %w", user.Username, err)
+		return fmt.Errorf("failed to update user attributes for %s: %w", identifier, err)
 	}
 
 	// Update user status if needed
 	if user.Enabled {
 		enableInput := &cognitoidentityprovider.AdminEnableUserInput{
 			UserPoolId: aws.String(c.userPoolID),
-			Username:   aws.String(user.Username),
+			Username:   aws.String(username),
 		}
 		_, err = c.cognito.AdminEnableUser(ctx, enableInput)
 		if err != nil {
-			return fmt.Errorf("failed to enable user %s: %w", user.Username, err)
+			return fmt.Errorf("failed to enable user %s: %w", identifier, err)
 		}
 	} else {
 		disableInput := &cognitoidentityprovider.AdminDisableUserInput{
 			UserPoolId: aws.String(c.userPoolID),
-			Username:   aws.String(user.Username),
+			Username:   aws.String(username),
 		}
 		_, err = c.cognito.AdminDisableUser(ctx, disableInput)
 		if err != nil {
-			return fmt.Errorf("failed to disable user %s: %w", user.Username, err)
+			return fmt.Errorf("failed to disable user %s: %w", identifier, err)
 		}
 	}
 
 	return nil
 }
 
+// DeleteUserAttributes removes the named attributes from a user in the Cognito user pool
+func (c *AWSClient) DeleteUserAttributes(ctx context.Context, username string, names []string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete attributes of user %s: %w", username, err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	input := &cognitoidentityprovider.AdminDeleteUserAttributesInput{
+		UserPoolId:         aws.String(c.userPoolID),
+		Username:           aws.String(cognitoUsername),
+		UserAttributeNames: names,
+	}
+
+	if _, err := c.cognito.AdminDeleteUserAttributes(ctx, input); err != nil {
+		return fmt.Errorf("failed to delete attributes %v of user %s: %w", names, username, err)
+	}
+
+	return nil
+}
+
+// SetUserPassword sets a user's password in the Cognito user pool
+func (c *AWSClient) SetUserPassword(ctx context.Context, username, password string, permanent bool) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+
+	input := &cognitoidentityprovider.AdminSetUserPasswordInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Username:   aws.String(cognitoUsername),
+		Password:   aws.String(password),
+		Permanent:  permanent,
+	}
+
+	if _, err := c.cognito.AdminSetUserPassword(ctx, input); err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
+
+	return nil
+}
+
+// ResendInvitation resends the welcome message to a user in the Cognito user pool.
+// Cognito only accepts this for users that have not replaced their temporary password yet.
+func (c *AWSClient) ResendInvitation(ctx context.Context, username string, deliveryMediums []string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to resend invitation to user %s: %w", username, err)
+	}
+	if len(deliveryMediums) == 0 {
+		return fmt.Errorf("at least one delivery medium is required")
+	}
+
+	input := &cognitoidentityprovider.AdminCreateUserInput{
+		UserPoolId:             aws.String(c.userPoolID),
+		Username:               aws.String(cognitoUsername),
+		MessageAction:          types.MessageActionTypeResend,
+		DesiredDeliveryMediums: toDeliveryMediums(deliveryMediums),
+	}
+
+	if _, err := c.cognito.AdminCreateUser(ctx, input); err != nil {
+		return fmt.Errorf("failed to resend invitation to user %s: %w", username, err)
+	}
+
+	return nil
+}
+
+// DisableUser disables a user in the Cognito user pool
+func (c *AWSClient) DisableUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to disable user %s: %w", username, err)
+	}
+
+	input := &cognitoidentityprovider.AdminDisableUserInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Username:   aws.String(cognitoUsername),
+	}
+
+	if _, err := c.cognito.AdminDisableUser(ctx, input); err != nil {
+		return fmt.Errorf("failed to disable user %s: %w", username, err)
+	}
+
+	return nil
+}
+
 // DeleteUser removes a user from the Cognito user pool
 func (c *AWSClient) DeleteUser(ctx context.Context, username string) error {
 	if username == "" {
 		return fmt.Errorf("username cannot be empty")
 	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", username, err)
+	}
 
 	input := &cognitoidentityprovider.AdminDeleteUserInput{
 		UserPoolId: aws.String(c.userPoolID),
-		Username:   aws.String(username),
+		Username:   aws.String(cognitoUsername),
 	}
 
-	_, err := c.cognito.AdminDeleteUser(ctx, input)
+	_, err = c.cognito.AdminDeleteUser(ctx, input)
 	if err != nil {
 		// Check if the error is due to user not existing
 		var userNotFoundErr *types.UserNotFoundException
@@ -311,17 +586,19 @@ func (c *AWSClient) ListUsers(ctx context.Context) ([]*userpool.User, error) {
 			}
 
 			user := &userpool.User{
-				Username: *cognitoUser.Username,
-				Enabled:  cognitoUser.Enabled,
+				Username:               *cognitoUser.Username,
+				Sub:                    subOf(cognitoUser.Username, cognitoUser.Attributes),
+				Enabled:                cognitoUser.Enabled,
+				PasswordChangeRequired: cognitoUser.UserStatus == types.UserStatusTypeForceChangePassword,
+				Status:                 string(cognitoUser.UserStatus),
+				CreatedAt:              aws.ToTime(cognitoUser.UserCreateDate),
+				LastModifiedAt:         aws.ToTime(cognitoUser.UserLastModifiedDate),
+				MFAMethods:             fromMFAOptions(cognitoUser.MFAOptions),
 			}
 
-			// Extract email from user attributes
-			for _, attr := range cognitoUser.Attributes {
-				if attr.Name != nil && *attr.Name == emailAttribute && attr.Value != nil {
-					user.Email = *attr.Value
-					break
-				}
-			}
+			// Extract email and other attributes from user attributes
+			user.Email, user.Attributes = fromAttributeTypes(cognitoUser.Attributes)
+			c.rememberUsername(user.Sub, user.Username)
 
 			users = append(users, user)
 		}
@@ -335,6 +612,302 @@ func (c *AWSClient) ListUsers(ctx context.Context) ([]*userpool.User, error) {
 	return users, nil
 }
 
+// ListSchemaAttributes lists the names of the attributes declared in the Cognito user pool schema
+func (c *AWSClient) ListSchemaAttributes(ctx context.Context) ([]string, error) {
+	output, err := c.cognito.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: aws.String(c.userPoolID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe user pool %s: %w", c.userPoolID, err)
+	}
+	if output.UserPool == nil {
+		return nil, fmt.Errorf("user pool %s not found", c.userPoolID)
+	}
+
+	names := make([]string, 0, len(output.UserPool.SchemaAttributes))
+	for _, attr := range output.UserPool.SchemaAttributes {
+		if attr.Name != nil {
+			names = append(names, *attr.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// GetGroup retrieves a group from the Cognito user pool
+func (c *AWSClient) GetGroup(ctx context.Context, name string) (*userpool.Group, error) {
+	if name == "" {
+		return nil, fmt.Errorf("group name cannot be empty")
+	}
+
+	output, err := c.cognito.GetGroup(ctx, &cognitoidentityprovider.GetGroupInput{
+		UserPoolId: aws.String(c.userPoolID),
+		GroupName:  aws.String(name),
+	})
+	if err != nil {
+		var notFoundErr *types.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return nil, fmt.Errorf("%w: %s: %w", userpool.ErrGroupNotFound, name, err)
+		}
+		return nil, fmt.Errorf("failed to get group %s: %w", name, err)
+	}
+	if output.Group == nil {
+		return nil, fmt.Errorf("%w: %s", userpool.ErrGroupNotFound, name)
+	}
+
+	description, owner := splitGroupDescription(aws.ToString(output.Group.Description))
+	return &userpool.Group{
+		Name:        aws.ToString(output.Group.GroupName),
+		Description: description,
+		Precedence:  output.Group.Precedence,
+		Owner:       owner,
+	}, nil
+}
+
+// CreateGroup creates a group in the Cognito user pool, updating it if it already exists.
+// The owner is recorded on the last line of the description.
+func (c *AWSClient) CreateGroup(ctx context.Context, group *userpool.Group) error {
+	if group == nil {
+		return fmt.Errorf("group cannot be nil")
+	}
+	if group.Name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	input := &cognitoidentityprovider.CreateGroupInput{
+		UserPoolId: aws.String(c.userPoolID),
+		GroupName:  aws.String(group.Name),
+		Precedence: group.Precedence,
+	}
+	if description := joinGroupDescription(group.Description, group.Owner); description != "" {
+		input.Description = aws.String(description)
+	}
+
+	_, err := c.cognito.CreateGroup(ctx, input)
+	if err == nil {
+		return nil
+	}
+
+	// Check if the error is due to group already existing
+	var groupExistsErr *types.GroupExistsException
+	if !errors.As(err, &groupExistsErr) {
+		return fmt.Errorf("failed to create group %s: %w", group.Name, err)
+	}
+
+	// Group already exists, bring its settings in line with the desired ones
+	updateInput := &cognitoidentityprovider.UpdateGroupInput{
+		UserPoolId:  aws.String(c.userPoolID),
+		GroupName:   aws.String(group.Name),
+		Description: input.Description,
+		Precedence:  group.Precedence,
+	}
+	if _, err := c.cognito.UpdateGroup(ctx, updateInput); err != nil {
+		return fmt.Errorf("failed to update group %s: %w", group.Name, err)
+	}
+
+	return nil
+}
+
+// joinGroupDescription appends the owner of a group to its description
+func joinGroupDescription(description, owner string) string {
+	if owner == "" {
+		return description
+	}
+	if description == "" {
+		return groupOwnerPrefix + owner
+	}
+	return description + "\n" + groupOwnerPrefix + owner
+}
+
+// splitGroupDescription separates the owner recorded on the last line of a group description
+func splitGroupDescription(description string) (string, string) {
+	lastLine := description[strings.LastIndex(description, "\n")+1:]
+	if !strings.HasPrefix(lastLine, groupOwnerPrefix) {
+		return description, ""
+	}
+	rest := strings.TrimSuffix(description[:len(description)-len(lastLine)], "\n")
+	return rest, strings.TrimPrefix(lastLine, groupOwnerPrefix)
+}
+
+// DeleteGroup removes a group from the Cognito user pool
+func (c *AWSClient) DeleteGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	input := &cognitoidentityprovider.DeleteGroupInput{
+		UserPoolId: aws.String(c.userPoolID),
+		GroupName:  aws.String(name),
+	}
+
+	_, err := c.cognito.DeleteGroup(ctx, input)
+	if err != nil {
+		// Group doesn't exist, this is not an error for deletion
+		var notFoundErr *types.ResourceNotFoundException
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete group %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// AddUserToGroup adds a user to a group in the Cognito user pool
+func (c *AWSClient) AddUserToGroup(ctx context.Context, username, group string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to add user %s: %w", username, err)
+	}
+	if group == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	input := &cognitoidentityprovider.AdminAddUserToGroupInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Username:   aws.String(cognitoUsername),
+		GroupName:  aws.String(group),
+	}
+
+	if _, err := c.cognito.AdminAddUserToGroup(ctx, input); err != nil {
+		return fmt.Errorf("failed to add user %s to group %s: %w", username, group, err)
+	}
+
+	return nil
+}
+
+// RemoveUserFromGroup removes a user from a group in the Cognito user pool
+func (c *AWSClient) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to remove user %s: %w", username, err)
+	}
+	if group == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	input := &cognitoidentityprovider.AdminRemoveUserFromGroupInput{
+		UserPoolId: aws.String(c.userPoolID),
+		Username:   aws.String(cognitoUsername),
+		GroupName:  aws.String(group),
+	}
+
+	if _, err := c.cognito.AdminRemoveUserFromGroup(ctx, input); err != nil {
+		return fmt.Errorf("failed to remove user %s from group %s: %w", username, group, err)
+	}
+
+	return nil
+}
+
+// ListGroupsForUser lists the names of the Cognito groups a user belongs to
+func (c *AWSClient) ListGroupsForUser(ctx context.Context, username string) ([]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+	cognitoUsername, err := c.username(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups for user %s: %w", username, err)
+	}
+
+	var groups []string
+	var nextToken *string
+
+	for {
+		input := &cognitoidentityprovider.AdminListGroupsForUserInput{
+			UserPoolId: aws.String(c.userPoolID),
+			Username:   aws.String(cognitoUsername),
+			Limit:      aws.Int32(60), // Max allowed by AWS
+			NextToken:  nextToken,
+		}
+
+		output, err := c.cognito.AdminListGroupsForUser(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list groups for user %s: %w", username, err)
+		}
+
+		for _, group := range output.Groups {
+			if group.GroupName != nil {
+				groups = append(groups, *group.GroupName)
+			}
+		}
+
+		nextToken = output.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	return groups, nil
+}
+
+// toAttributeTypes converts an attribute map to Cognito attributes, sorted by name
+func toAttributeTypes(attributes map[string]string) []types.AttributeType {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]types.AttributeType, 0, len(names))
+	for _, name := range names {
+		result = append(result, types.AttributeType{
+			Name:  aws.String(name),
+			Value: aws.String(attributes[name]),
+		})
+	}
+	return result
+}
+
+// fromAttributeTypes extracts the email and the remaining attributes from Cognito attributes.
+// The sub and email_verified attributes are maintained by Cognito and are not returned.
+func fromAttributeTypes(attributes []types.AttributeType) (string, map[string]string) {
+	var email string
+	var result map[string]string
+	for _, attr := range attributes {
+		if attr.Name == nil || attr.Value == nil {
+			continue
+		}
+		switch *attr.Name {
+		case emailAttribute:
+			email = *attr.Value
+		case subAttribute, emailVerifiedAttribute:
+		default:
+			if result == nil {
+				result = map[string]string{}
+			}
+			result[*attr.Name] = *attr.Value
+		}
+	}
+	return email, result
+}
+
+// fromMFAOptions converts the legacy per-user MFA options, which are the only MFA information
+// returned by ListUsers, to MFA method names
+func fromMFAOptions(options []types.MFAOptionType) []string {
+	var methods []string
+	for _, option := range options {
+		if option.DeliveryMedium == types.DeliveryMediumTypeSms {
+			methods = append(methods, smsMFAMethod)
+		}
+	}
+	return methods
+}
+
+// toDeliveryMediums converts delivery medium names (EMAIL, SMS) to Cognito delivery mediums
+func toDeliveryMediums(mediums []string) []types.DeliveryMediumType {
+	result := make([]types.DeliveryMediumType, 0, len(mediums))
+	for _, medium := range mediums {
+		result = append(result, types.DeliveryMediumType(medium))
+	}
+	return result
+}
+
 // NewClient creates a new Cognito client with Pod Identity authentication
 // This is a convenience function that returns the AWS implementation
 func NewClient(ctx context.Context, userPoolID string) (userpool.Client, error) {
@@ -346,3 +919,9 @@ func NewClient(ctx context.Context, userPoolID string) (userpool.Client, error)
 func NewClientByName(ctx context.Context, userPoolName string) (userpool.Client, error) {
 	return NewAWSClientByName(ctx, userPoolName)
 }
+
+// NewClientFromConfig creates a new Cognito client for the user pool, region and credentials in cfg
+// This is a convenience function that returns the AWS implementation
+func NewClientFromConfig(ctx context.Context, cfg Config) (userpool.Client, error) {
+	return NewAWSClientFromConfig(ctx, cfg)
+}
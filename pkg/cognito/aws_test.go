@@ -19,7 +19,13 @@ package cognito
 import (
 	"context"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -28,17 +34,20 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito/fake"
 	"github.com/cogniteo/kcp-users-controller/pkg/cognito/mocks"
 	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/conformance"
 )
 
 func TestAWSClient_CreateUser(t *testing.T) {
 	tests := []struct {
-		name       string
-		user       *userpool.User
-		setupMocks func(*mocks.MockCognitoAPI)
-		expectErr  bool
-		expected   *userpool.User
+		name        string
+		user        *userpool.User
+		setupMocks  func(*mocks.MockCognitoAPI)
+		expectErr   bool
+		expectErrIs error
+		expected    *userpool.User
 	}{
 		{
 			name: "successful user creation",
@@ -69,36 +78,109 @@ func TestAWSClient_CreateUser(t *testing.T) {
 			},
 			expectErr: false,
 			expected: &userpool.User{
+				Username: "test@example.com",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123", // read from the sub attribute
+			},
+		},
+		{
+			name: "successful user creation with invitation",
+			user: &userpool.User{
+				Username:                  "testuser",
+				Email:                     "test@example.com",
+				Enabled:                   true,
+				InvitationDeliveryMediums: []string{"EMAIL", "SMS"},
+			},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminCreateUserInput) bool {
+						return input.MessageAction == "" &&
+							len(input.DesiredDeliveryMediums) == 2 &&
+							input.DesiredDeliveryMediums[0] == types.DeliveryMediumTypeEmail &&
+							input.DesiredDeliveryMediums[1] == types.DeliveryMediumTypeSms
+					})).
+					Return(&cognitoidentityprovider.AdminCreateUserOutput{
+						User: &types.UserType{
+							Username:   aws.String("test@example.com"),
+							Enabled:    true,
+							UserStatus: types.UserStatusTypeForceChangePassword,
+						},
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username:               "test@example.com",
+				Enabled:                true,
+				Sub:                    "test@example.com",
+				PasswordChangeRequired: true,
+				Status:                 "FORCE_CHANGE_PASSWORD",
+			},
+		},
+		{
+			name: "user creation without invitation suppresses message",
+			user: &userpool.User{
 				Username: "testuser",
 				Email:    "test@example.com",
 				Enabled:  true,
+			},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminCreateUserInput) bool {
+						return input.MessageAction == types.MessageActionTypeSuppress &&
+							len(input.DesiredDeliveryMediums) == 0
+					})).
+					Return(&cognitoidentityprovider.AdminCreateUserOutput{
+						User: &types.UserType{
+							Username: aws.String("test@example.com"),
+							Enabled:  true,
+						},
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username: "test@example.com",
+				Enabled:  true,
 				Sub:      "test@example.com",
 			},
 		},
 		{
-			name: "user already exists - returns existing user",
+			name: "successful user creation with attributes",
 			user: &userpool.User{
 				Username: "testuser",
 				Email:    "test@example.com",
 				Enabled:  true,
+				Attributes: map[string]string{
+					"given_name":        "Jane",
+					"custom:department": "engineering",
+				},
 			},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				userExistsErr := &types.UsernameExistsException{
-					Message: aws.String("User already exists"),
-				}
 				mockAPI.On("AdminCreateUser", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.AdminCreateUserInput")).Return(nil, userExistsErr)
-
-				// Mock the GetUser call when user already exists
-				mockAPI.On("AdminGetUser", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.AdminGetUserInput")).
-					Return(&cognitoidentityprovider.AdminGetUserOutput{
-						Username: aws.String("test@example.com"),
-						Enabled:  true,
-						UserAttributes: []types.AttributeType{
-							{
-								Name:  aws.String("email"),
-								Value: aws.String("test@example.com"),
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminCreateUserInput) bool {
+						return len(input.UserAttributes) == 4 &&
+							*input.UserAttributes[2].Name == "custom:department" &&
+							*input.UserAttributes[2].Value == "engineering" &&
+							*input.UserAttributes[3].Name == "given_name" &&
+							*input.UserAttributes[3].Value == "Jane"
+					})).
+					Return(&cognitoidentityprovider.AdminCreateUserOutput{
+						User: &types.UserType{
+							Username: aws.String("test@example.com"),
+							Enabled:  true,
+							Attributes: []types.AttributeType{
+								{
+									Name:  aws.String("email"),
+									Value: aws.String("test@example.com"),
+								},
+								{
+									Name:  aws.String("given_name"),
+									Value: aws.String("Jane"),
+								},
+								{
+									Name:  aws.String("custom:department"),
+									Value: aws.String("engineering"),
+								},
 							},
 						},
 					}, nil)
@@ -109,7 +191,28 @@ func TestAWSClient_CreateUser(t *testing.T) {
 				Email:    "test@example.com",
 				Enabled:  true,
 				Sub:      "test@example.com",
+				Attributes: map[string]string{
+					"given_name":        "Jane",
+					"custom:department": "engineering",
+				},
+			},
+		},
+		{
+			name: "user already exists",
+			user: &userpool.User{
+				Username: "testuser",
+				Email:    "test@example.com",
+				Enabled:  true,
+			},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				userExistsErr := &types.UsernameExistsException{
+					Message: aws.String("User already exists"),
+				}
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminCreateUserInput")).Return(nil, userExistsErr)
 			},
+			expectErr:   true,
+			expectErrIs: userpool.ErrUserExists,
 		},
 		{
 			name: "nil user input",
@@ -148,6 +251,20 @@ func TestAWSClient_CreateUser(t *testing.T) {
 			expectErr: true,
 			expected:  nil,
 		},
+		{
+			name: "no user returned",
+			user: &userpool.User{
+				Username: "testuser",
+				Email:    "test@example.com",
+			},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminCreateUserInput")).
+					Return(&cognitoidentityprovider.AdminCreateUserOutput{}, nil)
+			},
+			expectErr: true,
+			expected:  nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -165,6 +282,9 @@ func TestAWSClient_CreateUser(t *testing.T) {
 			if tt.expectErr {
 				require.Error(t, err)
 				assert.Nil(t, result)
+				if tt.expectErrIs != nil {
+					require.ErrorIs(t, err, tt.expectErrIs)
+				}
 			} else {
 				require.NoError(t, err)
 				assert.Equal(t, tt.expected, result)
@@ -206,6 +326,122 @@ func TestAWSClient_GetUser(t *testing.T) {
 				Sub:      "test@example.com",
 			},
 		},
+		{
+			name:     "user with status, dates and MFA",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminGetUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminGetUserInput")).
+					Return(&cognitoidentityprovider.AdminGetUserOutput{
+						Username:             aws.String("test@example.com"),
+						Enabled:              true,
+						UserStatus:           types.UserStatusTypeConfirmed,
+						UserCreateDate:       aws.Time(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+						UserLastModifiedDate: aws.Time(time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC)),
+						UserMFASettingList:   []string{"SOFTWARE_TOKEN_MFA"},
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username:       "test@example.com",
+				Enabled:        true,
+				Sub:            "test@example.com",
+				Status:         "CONFIRMED",
+				CreatedAt:      time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				LastModifiedAt: time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC),
+				MFAMethods:     []string{"SOFTWARE_TOKEN_MFA"},
+			},
+		},
+		{
+			name:     "user with legacy SMS MFA option",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminGetUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminGetUserInput")).
+					Return(&cognitoidentityprovider.AdminGetUserOutput{
+						Username:   aws.String("test@example.com"),
+						Enabled:    true,
+						UserStatus: types.UserStatusTypeConfirmed,
+						MFAOptions: []types.MFAOptionType{
+							{AttributeName: aws.String("phone_number"), DeliveryMedium: types.DeliveryMediumTypeSms},
+						},
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username:   "test@example.com",
+				Enabled:    true,
+				Sub:        "test@example.com",
+				Status:     "CONFIRMED",
+				MFAMethods: []string{"SMS_MFA"},
+			},
+		},
+		{
+			name:     "user with pending temporary password",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminGetUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminGetUserInput")).
+					Return(&cognitoidentityprovider.AdminGetUserOutput{
+						Username:   aws.String("test@example.com"),
+						Enabled:    true,
+						UserStatus: types.UserStatusTypeForceChangePassword,
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username:               "test@example.com",
+				Enabled:                true,
+				Sub:                    "test@example.com",
+				PasswordChangeRequired: true,
+				Status:                 "FORCE_CHANGE_PASSWORD",
+			},
+		},
+		{
+			name:     "user with additional attributes",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminGetUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminGetUserInput")).
+					Return(&cognitoidentityprovider.AdminGetUserOutput{
+						Username: aws.String("test@example.com"),
+						Enabled:  true,
+						UserAttributes: []types.AttributeType{
+							{
+								Name:  aws.String("sub"),
+								Value: aws.String("test-sub-123"),
+							},
+							{
+								Name:  aws.String("email"),
+								Value: aws.String("test@example.com"),
+							},
+							{
+								Name:  aws.String("email_verified"),
+								Value: aws.String("true"),
+							},
+							{
+								Name:  aws.String("family_name"),
+								Value: aws.String("Doe"),
+							},
+							{
+								Name:  aws.String("custom:team"),
+								Value: aws.String("platform"),
+							},
+						},
+					}, nil)
+			},
+			expectErr: false,
+			expected: &userpool.User{
+				Username: "test@example.com",
+				Email:    "test@example.com",
+				Enabled:  true,
+				Sub:      "test-sub-123",
+				Attributes: map[string]string{
+					"family_name": "Doe",
+					"custom:team": "platform",
+				},
+			},
+		},
 		{
 			name:     "empty username",
 			username: "",
@@ -275,6 +511,34 @@ func TestAWSClient_UpdateUser(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "successful user update - writes attributes",
+			user: &userpool.User{
+				Username: "test@example.com",
+				Sub:      "test-sub-123",
+				Email:    "updated@example.com",
+				Enabled:  true,
+				Attributes: map[string]string{
+					"locale": "en-US",
+				},
+			},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminUpdateUserAttributes", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminUpdateUserAttributesInput) bool {
+						return *input.Username == "test-sub-123" &&
+							len(input.UserAttributes) == 2 &&
+							*input.UserAttributes[1].Name == "locale" &&
+							*input.UserAttributes[1].Value == "en-US"
+					})).
+					Return(&cognitoidentityprovider.AdminUpdateUserAttributesOutput{}, nil)
+				mockAPI.On("AdminEnableUser", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminEnableUserInput) bool {
+						return *input.Username == "test-sub-123"
+					})).
+					Return(&cognitoidentityprovider.AdminEnableUserOutput{}, nil)
+			},
+			expectErr: false,
+		},
 		{
 			name: "successful user update - disable user",
 			user: &userpool.User{
@@ -301,10 +565,9 @@ func TestAWSClient_UpdateUser(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name: "empty username",
+			name: "missing sub and email",
 			user: &userpool.User{
-				Username: "",
-				Email:    "updated@example.com",
+				Username: "test-user",
 				Enabled:  true,
 			},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
@@ -366,50 +629,53 @@ func TestAWSClient_UpdateUser(t *testing.T) {
 	}
 }
 
-func TestAWSClient_DeleteUser(t *testing.T) {
+func TestAWSClient_DeleteUserAttributes(t *testing.T) {
 	tests := []struct {
 		name       string
 		username   string
+		attributes []string
 		setupMocks func(*mocks.MockCognitoAPI)
 		expectErr  bool
 	}{
 		{
-			name:     "successful user deletion",
-			username: "test@example.com",
+			name:       "successful attribute deletion",
+			username:   "test-sub-123",
+			attributes: []string{"custom:team", "locale"},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				mockAPI.On("AdminDeleteUser", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
-					Return(&cognitoidentityprovider.AdminDeleteUserOutput{}, nil)
+				mockAPI.On("AdminDeleteUserAttributes", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminDeleteUserAttributesInput) bool {
+						return *input.Username == "test-sub-123" &&
+							assert.ObjectsAreEqual([]string{"custom:team", "locale"}, input.UserAttributeNames)
+					})).
+					Return(&cognitoidentityprovider.AdminDeleteUserAttributesOutput{}, nil)
 			},
 			expectErr: false,
 		},
 		{
-			name:     "user not found - should not error",
-			username: "nonexistent@example.com",
+			name:       "no attributes - nothing to do",
+			username:   "test-sub-123",
+			attributes: nil,
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				userNotFoundErr := &types.UserNotFoundException{
-					Message: aws.String("User not found"),
-				}
-				mockAPI.On("AdminDeleteUser", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
-					Return(nil, userNotFoundErr)
+				// No mocks needed as nothing should be sent to AWS
 			},
 			expectErr: false,
 		},
 		{
-			name:     "empty username",
-			username: "",
+			name:       "empty username",
+			username:   "",
+			attributes: []string{"locale"},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
 				// No mocks needed as it should fail before calling AWS
 			},
 			expectErr: true,
 		},
 		{
-			name:     "AWS error during deletion",
-			username: "test@example.com",
+			name:       "AWS error during deletion",
+			username:   "test-sub-123",
+			attributes: []string{"locale"},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				mockAPI.On("AdminDeleteUser", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
+				mockAPI.On("AdminDeleteUserAttributes", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserAttributesInput")).
 					Return(nil, errors.New("AWS error"))
 			},
 			expectErr: true,
@@ -426,7 +692,7 @@ func TestAWSClient_DeleteUser(t *testing.T) {
 				userPoolID: "test-pool-id",
 			}
 
-			err := client.DeleteUser(context.Background(), tt.username)
+			err := client.DeleteUserAttributes(context.Background(), tt.username, tt.attributes)
 
 			if tt.expectErr {
 				require.Error(t, err)
@@ -437,66 +703,363 @@ func TestAWSClient_DeleteUser(t *testing.T) {
 	}
 }
 
-func TestAWSClient_ListUsers(t *testing.T) {
+func TestAWSClient_ResendInvitation(t *testing.T) {
 	tests := []struct {
-		name       string
-		setupMocks func(*mocks.MockCognitoAPI)
-		expectErr  bool
-		expected   []*userpool.User
+		name            string
+		username        string
+		deliveryMediums []string
+		setupMocks      func(*mocks.MockCognitoAPI)
+		expectErr       bool
 	}{
 		{
-			name: "successful user listing - single page",
+			name:            "successful resend",
+			username:        "test-sub-123",
+			deliveryMediums: []string{"EMAIL"},
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				mockAPI.On("ListUsers", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
-					Return(&cognitoidentityprovider.ListUsersOutput{
-						Users: []types.UserType{
-							{
-								Username: aws.String("user1@example.com"),
-								Enabled:  true,
-								Attributes: []types.AttributeType{
-									{
-										Name:  aws.String("email"),
-										Value: aws.String("user1@example.com"),
-									},
-								},
-							},
-							{
-								Username: aws.String("user2@example.com"),
-								Enabled:  false,
-								Attributes: []types.AttributeType{
-									{
-										Name:  aws.String("email"),
-										Value: aws.String("user2@example.com"),
-									},
-								},
-							},
-						},
-						PaginationToken: nil,
-					}, nil)
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminCreateUserInput) bool {
+						return *input.Username == "test-sub-123" &&
+							input.MessageAction == types.MessageActionTypeResend &&
+							len(input.DesiredDeliveryMediums) == 1 &&
+							input.DesiredDeliveryMediums[0] == types.DeliveryMediumTypeEmail
+					})).
+					Return(&cognitoidentityprovider.AdminCreateUserOutput{}, nil)
 			},
 			expectErr: false,
-			expected: []*userpool.User{
-				{
-					Username: "user1@example.com",
-					Email:    "user1@example.com",
-					Enabled:  true,
-				},
-				{
-					Username: "user2@example.com",
-					Email:    "user2@example.com",
-					Enabled:  false,
-				},
+		},
+		{
+			name:            "empty username",
+			username:        "",
+			deliveryMediums: []string{"EMAIL"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
 			},
+			expectErr: true,
 		},
 		{
-			name: "successful user listing - multiple pages",
+			name:     "no delivery mediums",
+			username: "test-sub-123",
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				// First page
-				mockAPI.On("ListUsers", mock.Anything, mock.MatchedBy(func(input *cognitoidentityprovider.ListUsersInput) bool {
-					return input.PaginationToken == nil
-				})).Return(&cognitoidentityprovider.ListUsersOutput{
-					Users: []types.UserType{
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:            "user already signed in",
+			username:        "test-sub-123",
+			deliveryMediums: []string{"SMS"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminCreateUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminCreateUserInput")).
+					Return(nil, &types.UnsupportedUserStateException{Message: aws.String("User is already confirmed")})
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			err := client.ResendInvitation(context.Background(), tt.username, tt.deliveryMediums)
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAWSClient_SetUserPassword(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		permanent  bool
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+	}{
+		{
+			name:      "successful temporary password",
+			username:  "test-sub-123",
+			password:  "Initial#Passw0rd",
+			permanent: false,
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminSetUserPassword", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminSetUserPasswordInput) bool {
+						return *input.Username == "test-sub-123" && *input.Password == "Initial#Passw0rd" &&
+							!input.Permanent
+					})).
+					Return(&cognitoidentityprovider.AdminSetUserPasswordOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:      "successful permanent password",
+			username:  "test-sub-123",
+			password:  "Initial#Passw0rd",
+			permanent: true,
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminSetUserPassword", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminSetUserPasswordInput) bool {
+						return input.Permanent
+					})).
+					Return(&cognitoidentityprovider.AdminSetUserPasswordOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:     "empty password",
+			username: "test-sub-123",
+			password: "",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:     "AWS error",
+			username: "test-sub-123",
+			password: "weak",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminSetUserPassword", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminSetUserPasswordInput")).
+					Return(nil, &types.InvalidPasswordException{Message: aws.String("Password does not conform to policy")})
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			err := client.SetUserPassword(context.Background(), tt.username, tt.password, tt.permanent)
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAWSClient_DisableUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+	}{
+		{
+			name:     "successful disable",
+			username: "test-sub-123",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminDisableUser", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.AdminDisableUserInput) bool {
+						return *input.Username == "test-sub-123" && *input.UserPoolId == "test-pool-id"
+					})).
+					Return(&cognitoidentityprovider.AdminDisableUserOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:     "empty username",
+			username: "",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:     "AWS error during disable",
+			username: "test-sub-123",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminDisableUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminDisableUserInput")).
+					Return(nil, errors.New("AWS error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			err := client.DisableUser(context.Background(), tt.username)
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAWSClient_DeleteUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+	}{
+		{
+			name:     "successful user deletion",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminDeleteUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
+					Return(&cognitoidentityprovider.AdminDeleteUserOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:     "user not found - should not error",
+			username: "nonexistent@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				userNotFoundErr := &types.UserNotFoundException{
+					Message: aws.String("User not found"),
+				}
+				mockAPI.On("AdminDeleteUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
+					Return(nil, userNotFoundErr)
+			},
+			expectErr: false,
+		},
+		{
+			name:     "empty username",
+			username: "",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:     "AWS error during deletion",
+			username: "test@example.com",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("AdminDeleteUser", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.AdminDeleteUserInput")).
+					Return(nil, errors.New("AWS error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			err := client.DeleteUser(context.Background(), tt.username)
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAWSClient_ListUsers(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+		expected   []*userpool.User
+	}{
+		{
+			name: "successful user listing - single page",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("ListUsers", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
+					Return(&cognitoidentityprovider.ListUsersOutput{
+						Users: []types.UserType{
+							{
+								Username:       aws.String("user1@example.com"),
+								Enabled:        true,
+								UserStatus:     types.UserStatusTypeConfirmed,
+								UserCreateDate: aws.Time(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)),
+								MFAOptions: []types.MFAOptionType{
+									{AttributeName: aws.String("phone_number"), DeliveryMedium: types.DeliveryMediumTypeSms},
+								},
+								Attributes: []types.AttributeType{
+									{
+										Name:  aws.String("email"),
+										Value: aws.String("user1@example.com"),
+									},
+								},
+							},
+							{
+								Username: aws.String("user2@example.com"),
+								Enabled:  false,
+								Attributes: []types.AttributeType{
+									{
+										Name:  aws.String("email"),
+										Value: aws.String("user2@example.com"),
+									},
+								},
+							},
+						},
+						PaginationToken: nil,
+					}, nil)
+			},
+			expectErr: false,
+			expected: []*userpool.User{
+				{
+					Username:   "user1@example.com",
+					Sub:        "user1@example.com",
+					Email:      "user1@example.com",
+					Enabled:    true,
+					Status:     "CONFIRMED",
+					CreatedAt:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+					MFAMethods: []string{"SMS_MFA"},
+				},
+				{
+					Username: "user2@example.com",
+					Sub:      "user2@example.com",
+					Email:    "user2@example.com",
+					Enabled:  false,
+				},
+			},
+		},
+		{
+			name: "successful user listing - multiple pages",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// First page
+				mockAPI.On("ListUsers", mock.Anything, mock.MatchedBy(func(input *cognitoidentityprovider.ListUsersInput) bool {
+					return input.PaginationToken == nil
+				})).Return(&cognitoidentityprovider.ListUsersOutput{
+					Users: []types.UserType{
 						{
 							Username: aws.String("user1@example.com"),
 							Enabled:  true,
@@ -526,46 +1089,365 @@ func TestAWSClient_ListUsers(t *testing.T) {
 								},
 							},
 						},
-					},
-					PaginationToken: nil,
-				}, nil)
+					},
+					PaginationToken: nil,
+				}, nil)
+			},
+			expectErr: false,
+			expected: []*userpool.User{
+				{
+					Username: "user1@example.com",
+					Sub:      "user1@example.com",
+					Email:    "user1@example.com",
+					Enabled:  true,
+				},
+				{
+					Username: "user2@example.com",
+					Sub:      "user2@example.com",
+					Email:    "user2@example.com",
+					Enabled:  false,
+				},
+			},
+		},
+		{
+			name: "AWS error during listing",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("ListUsers", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
+					Return(nil, errors.New("AWS error"))
+			},
+			expectErr: true,
+			expected:  nil,
+		},
+		{
+			name: "empty user list",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("ListUsers", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
+					Return(&cognitoidentityprovider.ListUsersOutput{
+						Users:           []types.UserType{},
+						PaginationToken: nil,
+					}, nil)
+			},
+			expectErr: false,
+			expected:  nil, // Change to nil to match actual implementation behavior
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			result, err := client.ListUsers(context.Background())
+
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				if tt.expected == nil {
+					assert.Nil(t, result)
+				} else {
+					assert.Equal(t, tt.expected, result)
+				}
+			}
+		})
+	}
+}
+
+func TestAWSClient_ListSchemaAttributes(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+		expected   []string
+	}{
+		{
+			name: "successful schema listing",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("DescribeUserPool", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.DescribeUserPoolInput) bool {
+						return *input.UserPoolId == "test-pool-id"
+					})).
+					Return(&cognitoidentityprovider.DescribeUserPoolOutput{
+						UserPool: &types.UserPoolType{
+							SchemaAttributes: []types.SchemaAttributeType{
+								{Name: aws.String("email")},
+								{Name: aws.String("given_name")},
+								{Name: aws.String("custom:department")},
+							},
+						},
+					}, nil)
 			},
 			expectErr: false,
-			expected: []*userpool.User{
-				{
-					Username: "user1@example.com",
-					Email:    "user1@example.com",
-					Enabled:  true,
-				},
-				{
-					Username: "user2@example.com",
-					Email:    "user2@example.com",
-					Enabled:  false,
-				},
+			expected:  []string{"email", "given_name", "custom:department"},
+		},
+		{
+			name: "missing user pool",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("DescribeUserPool", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.DescribeUserPoolInput")).
+					Return(&cognitoidentityprovider.DescribeUserPoolOutput{}, nil)
 			},
+			expectErr: true,
 		},
 		{
-			name: "AWS error during listing",
+			name: "AWS error",
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				mockAPI.On("ListUsers", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
+				mockAPI.On("DescribeUserPool", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.DescribeUserPoolInput")).
+					Return(nil, &types.ResourceNotFoundException{Message: aws.String("User pool not found")})
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			result, err := client.ListSchemaAttributes(context.Background())
+
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAWSClient_CreateGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		group      *userpool.Group
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+	}{
+		{
+			name:  "successful group creation",
+			group: &userpool.Group{Name: "admins", Description: "Administrators", Precedence: aws.Int32(1)},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("CreateGroup", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.CreateGroupInput) bool {
+						return *input.GroupName == "admins" && *input.Description == "Administrators" &&
+							*input.Precedence == 1
+					})).
+					Return(&cognitoidentityprovider.CreateGroupOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "owner recorded in description",
+			group: &userpool.Group{Name: "admins", Description: "Administrators", Owner: "root:org/default/admins"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("CreateGroup", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.CreateGroupInput) bool {
+						return *input.Description == "Administrators\nkcp_owner: root:org/default/admins"
+					})).
+					Return(&cognitoidentityprovider.CreateGroupOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "group already exists - updates group",
+			group: &userpool.Group{Name: "admins", Description: "Administrators"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("CreateGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.CreateGroupInput")).
+					Return(nil, &types.GroupExistsException{Message: aws.String("Group already exists")})
+				mockAPI.On("UpdateGroup", mock.Anything,
+					mock.MatchedBy(func(input *cognitoidentityprovider.UpdateGroupInput) bool {
+						return *input.GroupName == "admins" && *input.Description == "Administrators"
+					})).
+					Return(&cognitoidentityprovider.UpdateGroupOutput{}, nil)
+			},
+			expectErr: false,
+		},
+		{
+			name:  "update of existing group fails",
+			group: &userpool.Group{Name: "admins"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("CreateGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.CreateGroupInput")).
+					Return(nil, &types.GroupExistsException{Message: aws.String("Group already exists")})
+				mockAPI.On("UpdateGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.UpdateGroupInput")).
 					Return(nil, errors.New("AWS error"))
 			},
 			expectErr: true,
-			expected:  nil,
 		},
 		{
-			name: "empty user list",
+			name:  "nil group input",
+			group: nil,
 			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
-				mockAPI.On("ListUsers", mock.Anything,
-					mock.AnythingOfType("*cognitoidentityprovider.ListUsersInput")).
-					Return(&cognitoidentityprovider.ListUsersOutput{
-						Users:           []types.UserType{},
-						PaginationToken: nil,
-					}, nil)
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:  "empty group name",
+			group: &userpool.Group{},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:  "AWS error during creation",
+			group: &userpool.Group{Name: "admins"},
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("CreateGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.CreateGroupInput")).
+					Return(nil, errors.New("AWS error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			tt.setupMocks(mockAPI)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			err := client.CreateGroup(context.Background(), tt.group)
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAWSClient_GetGroup(t *testing.T) {
+	tests := []struct {
+		name        string
+		description *string
+		expected    *userpool.Group
+	}{
+		{
+			name:     "group without description",
+			expected: &userpool.Group{Name: "admins"},
+		},
+		{
+			name:        "group created outside kcp",
+			description: aws.String("Administrators"),
+			expected:    &userpool.Group{Name: "admins", Description: "Administrators"},
+		},
+		{
+			name:        "owned group without description",
+			description: aws.String("kcp_owner: root:org/default/admins"),
+			expected:    &userpool.Group{Name: "admins", Owner: "root:org/default/admins"},
+		},
+		{
+			name:        "owned group with multi-line description",
+			description: aws.String("Administrators\nof the platform\nkcp_owner: root:org/default/admins"),
+			expected: &userpool.Group{Name: "admins", Description: "Administrators\nof the platform",
+				Owner: "root:org/default/admins"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAPI := mocks.NewMockCognitoAPI(t)
+			mockAPI.On("GetGroup", mock.Anything, mock.MatchedBy(func(input *cognitoidentityprovider.GetGroupInput) bool {
+				return *input.GroupName == "admins" && *input.UserPoolId == "test-pool-id"
+			})).Return(&cognitoidentityprovider.GetGroupOutput{Group: &types.GroupType{
+				GroupName:   aws.String("admins"),
+				Description: tt.description,
+			}}, nil)
+
+			client := &AWSClient{
+				cognito:    mockAPI,
+				userPoolID: "test-pool-id",
+			}
+
+			group, err := client.GetGroup(context.Background(), "admins")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, group)
+			description := aws.ToString(tt.description)
+			assert.Equal(t, description, joinGroupDescription(group.Description, group.Owner))
+		})
+	}
+
+	t.Run("group not found", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("GetGroup", mock.Anything, mock.AnythingOfType("*cognitoidentityprovider.GetGroupInput")).
+			Return(nil, &types.ResourceNotFoundException{Message: aws.String("Group not found")})
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id"}
+
+		_, err := client.GetGroup(context.Background(), "admins")
+
+		assert.ErrorIs(t, err, userpool.ErrGroupNotFound)
+	})
+}
+
+func TestAWSClient_DeleteGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		group      string
+		setupMocks func(*mocks.MockCognitoAPI)
+		expectErr  bool
+	}{
+		{
+			name:  "successful group deletion",
+			group: "admins",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("DeleteGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.DeleteGroupInput")).
+					Return(&cognitoidentityprovider.DeleteGroupOutput{}, nil)
 			},
 			expectErr: false,
-			expected:  nil, // Change to nil to match actual implementation behavior
+		},
+		{
+			name:  "group not found - should not error",
+			group: "admins",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("DeleteGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.DeleteGroupInput")).
+					Return(nil, &types.ResourceNotFoundException{Message: aws.String("Group not found")})
+			},
+			expectErr: false,
+		},
+		{
+			name:  "empty group name",
+			group: "",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				// No mocks needed as it should fail before calling AWS
+			},
+			expectErr: true,
+		},
+		{
+			name:  "AWS error during deletion",
+			group: "admins",
+			setupMocks: func(mockAPI *mocks.MockCognitoAPI) {
+				mockAPI.On("DeleteGroup", mock.Anything,
+					mock.AnythingOfType("*cognitoidentityprovider.DeleteGroupInput")).
+					Return(nil, errors.New("AWS error"))
+			},
+			expectErr: true,
 		},
 	}
 
@@ -579,23 +1461,85 @@ func TestAWSClient_ListUsers(t *testing.T) {
 				userPoolID: "test-pool-id",
 			}
 
-			result, err := client.ListUsers(context.Background())
+			err := client.DeleteGroup(context.Background(), tt.group)
 
 			if tt.expectErr {
 				require.Error(t, err)
-				assert.Nil(t, result)
 			} else {
 				require.NoError(t, err)
-				if tt.expected == nil {
-					assert.Nil(t, result)
-				} else {
-					assert.Equal(t, tt.expected, result)
-				}
 			}
 		})
 	}
 }
 
+func TestAWSClient_GroupMembership(t *testing.T) {
+	t.Run("add user to group", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("AdminAddUserToGroup", mock.Anything,
+			mock.MatchedBy(func(input *cognitoidentityprovider.AdminAddUserToGroupInput) bool {
+				return *input.Username == "test-sub-123" && *input.GroupName == "admins"
+			})).
+			Return(&cognitoidentityprovider.AdminAddUserToGroupOutput{}, nil)
+
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id"}
+
+		require.NoError(t, client.AddUserToGroup(context.Background(), "test-sub-123", "admins"))
+		require.Error(t, client.AddUserToGroup(context.Background(), "", "admins"))
+		require.Error(t, client.AddUserToGroup(context.Background(), "test-sub-123", ""))
+	})
+
+	t.Run("remove user from group", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("AdminRemoveUserFromGroup", mock.Anything,
+			mock.MatchedBy(func(input *cognitoidentityprovider.AdminRemoveUserFromGroupInput) bool {
+				return *input.Username == "test-sub-123" && *input.GroupName == "admins"
+			})).
+			Return(nil, errors.New("AWS error"))
+
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id"}
+
+		require.Error(t, client.RemoveUserFromGroup(context.Background(), "test-sub-123", "admins"))
+	})
+
+	t.Run("list groups for user - multiple pages", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("AdminListGroupsForUser", mock.Anything,
+			mock.MatchedBy(func(input *cognitoidentityprovider.AdminListGroupsForUserInput) bool {
+				return input.NextToken == nil
+			})).
+			Return(&cognitoidentityprovider.AdminListGroupsForUserOutput{
+				Groups:    []types.GroupType{{GroupName: aws.String("admins")}},
+				NextToken: aws.String("next-token"),
+			}, nil)
+		mockAPI.On("AdminListGroupsForUser", mock.Anything,
+			mock.MatchedBy(func(input *cognitoidentityprovider.AdminListGroupsForUserInput) bool {
+				return input.NextToken != nil && *input.NextToken == "next-token"
+			})).
+			Return(&cognitoidentityprovider.AdminListGroupsForUserOutput{
+				Groups: []types.GroupType{{GroupName: aws.String("viewers")}},
+			}, nil)
+
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id"}
+
+		groups, err := client.ListGroupsForUser(context.Background(), "test-sub-123")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins", "viewers"}, groups)
+	})
+
+	t.Run("list groups for user - AWS error", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("AdminListGroupsForUser", mock.Anything,
+			mock.AnythingOfType("*cognitoidentityprovider.AdminListGroupsForUserInput")).
+			Return(nil, errors.New("AWS error"))
+
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id"}
+
+		groups, err := client.ListGroupsForUser(context.Background(), "test-sub-123")
+		require.Error(t, err)
+		assert.Nil(t, groups)
+	})
+}
+
 func TestFindUserPoolIDByName(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -730,3 +1674,310 @@ func TestFindUserPoolIDByName(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAWSClientFromConfig(t *testing.T) {
+	t.Run("user pool is required", func(t *testing.T) {
+		_, err := NewAWSClientFromConfig(context.Background(), Config{Region: "eu-west-1"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "either userPoolID or userPoolName must be set")
+	})
+
+	t.Run("static credentials and region", func(t *testing.T) {
+		client, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID:      "eu-west-1_tenant",
+			Region:          "eu-west-1",
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "eu-west-1_tenant", client.userPoolID)
+		assert.Equal(t, "eu-west-1/eu-west-1_tenant", client.Scope())
+
+		cognito, ok := client.cognito.(*cognitoidentityprovider.Client)
+		require.True(t, ok)
+		assert.Equal(t, "eu-west-1", cognito.Options().Region)
+		creds, err := cognito.Options().Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "AKIDEXAMPLE", creds.AccessKeyID)
+	})
+
+	t.Run("access key without secret", func(t *testing.T) {
+		_, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID:  "eu-west-1_tenant",
+			AccessKeyID: "AKIDEXAMPLE",
+		})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a secret access key must be set")
+	})
+
+	t.Run("endpoint", func(t *testing.T) {
+		client, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID: "us-east-1_local",
+			Region:     "us-east-1",
+			Endpoint:   "http://localhost:4566",
+		})
+
+		require.NoError(t, err)
+		cognito, ok := client.cognito.(*cognitoidentityprovider.Client)
+		require.True(t, ok)
+		assert.Equal(t, "http://localhost:4566", aws.ToString(cognito.Options().BaseEndpoint))
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		dir := t.TempDir()
+		configFile := filepath.Join(dir, "config")
+		require.NoError(t, os.WriteFile(configFile, []byte("[profile tenants]\nregion = ap-southeast-2\n"), 0o600))
+		credentialsFile := filepath.Join(dir, "credentials")
+		require.NoError(t, os.WriteFile(credentialsFile,
+			[]byte("[tenants]\naws_access_key_id = AKIDPROFILE\naws_secret_access_key = secret\n"), 0o600))
+		t.Setenv("AWS_CONFIG_FILE", configFile)
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+		t.Setenv("AWS_REGION", "")
+
+		client, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID: "ap-southeast-2_tenant",
+			Profile:    "tenants",
+		})
+
+		require.NoError(t, err)
+		cognito, ok := client.cognito.(*cognitoidentityprovider.Client)
+		require.True(t, ok)
+		assert.Equal(t, "ap-southeast-2", cognito.Options().Region)
+		creds, err := cognito.Options().Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "AKIDPROFILE", creds.AccessKeyID)
+	})
+
+	t.Run("assumed role", func(t *testing.T) {
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "AssumeRole", r.Form.Get("Action"))
+			assert.Equal(t, "arn:aws:iam::123456789012:role/tenants", r.Form.Get("RoleArn"))
+			assert.Equal(t, "tenant-a", r.Form.Get("ExternalId"))
+			assert.Equal(t, DefaultRoleSessionName, r.Form.Get("RoleSessionName"))
+			assert.Equal(t, "3600", r.Form.Get("DurationSeconds"))
+			authorization = r.Header.Get("Authorization")
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = io.WriteString(w, assumeRoleResponse)
+		}))
+		defer server.Close()
+		t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+
+		client, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID:      "eu-west-1_tenant",
+			Region:          "eu-west-1",
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			RoleARN:         "arn:aws:iam::123456789012:role/tenants",
+			ExternalID:      "tenant-a",
+			RoleDuration:    time.Hour,
+		})
+		require.NoError(t, err)
+
+		cognito, ok := client.cognito.(*cognitoidentityprovider.Client)
+		require.True(t, ok)
+		creds, err := cognito.Options().Credentials.Retrieve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "ASIAASSUMED", creds.AccessKeyID)
+		assert.Equal(t, "assumed-session-token", creds.SessionToken)
+		assert.Contains(t, authorization, "Credential=AKIDEXAMPLE/", "the role is assumed with the static credentials")
+	})
+
+	t.Run("role cannot be assumed", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/xml")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, accessDeniedResponse)
+		}))
+		defer server.Close()
+		t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+
+		client, err := NewAWSClientFromConfig(context.Background(), Config{
+			UserPoolID:      "eu-west-1_tenant",
+			Region:          "eu-west-1",
+			Endpoint:        server.URL,
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			RoleARN:         "arn:aws:iam::123456789012:role/denied",
+		})
+		require.NoError(t, err, "credentials are only retrieved when calling Cognito")
+
+		_, err = client.ListUsers(context.Background())
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, userpool.ErrCredentials)
+		assert.Contains(t, err.Error(), "AccessDenied")
+	})
+}
+
+// assumeRoleResponse is an STS AssumeRole response
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAASSUMED</AccessKeyId>
+      <SecretAccessKey>assumed-secret</SecretAccessKey>
+      <SessionToken>assumed-session-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/tenants/session</Arn>
+      <AssumedRoleId>AROAEXAMPLE:session</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata>
+    <RequestId>c6104cbe-af31-11e0-8154-cbc7ccf896c7</RequestId>
+  </ResponseMetadata>
+</AssumeRoleResponse>`
+
+// accessDeniedResponse is an STS error response refusing to assume a role
+const accessDeniedResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>AccessDenied</Code>
+    <Message>User is not authorized to perform: sts:AssumeRole</Message>
+  </Error>
+  <RequestId>c6104cbe-af31-11e0-8154-cbc7ccf896c7</RequestId>
+</ErrorResponse>`
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		api := fake.New()
+		return &AWSClient{cognito: api, userPoolID: api.CreateUserPool("test", userpool.OwnershipAttributes...)}
+	})
+}
+
+func TestConformance_OwnUsernames(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		api := fake.New()
+		poolID := api.CreateUserPoolWithUsernameAttributes("test", nil, userpool.OwnershipAttributes...)
+		return &AWSClient{cognito: api, userPoolID: poolID, mode: detectUsernames}
+	})
+}
+
+func TestAWSClient_Usernames(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pools signing in with the email ignore the chosen username", func(t *testing.T) {
+		api := fake.New()
+		client := &AWSClient{cognito: api, userPoolID: api.CreateUserPool("test"), mode: detectUsernames}
+
+		created, err := client.CreateUser(ctx, &userpool.User{Username: "jane", Email: "jane@example.com", Enabled: true})
+		require.NoError(t, err)
+		assert.Equal(t, created.Sub, created.Username, "Cognito generates the username, which is the sub")
+		assert.Equal(t, emailUsernames, client.mode)
+	})
+
+	t.Run("pools signing in with the phone number", func(t *testing.T) {
+		api := fake.New()
+		poolID := api.CreateUserPoolWithUsernameAttributes("test",
+			[]types.UsernameAttributeType{types.UsernameAttributeTypePhoneNumber})
+		client := &AWSClient{cognito: api, userPoolID: poolID, mode: detectUsernames}
+
+		_, err := client.CreateUser(ctx, &userpool.User{Username: "jane", Email: "jane@example.com", Enabled: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "signs users in with their phone number")
+
+		created, err := client.CreateUser(ctx, &userpool.User{
+			Username:   "jane",
+			Email:      "jane@example.com",
+			Enabled:    true,
+			Attributes: map[string]string{phoneNumberAttribute: "+15555550100"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, created.Sub, created.Username)
+
+		byEmail, err := client.GetUser(ctx, "jane@example.com")
+		require.NoError(t, err, "emails are looked up as they are no username")
+		assert.Equal(t, created.Sub, byEmail.Sub)
+	})
+
+	t.Run("pools keeping the chosen username", func(t *testing.T) {
+		api := fake.New()
+		poolID := api.CreateUserPoolWithUsernameAttributes("test", nil)
+		client := &AWSClient{cognito: api, userPoolID: poolID, mode: detectUsernames}
+
+		created, err := client.CreateUser(ctx, &userpool.User{
+			Username: "root:org:team.jane",
+			Email:    "jane@example.com",
+			Enabled:  true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "root:org:team.jane", created.Username)
+		assert.NotEqual(t, created.Username, created.Sub, "the sub is read from its attribute")
+		assert.Equal(t, ownUsernames, client.mode)
+
+		bySub, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "root:org:team.jane", bySub.Username)
+		byEmail, err := client.GetUser(ctx, "jane@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, created.Sub, byEmail.Sub)
+
+		require.NoError(t, client.UpdateUser(ctx, &userpool.User{
+			Sub:        created.Sub,
+			Email:      "jane.doe@example.com",
+			Attributes: map[string]string{"given_name": "Jane"},
+		}))
+		updated, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", updated.Email)
+		assert.False(t, updated.Enabled)
+
+		require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+		require.NoError(t, client.AddUserToGroup(ctx, created.Sub, "developers"))
+		groups, err := client.ListGroupsForUser(ctx, "jane.doe@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"developers"}, groups)
+
+		require.NoError(t, client.DeleteUser(ctx, created.Sub))
+		_, err = client.GetUser(ctx, created.Sub)
+		assert.ErrorIs(t, err, userpool.ErrUserNotFound)
+	})
+
+	t.Run("emails used by several users", func(t *testing.T) {
+		api := fake.New()
+		poolID := api.CreateUserPoolWithUsernameAttributes("test", nil)
+		client := &AWSClient{cognito: api, userPoolID: poolID, mode: detectUsernames}
+		for _, username := range []string{"jane", "jane.doe"} {
+			_, err := client.CreateUser(ctx, &userpool.User{Username: username, Email: "jane@example.com", Enabled: true})
+			require.NoError(t, err)
+		}
+
+		_, err := client.GetUser(ctx, "jane@example.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "used by several users")
+	})
+
+	t.Run("user pool cannot be described", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		mockAPI.On("DescribeUserPool", mock.Anything, mock.Anything).Return(nil, errors.New("access denied")).Once()
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id", mode: detectUsernames}
+
+		err := client.DisableUser(ctx, "jane@example.com")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "access denied")
+		assert.Equal(t, detectUsernames, client.mode, "the pool is described again on the next call")
+	})
+
+	t.Run("usernames are cached while the pool is described", func(t *testing.T) {
+		mockAPI := mocks.NewMockCognitoAPI(t)
+		client := &AWSClient{cognito: mockAPI, userPoolID: "test-pool-id", mode: detectUsernames}
+		mockAPI.On("DescribeUserPool", mock.Anything, mock.Anything).
+			Run(func(mock.Arguments) {
+				// Deadlocks if the client is locked during the call
+				client.rememberUsername("jane-sub", "jane")
+			}).
+			Return(&cognitoidentityprovider.DescribeUserPoolOutput{UserPool: &types.UserPoolType{}}, nil).Once()
+
+		mode, err := client.usernameMode(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, ownUsernames, mode)
+		assert.Equal(t, ownUsernames, client.mode, "the pool is only described once")
+		assert.Equal(t, "jane", client.usernames["jane-sub"])
+	})
+}
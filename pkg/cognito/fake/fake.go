@@ -0,0 +1,833 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides a stateful in-memory fake of the Cognito user pool API. It implements
+// cognito.CognitoAPI for tests and serves the Cognito JSON protocol over HTTP, so the controller
+// can be pointed at it with --cognito-endpoint.
+//
+// The fake models user pools that sign users in with their email, where Cognito generates the
+// usernames, which are the subs, and accepts the emails in their place, as well as user pools
+// that keep the username given at creation.
+package fake
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/google/uuid"
+)
+
+// Region is the region of the user pool IDs generated by the fake
+const Region = "us-east-1"
+
+// maxResults is the page size limit Cognito enforces on list operations
+const maxResults = 60
+
+// standardAttributes are the attributes of every Cognito user pool schema
+var standardAttributes = []string{
+	"address", "birthdate", "email", "email_verified", "family_name", "gender", "given_name",
+	"identities", "locale", "middle_name", "name", "nickname", "phone_number", "phone_number_verified",
+	"picture", "preferred_username", "profile", "sub", "updated_at", "website", "zoneinfo",
+}
+
+// Cognito is an in-memory fake of the Cognito user pool API. It is safe for concurrent use.
+type Cognito struct {
+	mu        sync.Mutex
+	pools     map[string]*userPool
+	poolOrder []string
+	throttles map[string]int
+	now       func() time.Time
+}
+
+// userPool is the state of a single user pool
+type userPool struct {
+	id                 string
+	name               string
+	usernameAttributes []types.UsernameAttributeType
+	schema             map[string]bool
+	users              []*user
+	groups             map[string]*types.GroupType
+}
+
+// user is a user of a user pool
+type user struct {
+	username    string
+	sub         string
+	attributes  map[string]string
+	enabled     bool
+	status      types.UserStatusType
+	password    string
+	groups      map[string]bool
+	invitations int
+	created     time.Time
+	modified    time.Time
+}
+
+// New creates a fake without user pools
+func New() *Cognito {
+	return &Cognito{
+		pools:     map[string]*userPool{},
+		throttles: map[string]int{},
+		now:       time.Now,
+	}
+}
+
+// SetClock replaces the clock used for creation and modification dates
+func (c *Cognito) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// CreateUserPool creates a user pool signing users in with their email and returns its ID. Custom
+// attributes are declared with or without their custom: prefix.
+func (c *Cognito) CreateUserPool(name string, customAttributes ...string) string {
+	return c.CreateUserPoolWithUsernameAttributes(name,
+		[]types.UsernameAttributeType{types.UsernameAttributeTypeEmail}, customAttributes...)
+}
+
+// CreateUserPoolWithUsernameAttributes creates a user pool signing users in with the given
+// attributes and returns its ID. Without username attributes, users keep the username given at
+// creation.
+func (c *Cognito) CreateUserPoolWithUsernameAttributes(name string,
+	usernameAttributes []types.UsernameAttributeType, customAttributes ...string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pool := &userPool{
+		id:                 fmt.Sprintf("%s_fake%05d", Region, len(c.poolOrder)+1),
+		name:               name,
+		usernameAttributes: usernameAttributes,
+		schema:             map[string]bool{},
+		groups:             map[string]*types.GroupType{},
+	}
+	for _, attribute := range standardAttributes {
+		pool.schema[attribute] = true
+	}
+	for _, attribute := range customAttributes {
+		pool.schema["custom:"+strings.TrimPrefix(attribute, "custom:")] = true
+	}
+	c.pools[pool.id] = pool
+	c.poolOrder = append(c.poolOrder, pool.id)
+	return pool.id
+}
+
+// Throttle fails the next count calls of the operation, e.g. AdminGetUser, with
+// TooManyRequestsException
+func (c *Cognito) Throttle(operation string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.throttles[operation] = count
+}
+
+// CompletePasswordChange replaces the temporary password of a user like a first sign-in does
+func (c *Cognito) CompletePasswordChange(userPoolID, username, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	u, err := c.findUser(userPoolID, username)
+	if err != nil {
+		return err
+	}
+	if u.status != types.UserStatusTypeForceChangePassword {
+		return &types.NotAuthorizedException{Message: aws.String("User is not in FORCE_CHANGE_PASSWORD state.")}
+	}
+	u.password = password
+	u.status = types.UserStatusTypeConfirmed
+	u.modified = c.now()
+	return nil
+}
+
+// Invitations returns the number of invitation messages sent to a user
+func (c *Cognito) Invitations(userPoolID, username string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	u, err := c.findUser(userPoolID, username)
+	if err != nil {
+		return 0
+	}
+	return u.invitations
+}
+
+// AdminCreateUser creates a user, or resends its invitation with MessageAction RESEND
+func (c *Cognito) AdminCreateUser(ctx context.Context, params *cip.AdminCreateUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminCreateUserOutput, error) {
+	pool, err := c.begin(ctx, "AdminCreateUser", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	username := aws.ToString(params.Username)
+	if username == "" {
+		return nil, invalidParameter("Username is required.")
+	}
+
+	if params.MessageAction == types.MessageActionTypeResend {
+		u, err := pool.find(username)
+		if err != nil {
+			return nil, err
+		}
+		if u.status != types.UserStatusTypeForceChangePassword {
+			return nil, &types.UnsupportedUserStateException{Message: aws.String("Resend not possible. " +
+				u.username + " status is not FORCE_CHANGE_PASSWORD.")}
+		}
+		u.invitations++
+		return &cip.AdminCreateUserOutput{User: u.userType()}, nil
+	}
+
+	attributes := fromAttributeTypes(params.UserAttributes)
+	if len(pool.usernameAttributes) > 0 {
+		// The username is the value of a username attribute, and Cognito generates the actual one
+		signIn := pool.signInAttribute(username)
+		if _, ok := attributes[signIn]; !ok {
+			attributes[signIn] = username
+		}
+		if !strings.EqualFold(attributes[signIn], username) {
+			return nil, invalidParameter("Username should be either an email or a phone number.")
+		}
+	}
+	if err := pool.checkAttributes(attributes); err != nil {
+		return nil, err
+	}
+	if _, err := pool.find(username); err == nil {
+		return nil, &types.UsernameExistsException{Message: aws.String("An account with the given email already exists.")}
+	}
+
+	now := c.now()
+	u := &user{
+		username:   username,
+		sub:        uuid.NewString(),
+		attributes: attributes,
+		enabled:    true,
+		status:     types.UserStatusTypeForceChangePassword,
+		password:   aws.ToString(params.TemporaryPassword),
+		groups:     map[string]bool{},
+		created:    now,
+		modified:   now,
+	}
+	u.attributes["sub"] = u.sub
+	if len(pool.usernameAttributes) > 0 {
+		u.username = u.sub
+	}
+	if params.MessageAction != types.MessageActionTypeSuppress {
+		u.invitations++
+	}
+	pool.users = append(pool.users, u)
+	return &cip.AdminCreateUserOutput{User: u.userType()}, nil
+}
+
+// AdminGetUser returns a user
+func (c *Cognito) AdminGetUser(ctx context.Context, params *cip.AdminGetUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminGetUserOutput, error) {
+	pool, err := c.begin(ctx, "AdminGetUser", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	return &cip.AdminGetUserOutput{
+		Username:             aws.String(u.username),
+		UserAttributes:       toAttributeTypes(u.attributes),
+		Enabled:              u.enabled,
+		UserStatus:           u.status,
+		UserCreateDate:       aws.Time(u.created),
+		UserLastModifiedDate: aws.Time(u.modified),
+	}, nil
+}
+
+// AdminUpdateUserAttributes sets attributes of a user, keeping the others
+func (c *Cognito) AdminUpdateUserAttributes(ctx context.Context, params *cip.AdminUpdateUserAttributesInput,
+	_ ...func(*cip.Options)) (*cip.AdminUpdateUserAttributesOutput, error) {
+	pool, err := c.begin(ctx, "AdminUpdateUserAttributes", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	attributes := fromAttributeTypes(params.UserAttributes)
+	if err := pool.checkAttributes(attributes); err != nil {
+		return nil, err
+	}
+	if email, ok := attributes["email"]; ok {
+		if other, err := pool.find(email); err == nil && other != u {
+			return nil, &types.AliasExistsException{Message: aws.String("An account with the given email already exists.")}
+		}
+	}
+
+	for name, value := range attributes {
+		u.attributes[name] = value
+	}
+	u.modified = c.now()
+	return &cip.AdminUpdateUserAttributesOutput{}, nil
+}
+
+// AdminDeleteUserAttributes removes attributes from a user
+func (c *Cognito) AdminDeleteUserAttributes(ctx context.Context, params *cip.AdminDeleteUserAttributesInput,
+	_ ...func(*cip.Options)) (*cip.AdminDeleteUserAttributesOutput, error) {
+	pool, err := c.begin(ctx, "AdminDeleteUserAttributes", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range params.UserAttributeNames {
+		if !pool.schema[name] {
+			return nil, invalidParameter("Attribute " + name + " does not exist in the schema.")
+		}
+		if name == "sub" || name == "email" {
+			return nil, invalidParameter("Cannot delete the required attribute " + name + ".")
+		}
+	}
+	for _, name := range params.UserAttributeNames {
+		delete(u.attributes, name)
+	}
+	u.modified = c.now()
+	return &cip.AdminDeleteUserAttributesOutput{}, nil
+}
+
+// AdminSetUserPassword sets the password of a user, which has to be changed at next sign-in
+// unless it is permanent
+func (c *Cognito) AdminSetUserPassword(ctx context.Context, params *cip.AdminSetUserPasswordInput,
+	_ ...func(*cip.Options)) (*cip.AdminSetUserPasswordOutput, error) {
+	pool, err := c.begin(ctx, "AdminSetUserPassword", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	if aws.ToString(params.Password) == "" {
+		return nil, &types.InvalidPasswordException{Message: aws.String("Password does not conform to policy.")}
+	}
+	u.password = aws.ToString(params.Password)
+	u.status = types.UserStatusTypeForceChangePassword
+	if params.Permanent {
+		u.status = types.UserStatusTypeConfirmed
+	}
+	u.modified = c.now()
+	return &cip.AdminSetUserPasswordOutput{}, nil
+}
+
+// AdminEnableUser enables a user
+func (c *Cognito) AdminEnableUser(ctx context.Context, params *cip.AdminEnableUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminEnableUserOutput, error) {
+	if err := c.setEnabled(ctx, "AdminEnableUser", params.UserPoolId, params.Username, true); err != nil {
+		return nil, err
+	}
+	return &cip.AdminEnableUserOutput{}, nil
+}
+
+// AdminDisableUser disables a user
+func (c *Cognito) AdminDisableUser(ctx context.Context, params *cip.AdminDisableUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminDisableUserOutput, error) {
+	if err := c.setEnabled(ctx, "AdminDisableUser", params.UserPoolId, params.Username, false); err != nil {
+		return nil, err
+	}
+	return &cip.AdminDisableUserOutput{}, nil
+}
+
+// AdminDeleteUser deletes a user
+func (c *Cognito) AdminDeleteUser(ctx context.Context, params *cip.AdminDeleteUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminDeleteUserOutput, error) {
+	pool, err := c.begin(ctx, "AdminDeleteUser", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	pool.users = slices.DeleteFunc(pool.users, func(other *user) bool { return other == u })
+	return &cip.AdminDeleteUserOutput{}, nil
+}
+
+// ListUsers lists the users of a user pool in creation order. Filters of the form
+// attribute = "value" and attribute ^= "prefix" are supported.
+func (c *Cognito) ListUsers(ctx context.Context, params *cip.ListUsersInput,
+	_ ...func(*cip.Options)) (*cip.ListUsersOutput, error) {
+	pool, err := c.begin(ctx, "ListUsers", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	match, err := parseFilter(aws.ToString(params.Filter))
+	if err != nil {
+		return nil, err
+	}
+	var users []*user
+	for _, u := range pool.users {
+		if match(u) {
+			users = append(users, u)
+		}
+	}
+
+	page, next, err := paginate(users, params.PaginationToken, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	output := &cip.ListUsersOutput{Users: []types.UserType{}, PaginationToken: next}
+	for _, u := range page {
+		output.Users = append(output.Users, *u.userType())
+	}
+	return output, nil
+}
+
+// DescribeUserPool returns a user pool with its schema
+func (c *Cognito) DescribeUserPool(ctx context.Context, params *cip.DescribeUserPoolInput,
+	_ ...func(*cip.Options)) (*cip.DescribeUserPoolOutput, error) {
+	pool, err := c.begin(ctx, "DescribeUserPool", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	output := &cip.DescribeUserPoolOutput{UserPool: &types.UserPoolType{
+		Id:                     aws.String(pool.id),
+		Name:                   aws.String(pool.name),
+		UsernameAttributes:     pool.usernameAttributes,
+		EstimatedNumberOfUsers: int32(len(pool.users)),
+	}}
+	for _, name := range slices.Sorted(maps.Keys(pool.schema)) {
+		output.UserPool.SchemaAttributes = append(output.UserPool.SchemaAttributes,
+			types.SchemaAttributeType{Name: aws.String(name), Mutable: aws.Bool(name != "sub")})
+	}
+	return output, nil
+}
+
+// ListUserPools lists the user pools in creation order
+func (c *Cognito) ListUserPools(ctx context.Context, params *cip.ListUserPoolsInput,
+	_ ...func(*cip.Options)) (*cip.ListUserPoolsOutput, error) {
+	if _, err := c.begin(ctx, "ListUserPools", nil); err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	if params.MaxResults == nil {
+		return nil, invalidParameter("MaxResults is required.")
+	}
+	pools := make([]*userPool, 0, len(c.poolOrder))
+	for _, id := range c.poolOrder {
+		pools = append(pools, c.pools[id])
+	}
+	page, next, err := paginate(pools, params.NextToken, params.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	output := &cip.ListUserPoolsOutput{UserPools: []types.UserPoolDescriptionType{}, NextToken: next}
+	for _, pool := range page {
+		output.UserPools = append(output.UserPools, types.UserPoolDescriptionType{
+			Id:   aws.String(pool.id),
+			Name: aws.String(pool.name),
+		})
+	}
+	return output, nil
+}
+
+// GetGroup returns a group
+func (c *Cognito) GetGroup(ctx context.Context, params *cip.GetGroupInput,
+	_ ...func(*cip.Options)) (*cip.GetGroupOutput, error) {
+	pool, err := c.begin(ctx, "GetGroup", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	group, err := pool.group(aws.ToString(params.GroupName))
+	if err != nil {
+		return nil, err
+	}
+	copied := *group
+	return &cip.GetGroupOutput{Group: &copied}, nil
+}
+
+// CreateGroup creates a group
+func (c *Cognito) CreateGroup(ctx context.Context, params *cip.CreateGroupInput,
+	_ ...func(*cip.Options)) (*cip.CreateGroupOutput, error) {
+	pool, err := c.begin(ctx, "CreateGroup", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	name := aws.ToString(params.GroupName)
+	if name == "" {
+		return nil, invalidParameter("GroupName is required.")
+	}
+	if _, ok := pool.groups[name]; ok {
+		return nil, &types.GroupExistsException{Message: aws.String("A group with the name " + name + " already exists.")}
+	}
+	now := c.now()
+	group := &types.GroupType{
+		GroupName:        aws.String(name),
+		UserPoolId:       aws.String(pool.id),
+		Description:      params.Description,
+		Precedence:       params.Precedence,
+		CreationDate:     aws.Time(now),
+		LastModifiedDate: aws.Time(now),
+	}
+	pool.groups[name] = group
+	copied := *group
+	return &cip.CreateGroupOutput{Group: &copied}, nil
+}
+
+// UpdateGroup updates the description and precedence of a group
+func (c *Cognito) UpdateGroup(ctx context.Context, params *cip.UpdateGroupInput,
+	_ ...func(*cip.Options)) (*cip.UpdateGroupOutput, error) {
+	pool, err := c.begin(ctx, "UpdateGroup", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	group, err := pool.group(aws.ToString(params.GroupName))
+	if err != nil {
+		return nil, err
+	}
+	group.Description = params.Description
+	group.Precedence = params.Precedence
+	group.LastModifiedDate = aws.Time(c.now())
+	copied := *group
+	return &cip.UpdateGroupOutput{Group: &copied}, nil
+}
+
+// DeleteGroup deletes a group along with its memberships
+func (c *Cognito) DeleteGroup(ctx context.Context, params *cip.DeleteGroupInput,
+	_ ...func(*cip.Options)) (*cip.DeleteGroupOutput, error) {
+	pool, err := c.begin(ctx, "DeleteGroup", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	name := aws.ToString(params.GroupName)
+	if _, err := pool.group(name); err != nil {
+		return nil, err
+	}
+	delete(pool.groups, name)
+	for _, u := range pool.users {
+		delete(u.groups, name)
+	}
+	return &cip.DeleteGroupOutput{}, nil
+}
+
+// AdminAddUserToGroup adds a user to a group
+func (c *Cognito) AdminAddUserToGroup(ctx context.Context, params *cip.AdminAddUserToGroupInput,
+	_ ...func(*cip.Options)) (*cip.AdminAddUserToGroupOutput, error) {
+	err := c.setMembership(ctx, "AdminAddUserToGroup", params.UserPoolId, params.Username, params.GroupName, true)
+	if err != nil {
+		return nil, err
+	}
+	return &cip.AdminAddUserToGroupOutput{}, nil
+}
+
+// AdminRemoveUserFromGroup removes a user from a group
+func (c *Cognito) AdminRemoveUserFromGroup(ctx context.Context, params *cip.AdminRemoveUserFromGroupInput,
+	_ ...func(*cip.Options)) (*cip.AdminRemoveUserFromGroupOutput, error) {
+	err := c.setMembership(ctx, "AdminRemoveUserFromGroup", params.UserPoolId, params.Username, params.GroupName, false)
+	if err != nil {
+		return nil, err
+	}
+	return &cip.AdminRemoveUserFromGroupOutput{}, nil
+}
+
+// AdminListGroupsForUser lists the groups of a user ordered by name
+func (c *Cognito) AdminListGroupsForUser(ctx context.Context, params *cip.AdminListGroupsForUserInput,
+	_ ...func(*cip.Options)) (*cip.AdminListGroupsForUserOutput, error) {
+	pool, err := c.begin(ctx, "AdminListGroupsForUser", params.UserPoolId)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(params.Username))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(u.groups))
+	for name := range u.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	page, next, err := paginate(names, params.NextToken, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+	output := &cip.AdminListGroupsForUserOutput{Groups: []types.GroupType{}, NextToken: next}
+	for _, name := range page {
+		output.Groups = append(output.Groups, *pool.groups[name])
+	}
+	return output, nil
+}
+
+// begin locks the fake for an API call and returns the addressed user pool. Unless it returns an
+// error, the caller must unlock c.mu.
+func (c *Cognito) begin(ctx context.Context, operation string, userPoolID *string) (*userPool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.throttles[operation] > 0 {
+		c.throttles[operation]--
+		c.mu.Unlock()
+		return nil, &types.TooManyRequestsException{Message: aws.String("Rate exceeded")}
+	}
+	if userPoolID == nil {
+		return nil, nil
+	}
+	pool, ok := c.pools[aws.ToString(userPoolID)]
+	if !ok {
+		c.mu.Unlock()
+		return nil, &types.ResourceNotFoundException{
+			Message: aws.String("User pool " + aws.ToString(userPoolID) + " does not exist."),
+		}
+	}
+	return pool, nil
+}
+
+// findUser returns a user of a user pool. Callers must hold the lock.
+func (c *Cognito) findUser(userPoolID, username string) (*user, error) {
+	pool, ok := c.pools[userPoolID]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("User pool " + userPoolID + " does not exist.")}
+	}
+	return pool.find(username)
+}
+
+// setEnabled enables or disables a user
+func (c *Cognito) setEnabled(ctx context.Context, operation string, userPoolID, username *string,
+	enabled bool) error {
+	pool, err := c.begin(ctx, operation, userPoolID)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(username))
+	if err != nil {
+		return err
+	}
+	u.enabled = enabled
+	u.modified = c.now()
+	return nil
+}
+
+// setMembership adds a user to or removes it from a group
+func (c *Cognito) setMembership(ctx context.Context, operation string, userPoolID, username, group *string,
+	member bool) error {
+	pool, err := c.begin(ctx, operation, userPoolID)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	u, err := pool.find(aws.ToString(username))
+	if err != nil {
+		return err
+	}
+	if _, err := pool.group(aws.ToString(group)); err != nil {
+		return err
+	}
+	if member {
+		u.groups[aws.ToString(group)] = true
+	} else {
+		delete(u.groups, aws.ToString(group))
+	}
+	return nil
+}
+
+// find returns the user with the given username, or the given value of a username attribute
+func (p *userPool) find(username string) (*user, error) {
+	for _, u := range p.users {
+		if strings.EqualFold(u.username, username) {
+			return u, nil
+		}
+		for _, attribute := range p.usernameAttributes {
+			if value := u.attributes[string(attribute)]; value != "" && strings.EqualFold(value, username) {
+				return u, nil
+			}
+		}
+	}
+	return nil, &types.UserNotFoundException{Message: aws.String("User does not exist.")}
+}
+
+// signInAttribute returns the username attribute a username given at creation is the value of
+func (p *userPool) signInAttribute(username string) string {
+	if strings.HasPrefix(username, "+") && slices.Contains(p.usernameAttributes, types.UsernameAttributeTypePhoneNumber) {
+		return string(types.UsernameAttributeTypePhoneNumber)
+	}
+	return string(p.usernameAttributes[0])
+}
+
+// group returns the group with the given name
+func (p *userPool) group(name string) (*types.GroupType, error) {
+	group, ok := p.groups[name]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Group not found.")}
+	}
+	return group, nil
+}
+
+// checkAttributes rejects attributes that are not declared in the schema or cannot be written
+func (p *userPool) checkAttributes(attributes map[string]string) error {
+	for name := range attributes {
+		if name == "sub" {
+			return invalidParameter("Cannot modify the non-mutable attribute sub.")
+		}
+		if !p.schema[name] {
+			return invalidParameter("Attributes did not conform to the schema: " + name +
+				": Attribute does not exist in the schema.")
+		}
+	}
+	return nil
+}
+
+// userType returns the API representation of a user
+func (u *user) userType() *types.UserType {
+	return &types.UserType{
+		Username:             aws.String(u.username),
+		Attributes:           toAttributeTypes(u.attributes),
+		Enabled:              u.enabled,
+		UserStatus:           u.status,
+		UserCreateDate:       aws.Time(u.created),
+		UserLastModifiedDate: aws.Time(u.modified),
+	}
+}
+
+// filterValue returns the value ListUsers filters compare for an attribute, which may be the username
+func (u *user) filterValue(name string) string {
+	if name == "username" {
+		return u.username
+	}
+	return u.attributes[name]
+}
+
+// parseFilter parses a ListUsers filter into a predicate
+func parseFilter(filter string) (func(*user) bool, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(*user) bool { return true }, nil
+	}
+	for _, operator := range []string{"^=", "="} {
+		name, quoted, ok := strings.Cut(filter, operator)
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		value, err := strconv.Unquote(strings.TrimSpace(quoted))
+		if err != nil {
+			return nil, invalidParameter("Invalid filter " + filter)
+		}
+		if operator == "^=" {
+			return func(u *user) bool { return strings.HasPrefix(u.filterValue(name), value) }, nil
+		}
+		return func(u *user) bool { return u.filterValue(name) == value }, nil
+	}
+	return nil, invalidParameter("Invalid filter " + filter)
+}
+
+// paginate returns the page of items starting at the offset encoded in token and the token of the
+// next page
+func paginate[T any](items []T, token *string, limit *int32) ([]T, *string, error) {
+	size := maxResults
+	if limit != nil {
+		if *limit < 0 || *limit > maxResults {
+			return nil, nil, invalidParameter(fmt.Sprintf("Limit must be between 0 and %d.", maxResults))
+		}
+		if *limit > 0 {
+			size = int(*limit)
+		}
+	}
+
+	offset := 0
+	if token != nil {
+		decoded, err := base64.StdEncoding.DecodeString(*token)
+		if err == nil {
+			offset, err = strconv.Atoi(string(decoded))
+		}
+		if err != nil || offset < 0 {
+			return nil, nil, invalidParameter("Invalid pagination token.")
+		}
+	}
+
+	start := min(offset, len(items))
+	end := min(start+size, len(items))
+	var next *string
+	if end < len(items) {
+		next = aws.String(base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(end))))
+	}
+	return items[start:end], next, nil
+}
+
+// fromAttributeTypes converts API attributes into a map
+func fromAttributeTypes(attributes []types.AttributeType) map[string]string {
+	result := make(map[string]string, len(attributes))
+	for _, attribute := range attributes {
+		result[aws.ToString(attribute.Name)] = aws.ToString(attribute.Value)
+	}
+	return result
+}
+
+// toAttributeTypes converts an attribute map into API attributes sorted by name
+func toAttributeTypes(attributes map[string]string) []types.AttributeType {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]types.AttributeType, 0, len(names))
+	for _, name := range names {
+		result = append(result, types.AttributeType{Name: aws.String(name), Value: aws.String(attributes[name])})
+	}
+	return result
+}
+
+// invalidParameter returns an InvalidParameterException with the message
+func invalidParameter(message string) error {
+	return &types.InvalidParameterException{Message: aws.String(message)}
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/cognito"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/conformance"
+)
+
+// newServer serves the fake over HTTP
+func newServer(t *testing.T, api *Cognito) string {
+	server := httptest.NewServer(api.Handler())
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// newSDKClient returns a Cognito SDK client talking to the fake over HTTP without retries
+func newSDKClient(t *testing.T, api *Cognito) *cip.Client {
+	return cip.New(cip.Options{
+		Region:           Region,
+		BaseEndpoint:     aws.String(newServer(t, api)),
+		Credentials:      credentials.NewStaticCredentialsProvider("test", "test", ""),
+		RetryMaxAttempts: 1,
+	})
+}
+
+func createUser(t *testing.T, api *Cognito, poolID, email string) string {
+	t.Helper()
+	output, err := api.AdminCreateUser(context.Background(), &cip.AdminCreateUserInput{
+		UserPoolId:    aws.String(poolID),
+		Username:      aws.String(email),
+		MessageAction: types.MessageActionTypeSuppress,
+	})
+	require.NoError(t, err)
+	return aws.ToString(output.User.Username)
+}
+
+func TestCognito_Users(t *testing.T) {
+	ctx := context.Background()
+	api := New()
+	poolID := api.CreateUserPool("tenants", "department")
+
+	output, err := api.AdminCreateUser(ctx, &cip.AdminCreateUserInput{
+		UserPoolId:             aws.String(poolID),
+		Username:               aws.String("jane@example.com"),
+		UserAttributes:         []types.AttributeType{{Name: aws.String("custom:department"), Value: aws.String("R&D")}},
+		DesiredDeliveryMediums: []types.DeliveryMediumType{types.DeliveryMediumTypeEmail},
+	})
+	require.NoError(t, err)
+	sub := aws.ToString(output.User.Username)
+	assert.NotEqual(t, "jane@example.com", sub, "the sub is returned as username")
+	assert.True(t, output.User.Enabled)
+	assert.Equal(t, types.UserStatusTypeForceChangePassword, output.User.UserStatus)
+	assert.Equal(t, 1, api.Invitations(poolID, sub))
+
+	t.Run("existing email", func(t *testing.T) {
+		_, err := api.AdminCreateUser(ctx, &cip.AdminCreateUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("JANE@example.com"),
+		})
+		var exists *types.UsernameExistsException
+		assert.ErrorAs(t, err, &exists)
+	})
+
+	t.Run("undeclared attribute", func(t *testing.T) {
+		_, err := api.AdminUpdateUserAttributes(ctx, &cip.AdminUpdateUserAttributesInput{
+			UserPoolId:     aws.String(poolID),
+			Username:       aws.String(sub),
+			UserAttributes: []types.AttributeType{{Name: aws.String("custom:team"), Value: aws.String("a")}},
+		})
+		var invalid *types.InvalidParameterException
+		assert.ErrorAs(t, err, &invalid)
+	})
+
+	t.Run("get by email", func(t *testing.T) {
+		user, err := api.AdminGetUser(ctx, &cip.AdminGetUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("jane@example.com"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, sub, aws.ToString(user.Username))
+		assert.Contains(t, user.UserAttributes, types.AttributeType{Name: aws.String("sub"), Value: aws.String(sub)})
+	})
+
+	t.Run("resend requires a temporary password", func(t *testing.T) {
+		resend := &cip.AdminCreateUserInput{
+			UserPoolId:    aws.String(poolID),
+			Username:      aws.String(sub),
+			MessageAction: types.MessageActionTypeResend,
+		}
+		_, err := api.AdminCreateUser(ctx, resend)
+		require.NoError(t, err)
+		assert.Equal(t, 2, api.Invitations(poolID, sub))
+
+		require.NoError(t, api.CompletePasswordChange(poolID, sub, "Chosen#Passw0rd"))
+		_, err = api.AdminCreateUser(ctx, resend)
+		var unsupported *types.UnsupportedUserStateException
+		assert.ErrorAs(t, err, &unsupported)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		_, err := api.AdminDeleteUser(ctx, &cip.AdminDeleteUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("nobody@example.com"),
+		})
+		var notFound *types.UserNotFoundException
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	t.Run("missing user pool", func(t *testing.T) {
+		_, err := api.AdminGetUser(ctx, &cip.AdminGetUserInput{
+			UserPoolId: aws.String("us-east-1_missing"),
+			Username:   aws.String(sub),
+		})
+		var notFound *types.ResourceNotFoundException
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestCognito_OwnUsernames(t *testing.T) {
+	ctx := context.Background()
+	api := New()
+	poolID := api.CreateUserPoolWithUsernameAttributes("tenants", nil)
+
+	output, err := api.AdminCreateUser(ctx, &cip.AdminCreateUserInput{
+		UserPoolId:     aws.String(poolID),
+		Username:       aws.String("jane"),
+		UserAttributes: []types.AttributeType{{Name: aws.String("email"), Value: aws.String("jane@example.com")}},
+		MessageAction:  types.MessageActionTypeSuppress,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "jane", aws.ToString(output.User.Username), "the username given at creation is kept")
+
+	pool, err := api.DescribeUserPool(ctx, &cip.DescribeUserPoolInput{UserPoolId: aws.String(poolID)})
+	require.NoError(t, err)
+	assert.Empty(t, pool.UserPool.UsernameAttributes)
+
+	t.Run("existing username", func(t *testing.T) {
+		_, err := api.AdminCreateUser(ctx, &cip.AdminCreateUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("JANE"),
+		})
+		var exists *types.UsernameExistsException
+		assert.ErrorAs(t, err, &exists)
+	})
+
+	t.Run("email is no username", func(t *testing.T) {
+		_, err := api.AdminGetUser(ctx, &cip.AdminGetUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("jane@example.com"),
+		})
+		var notFound *types.UserNotFoundException
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	t.Run("filter by username", func(t *testing.T) {
+		users, err := api.ListUsers(ctx, &cip.ListUsersInput{
+			UserPoolId: aws.String(poolID),
+			Filter:     aws.String(`username = "jane"`),
+		})
+		require.NoError(t, err)
+		require.Len(t, users.Users, 1)
+		assert.Equal(t, "jane", aws.ToString(users.Users[0].Username))
+	})
+}
+
+func TestCognito_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	api := New()
+	poolID := api.CreateUserPool("tenants")
+	for i := range 130 {
+		createUser(t, api, poolID, fmt.Sprintf("user-%03d@example.com", i))
+	}
+
+	t.Run("pagination", func(t *testing.T) {
+		var emails []string
+		var token *string
+		pages := 0
+		for {
+			output, err := api.ListUsers(ctx, &cip.ListUsersInput{
+				UserPoolId:      aws.String(poolID),
+				Limit:           aws.Int32(50),
+				PaginationToken: token,
+			})
+			require.NoError(t, err)
+			pages++
+			for _, user := range output.Users {
+				for _, attribute := range user.Attributes {
+					if aws.ToString(attribute.Name) == "email" {
+						emails = append(emails, aws.ToString(attribute.Value))
+					}
+				}
+			}
+			if token = output.PaginationToken; token == nil {
+				break
+			}
+		}
+		assert.Equal(t, 3, pages)
+		require.Len(t, emails, 130)
+		assert.Equal(t, "user-000@example.com", emails[0])
+		assert.Equal(t, "user-129@example.com", emails[129])
+	})
+
+	t.Run("limit above maximum", func(t *testing.T) {
+		_, err := api.ListUsers(ctx, &cip.ListUsersInput{UserPoolId: aws.String(poolID), Limit: aws.Int32(61)})
+		var invalid *types.InvalidParameterException
+		assert.ErrorAs(t, err, &invalid)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		output, err := api.ListUsers(ctx, &cip.ListUsersInput{
+			UserPoolId: aws.String(poolID),
+			Filter:     aws.String(`email ^= "user-12"`),
+		})
+		require.NoError(t, err)
+		assert.Len(t, output.Users, 10)
+
+		output, err = api.ListUsers(ctx, &cip.ListUsersInput{
+			UserPoolId: aws.String(poolID),
+			Filter:     aws.String(`email = "user-042@example.com"`),
+		})
+		require.NoError(t, err)
+		assert.Len(t, output.Users, 1)
+	})
+}
+
+func TestCognito_ListUserPools(t *testing.T) {
+	api := New()
+	for i := range 3 {
+		api.CreateUserPool(fmt.Sprintf("pool-%d", i))
+	}
+
+	output, err := api.ListUserPools(context.Background(), &cip.ListUserPoolsInput{MaxResults: aws.Int32(2)})
+	require.NoError(t, err)
+	require.Len(t, output.UserPools, 2)
+	require.NotNil(t, output.NextToken)
+
+	output, err = api.ListUserPools(context.Background(), &cip.ListUserPoolsInput{
+		MaxResults: aws.Int32(2),
+		NextToken:  output.NextToken,
+	})
+	require.NoError(t, err)
+	require.Len(t, output.UserPools, 1)
+	assert.Equal(t, "pool-2", aws.ToString(output.UserPools[0].Name))
+	assert.Nil(t, output.NextToken)
+}
+
+func TestCognito_Throttle(t *testing.T) {
+	ctx := context.Background()
+	api := New()
+	poolID := api.CreateUserPool("tenants")
+	sub := createUser(t, api, poolID, "jane@example.com")
+	api.Throttle("AdminGetUser", 2)
+	input := &cip.AdminGetUserInput{UserPoolId: aws.String(poolID), Username: aws.String(sub)}
+
+	for range 2 {
+		_, err := api.AdminGetUser(ctx, input)
+		var throttled *types.TooManyRequestsException
+		assert.ErrorAs(t, err, &throttled)
+	}
+	_, err := api.AdminGetUser(ctx, input)
+	assert.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+	api := New()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	api.SetClock(func() time.Time { return created })
+	poolID := api.CreateUserPool("tenants")
+	client := newSDKClient(t, api)
+
+	output, err := client.AdminCreateUser(ctx, &cip.AdminCreateUserInput{
+		UserPoolId:    aws.String(poolID),
+		Username:      aws.String("jane@example.com"),
+		MessageAction: types.MessageActionTypeSuppress,
+	})
+	require.NoError(t, err)
+	sub := aws.ToString(output.User.Username)
+
+	user, err := client.AdminGetUser(ctx, &cip.AdminGetUserInput{
+		UserPoolId: aws.String(poolID),
+		Username:   aws.String("jane@example.com"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, sub, aws.ToString(user.Username))
+	assert.True(t, user.Enabled)
+	assert.Equal(t, types.UserStatusTypeForceChangePassword, user.UserStatus)
+	assert.Equal(t, created, aws.ToTime(user.UserCreateDate).UTC())
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := client.AdminGetUser(ctx, &cip.AdminGetUserInput{
+			UserPoolId: aws.String(poolID),
+			Username:   aws.String("nobody@example.com"),
+		})
+		var notFound *types.UserNotFoundException
+		assert.ErrorAs(t, err, &notFound)
+	})
+
+	t.Run("throttling", func(t *testing.T) {
+		api.Throttle("ListUsers", 1)
+
+		_, err := client.ListUsers(ctx, &cip.ListUsersInput{UserPoolId: aws.String(poolID)})
+
+		var throttled *types.TooManyRequestsException
+		assert.ErrorAs(t, err, &throttled)
+	})
+}
+
+func TestHandler_Conformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		api := New()
+		client, err := cognito.NewClientFromConfig(context.Background(), cognito.Config{
+			UserPoolID:      api.CreateUserPool("test", userpool.OwnershipAttributes...),
+			Region:          Region,
+			Endpoint:        newServer(t, api),
+			AccessKeyID:     "test",
+			SecretAccessKey: "test",
+		})
+		require.NoError(t, err)
+		return client
+	})
+}
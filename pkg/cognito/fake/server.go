@@ -0,0 +1,165 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	cip "github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/smithy-go"
+)
+
+// targetPrefix prefixes the operation name in the X-Amz-Target header
+const targetPrefix = "AWSCognitoIdentityProviderService."
+
+// contentType is the content type of the Cognito JSON protocol
+const contentType = "application/x-amz-json-1.1"
+
+// operation decodes the input of an API call, invokes it and returns its output
+type operation func(ctx context.Context, body []byte) (any, error)
+
+// Handler returns an HTTP handler serving the Cognito JSON protocol, for use as the endpoint of
+// a Cognito client. Requests are not authenticated.
+func (c *Cognito) Handler() http.Handler {
+	operations := map[string]operation{
+		"AdminAddUserToGroup":       newOperation(c.AdminAddUserToGroup),
+		"AdminCreateUser":           newOperation(c.AdminCreateUser),
+		"AdminDeleteUser":           newOperation(c.AdminDeleteUser),
+		"AdminDeleteUserAttributes": newOperation(c.AdminDeleteUserAttributes),
+		"AdminDisableUser":          newOperation(c.AdminDisableUser),
+		"AdminEnableUser":           newOperation(c.AdminEnableUser),
+		"AdminGetUser":              newOperation(c.AdminGetUser),
+		"AdminListGroupsForUser":    newOperation(c.AdminListGroupsForUser),
+		"AdminRemoveUserFromGroup":  newOperation(c.AdminRemoveUserFromGroup),
+		"AdminSetUserPassword":      newOperation(c.AdminSetUserPassword),
+		"AdminUpdateUserAttributes": newOperation(c.AdminUpdateUserAttributes),
+		"CreateGroup":               newOperation(c.CreateGroup),
+		"DeleteGroup":               newOperation(c.DeleteGroup),
+		"DescribeUserPool":          newOperation(c.DescribeUserPool),
+		"GetGroup":                  newOperation(c.GetGroup),
+		"ListUserPools":             newOperation(c.ListUserPools),
+		"ListUsers":                 newOperation(c.ListUsers),
+		"UpdateGroup":               newOperation(c.UpdateGroup),
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+		op, ok := operations[name]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "UnknownOperationException", "Unknown operation "+name)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "SerializationException", err.Error())
+			return
+		}
+
+		output, err := op(r.Context(), body)
+		var apiErr smithy.APIError
+		switch {
+		case errors.As(err, &apiErr):
+			writeError(w, http.StatusBadRequest, apiErr.ErrorCode(), apiErr.ErrorMessage())
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, "InternalErrorException", err.Error())
+		default:
+			writeJSON(w, http.StatusOK, encode(reflect.ValueOf(output)))
+		}
+	})
+}
+
+// newOperation adapts an API method to an operation
+func newOperation[In, Out any](call func(context.Context, *In, ...func(*cip.Options)) (*Out, error)) operation {
+	return func(ctx context.Context, body []byte) (any, error) {
+		input := new(In)
+		if err := json.Unmarshal(body, input); err != nil {
+			return nil, invalidParameter(err.Error())
+		}
+		return call(ctx, input)
+	}
+}
+
+// encode converts an API output into its JSON representation. Field names are kept, timestamps
+// become epoch seconds, and nil values and response metadata are dropped.
+func encode(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encode(v.Elem())
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return float64(t.UnixMilli()) / 1000
+		}
+		fields := map[string]any{}
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Name == "ResultMetadata" {
+				continue
+			}
+			if value := encode(v.Field(i)); value != nil {
+				fields[field.Name] = value
+			}
+		}
+		return fields
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		items := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			items = append(items, encode(v.Index(i)))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := map[string]any{}
+		for _, key := range v.MapKeys() {
+			entries[key.String()] = encode(v.MapIndex(key))
+		}
+		return entries
+	default:
+		return v.Interface()
+	}
+}
+
+// writeError writes an error in the format of the Cognito JSON protocol
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("X-Amzn-ErrorType", code)
+	writeJSON(w, status, map[string]string{"__type": code, "message": message})
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -25,18 +25,38 @@ import (
 // CognitoAPI defines the interface for Cognito API client operations
 // This allows us to mock the client for testing
 type CognitoAPI interface {
+	AdminAddUserToGroup(ctx context.Context, params *cognitoidentityprovider.AdminAddUserToGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error)
 	AdminCreateUser(ctx context.Context, params *cognitoidentityprovider.AdminCreateUserInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminCreateUserOutput, error)
 	AdminGetUser(ctx context.Context, params *cognitoidentityprovider.AdminGetUserInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminGetUserOutput, error)
+	AdminListGroupsForUser(ctx context.Context, params *cognitoidentityprovider.AdminListGroupsForUserInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error)
+	AdminRemoveUserFromGroup(ctx context.Context, params *cognitoidentityprovider.AdminRemoveUserFromGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error)
+	AdminSetUserPassword(ctx context.Context, params *cognitoidentityprovider.AdminSetUserPasswordInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserPasswordOutput, error)
 	AdminUpdateUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)
+	AdminDeleteUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserAttributesInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error)
 	AdminEnableUser(ctx context.Context, params *cognitoidentityprovider.AdminEnableUserInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminEnableUserOutput, error)
 	AdminDisableUser(ctx context.Context, params *cognitoidentityprovider.AdminDisableUserInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDisableUserOutput, error)
 	AdminDeleteUser(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserOutput, error)
+	GetGroup(ctx context.Context, params *cognitoidentityprovider.GetGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetGroupOutput, error)
+	CreateGroup(ctx context.Context, params *cognitoidentityprovider.CreateGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error)
+	UpdateGroup(ctx context.Context, params *cognitoidentityprovider.UpdateGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.UpdateGroupOutput, error)
+	DeleteGroup(ctx context.Context, params *cognitoidentityprovider.DeleteGroupInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error)
+	DescribeUserPool(ctx context.Context, params *cognitoidentityprovider.DescribeUserPoolInput,
+		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error)
 	ListUsers(ctx context.Context, params *cognitoidentityprovider.ListUsersInput,
 		optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)
 	ListUserPools(ctx context.Context, params *cognitoidentityprovider.ListUserPoolsInput,
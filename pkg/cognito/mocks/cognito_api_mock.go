@@ -22,6 +22,80 @@ func (_m *MockCognitoAPI) EXPECT() *MockCognitoAPI_Expecter {
 	return &MockCognitoAPI_Expecter{mock: &_m.Mock}
 }
 
+// AdminAddUserToGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminAddUserToGroup(ctx context.Context, params *cognitoidentityprovider.AdminAddUserToGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdminAddUserToGroup")
+	}
+
+	var r0 *cognitoidentityprovider.AdminAddUserToGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminAddUserToGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminAddUserToGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminAddUserToGroupOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminAddUserToGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminAddUserToGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_AdminAddUserToGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminAddUserToGroup'
+type MockCognitoAPI_AdminAddUserToGroup_Call struct {
+	*mock.Call
+}
+
+// AdminAddUserToGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.AdminAddUserToGroupInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) AdminAddUserToGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminAddUserToGroup_Call {
+	return &MockCognitoAPI_AdminAddUserToGroup_Call{Call: _e.mock.On("AdminAddUserToGroup",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_AdminAddUserToGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminAddUserToGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminAddUserToGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminAddUserToGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminAddUserToGroup_Call) Return(_a0 *cognitoidentityprovider.AdminAddUserToGroupOutput, _a1 error) *MockCognitoAPI_AdminAddUserToGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminAddUserToGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminAddUserToGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error)) *MockCognitoAPI_AdminAddUserToGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // AdminCreateUser provides a mock function with given fields: ctx, params, optFns
 func (_m *MockCognitoAPI) AdminCreateUser(ctx context.Context, params *cognitoidentityprovider.AdminCreateUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminCreateUserOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -170,6 +244,80 @@ func (_c *MockCognitoAPI_AdminDeleteUser_Call) RunAndReturn(run func(context.Con
 	return _c
 }
 
+// AdminDeleteUserAttributes provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminDeleteUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdminDeleteUserAttributes")
+	}
+
+	var r0 *cognitoidentityprovider.AdminDeleteUserAttributesOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminDeleteUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminDeleteUserAttributesInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminDeleteUserAttributesOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminDeleteUserAttributesOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminDeleteUserAttributesInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_AdminDeleteUserAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminDeleteUserAttributes'
+type MockCognitoAPI_AdminDeleteUserAttributes_Call struct {
+	*mock.Call
+}
+
+// AdminDeleteUserAttributes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.AdminDeleteUserAttributesInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) AdminDeleteUserAttributes(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminDeleteUserAttributes_Call {
+	return &MockCognitoAPI_AdminDeleteUserAttributes_Call{Call: _e.mock.On("AdminDeleteUserAttributes",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_AdminDeleteUserAttributes_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminDeleteUserAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminDeleteUserAttributesInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminDeleteUserAttributes_Call) Return(_a0 *cognitoidentityprovider.AdminDeleteUserAttributesOutput, _a1 error) *MockCognitoAPI_AdminDeleteUserAttributes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminDeleteUserAttributes_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminDeleteUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error)) *MockCognitoAPI_AdminDeleteUserAttributes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // AdminDisableUser provides a mock function with given fields: ctx, params, optFns
 func (_m *MockCognitoAPI) AdminDisableUser(ctx context.Context, params *cognitoidentityprovider.AdminDisableUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDisableUserOutput, error) {
 	_va := make([]interface{}, len(optFns))
@@ -392,8 +540,8 @@ func (_c *MockCognitoAPI_AdminGetUser_Call) RunAndReturn(run func(context.Contex
 	return _c
 }
 
-// AdminUpdateUserAttributes provides a mock function with given fields: ctx, params, optFns
-func (_m *MockCognitoAPI) AdminUpdateUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error) {
+// AdminListGroupsForUser provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminListGroupsForUser(ctx context.Context, params *cognitoidentityprovider.AdminListGroupsForUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error) {
 	_va := make([]interface{}, len(optFns))
 	for _i := range optFns {
 		_va[_i] = optFns[_i]
@@ -404,23 +552,23 @@ func (_m *MockCognitoAPI) AdminUpdateUserAttributes(ctx context.Context, params
 	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AdminUpdateUserAttributes")
+		panic("no return value specified for AdminListGroupsForUser")
 	}
 
-	var r0 *cognitoidentityprovider.AdminUpdateUserAttributesOutput
+	var r0 *cognitoidentityprovider.AdminListGroupsForUserOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminListGroupsForUserInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error)); ok {
 		return rf(ctx, params, optFns...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminUpdateUserAttributesOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminListGroupsForUserInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminListGroupsForUserOutput); ok {
 		r0 = rf(ctx, params, optFns...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*cognitoidentityprovider.AdminUpdateUserAttributesOutput)
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminListGroupsForUserOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminListGroupsForUserInput, ...func(*cognitoidentityprovider.Options)) error); ok {
 		r1 = rf(ctx, params, optFns...)
 	} else {
 		r1 = ret.Error(1)
@@ -429,21 +577,21 @@ func (_m *MockCognitoAPI) AdminUpdateUserAttributes(ctx context.Context, params
 	return r0, r1
 }
 
-// MockCognitoAPI_AdminUpdateUserAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminUpdateUserAttributes'
-type MockCognitoAPI_AdminUpdateUserAttributes_Call struct {
+// MockCognitoAPI_AdminListGroupsForUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminListGroupsForUser'
+type MockCognitoAPI_AdminListGroupsForUser_Call struct {
 	*mock.Call
 }
 
-// AdminUpdateUserAttributes is a helper method to define mock.On call
+// AdminListGroupsForUser is a helper method to define mock.On call
 //   - ctx context.Context
-//   - params *cognitoidentityprovider.AdminUpdateUserAttributesInput
+//   - params *cognitoidentityprovider.AdminListGroupsForUserInput
 //   - optFns ...func(*cognitoidentityprovider.Options)
-func (_e *MockCognitoAPI_Expecter) AdminUpdateUserAttributes(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
-	return &MockCognitoAPI_AdminUpdateUserAttributes_Call{Call: _e.mock.On("AdminUpdateUserAttributes",
+func (_e *MockCognitoAPI_Expecter) AdminListGroupsForUser(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminListGroupsForUser_Call {
+	return &MockCognitoAPI_AdminListGroupsForUser_Call{Call: _e.mock.On("AdminListGroupsForUser",
 		append([]interface{}{ctx, params}, optFns...)...)}
 }
 
-func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+func (_c *MockCognitoAPI_AdminListGroupsForUser_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminListGroupsForUserInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminListGroupsForUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
 		for i, a := range args[2:] {
@@ -451,23 +599,23 @@ func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) Run(run func(ctx contex
 				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
 			}
 		}
-		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminUpdateUserAttributesInput), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminListGroupsForUserInput), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) Return(_a0 *cognitoidentityprovider.AdminUpdateUserAttributesOutput, _a1 error) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+func (_c *MockCognitoAPI_AdminListGroupsForUser_Call) Return(_a0 *cognitoidentityprovider.AdminListGroupsForUserOutput, _a1 error) *MockCognitoAPI_AdminListGroupsForUser_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+func (_c *MockCognitoAPI_AdminListGroupsForUser_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminListGroupsForUserInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error)) *MockCognitoAPI_AdminListGroupsForUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListUserPools provides a mock function with given fields: ctx, params, optFns
-func (_m *MockCognitoAPI) ListUserPools(ctx context.Context, params *cognitoidentityprovider.ListUserPoolsInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error) {
+// AdminRemoveUserFromGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminRemoveUserFromGroup(ctx context.Context, params *cognitoidentityprovider.AdminRemoveUserFromGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error) {
 	_va := make([]interface{}, len(optFns))
 	for _i := range optFns {
 		_va[_i] = optFns[_i]
@@ -478,23 +626,23 @@ func (_m *MockCognitoAPI) ListUserPools(ctx context.Context, params *cognitoiden
 	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListUserPools")
+		panic("no return value specified for AdminRemoveUserFromGroup")
 	}
 
-	var r0 *cognitoidentityprovider.ListUserPoolsOutput
+	var r0 *cognitoidentityprovider.AdminRemoveUserFromGroupOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminRemoveUserFromGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error)); ok {
 		return rf(ctx, params, optFns...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.ListUserPoolsOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminRemoveUserFromGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminRemoveUserFromGroupOutput); ok {
 		r0 = rf(ctx, params, optFns...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*cognitoidentityprovider.ListUserPoolsOutput)
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminRemoveUserFromGroupOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminRemoveUserFromGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
 		r1 = rf(ctx, params, optFns...)
 	} else {
 		r1 = ret.Error(1)
@@ -503,21 +651,21 @@ func (_m *MockCognitoAPI) ListUserPools(ctx context.Context, params *cognitoiden
 	return r0, r1
 }
 
-// MockCognitoAPI_ListUserPools_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserPools'
-type MockCognitoAPI_ListUserPools_Call struct {
+// MockCognitoAPI_AdminRemoveUserFromGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminRemoveUserFromGroup'
+type MockCognitoAPI_AdminRemoveUserFromGroup_Call struct {
 	*mock.Call
 }
 
-// ListUserPools is a helper method to define mock.On call
+// AdminRemoveUserFromGroup is a helper method to define mock.On call
 //   - ctx context.Context
-//   - params *cognitoidentityprovider.ListUserPoolsInput
+//   - params *cognitoidentityprovider.AdminRemoveUserFromGroupInput
 //   - optFns ...func(*cognitoidentityprovider.Options)
-func (_e *MockCognitoAPI_Expecter) ListUserPools(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_ListUserPools_Call {
-	return &MockCognitoAPI_ListUserPools_Call{Call: _e.mock.On("ListUserPools",
+func (_e *MockCognitoAPI_Expecter) AdminRemoveUserFromGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminRemoveUserFromGroup_Call {
+	return &MockCognitoAPI_AdminRemoveUserFromGroup_Call{Call: _e.mock.On("AdminRemoveUserFromGroup",
 		append([]interface{}{ctx, params}, optFns...)...)}
 }
 
-func (_c *MockCognitoAPI_ListUserPools_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.ListUserPoolsInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_ListUserPools_Call {
+func (_c *MockCognitoAPI_AdminRemoveUserFromGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminRemoveUserFromGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminRemoveUserFromGroup_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
 		for i, a := range args[2:] {
@@ -525,23 +673,23 @@ func (_c *MockCognitoAPI_ListUserPools_Call) Run(run func(ctx context.Context, p
 				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
 			}
 		}
-		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.ListUserPoolsInput), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminRemoveUserFromGroupInput), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockCognitoAPI_ListUserPools_Call) Return(_a0 *cognitoidentityprovider.ListUserPoolsOutput, _a1 error) *MockCognitoAPI_ListUserPools_Call {
+func (_c *MockCognitoAPI_AdminRemoveUserFromGroup_Call) Return(_a0 *cognitoidentityprovider.AdminRemoveUserFromGroupOutput, _a1 error) *MockCognitoAPI_AdminRemoveUserFromGroup_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockCognitoAPI_ListUserPools_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error)) *MockCognitoAPI_ListUserPools_Call {
+func (_c *MockCognitoAPI_AdminRemoveUserFromGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminRemoveUserFromGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error)) *MockCognitoAPI_AdminRemoveUserFromGroup_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListUsers provides a mock function with given fields: ctx, params, optFns
-func (_m *MockCognitoAPI) ListUsers(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error) {
+// AdminSetUserPassword provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminSetUserPassword(ctx context.Context, params *cognitoidentityprovider.AdminSetUserPasswordInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserPasswordOutput, error) {
 	_va := make([]interface{}, len(optFns))
 	for _i := range optFns {
 		_va[_i] = optFns[_i]
@@ -552,23 +700,23 @@ func (_m *MockCognitoAPI) ListUsers(ctx context.Context, params *cognitoidentity
 	ret := _m.Called(_ca...)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListUsers")
+		panic("no return value specified for AdminSetUserPassword")
 	}
 
-	var r0 *cognitoidentityprovider.ListUsersOutput
+	var r0 *cognitoidentityprovider.AdminSetUserPasswordOutput
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminSetUserPasswordInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserPasswordOutput, error)); ok {
 		return rf(ctx, params, optFns...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.ListUsersOutput); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminSetUserPasswordInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminSetUserPasswordOutput); ok {
 		r0 = rf(ctx, params, optFns...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*cognitoidentityprovider.ListUsersOutput)
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminSetUserPasswordOutput)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminSetUserPasswordInput, ...func(*cognitoidentityprovider.Options)) error); ok {
 		r1 = rf(ctx, params, optFns...)
 	} else {
 		r1 = ret.Error(1)
@@ -577,21 +725,21 @@ func (_m *MockCognitoAPI) ListUsers(ctx context.Context, params *cognitoidentity
 	return r0, r1
 }
 
-// MockCognitoAPI_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
-type MockCognitoAPI_ListUsers_Call struct {
+// MockCognitoAPI_AdminSetUserPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminSetUserPassword'
+type MockCognitoAPI_AdminSetUserPassword_Call struct {
 	*mock.Call
 }
 
-// ListUsers is a helper method to define mock.On call
+// AdminSetUserPassword is a helper method to define mock.On call
 //   - ctx context.Context
-//   - params *cognitoidentityprovider.ListUsersInput
+//   - params *cognitoidentityprovider.AdminSetUserPasswordInput
 //   - optFns ...func(*cognitoidentityprovider.Options)
-func (_e *MockCognitoAPI_Expecter) ListUsers(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_ListUsers_Call {
-	return &MockCognitoAPI_ListUsers_Call{Call: _e.mock.On("ListUsers",
+func (_e *MockCognitoAPI_Expecter) AdminSetUserPassword(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminSetUserPassword_Call {
+	return &MockCognitoAPI_AdminSetUserPassword_Call{Call: _e.mock.On("AdminSetUserPassword",
 		append([]interface{}{ctx, params}, optFns...)...)}
 }
 
-func (_c *MockCognitoAPI_ListUsers_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_ListUsers_Call {
+func (_c *MockCognitoAPI_AdminSetUserPassword_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminSetUserPasswordInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminSetUserPassword_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
 		for i, a := range args[2:] {
@@ -599,17 +747,609 @@ func (_c *MockCognitoAPI_ListUsers_Call) Run(run func(ctx context.Context, param
 				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
 			}
 		}
-		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.ListUsersInput), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminSetUserPasswordInput), variadicArgs...)
 	})
 	return _c
 }
 
-func (_c *MockCognitoAPI_ListUsers_Call) Return(_a0 *cognitoidentityprovider.ListUsersOutput, _a1 error) *MockCognitoAPI_ListUsers_Call {
+func (_c *MockCognitoAPI_AdminSetUserPassword_Call) Return(_a0 *cognitoidentityprovider.AdminSetUserPasswordOutput, _a1 error) *MockCognitoAPI_AdminSetUserPassword_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockCognitoAPI_ListUsers_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)) *MockCognitoAPI_ListUsers_Call {
+func (_c *MockCognitoAPI_AdminSetUserPassword_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminSetUserPasswordInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserPasswordOutput, error)) *MockCognitoAPI_AdminSetUserPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AdminUpdateUserAttributes provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) AdminUpdateUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AdminUpdateUserAttributes")
+	}
+
+	var r0 *cognitoidentityprovider.AdminUpdateUserAttributesOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.AdminUpdateUserAttributesOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.AdminUpdateUserAttributesOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_AdminUpdateUserAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AdminUpdateUserAttributes'
+type MockCognitoAPI_AdminUpdateUserAttributes_Call struct {
+	*mock.Call
+}
+
+// AdminUpdateUserAttributes is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.AdminUpdateUserAttributesInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) AdminUpdateUserAttributes(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+	return &MockCognitoAPI_AdminUpdateUserAttributes_Call{Call: _e.mock.On("AdminUpdateUserAttributes",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.AdminUpdateUserAttributesInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) Return(_a0 *cognitoidentityprovider.AdminUpdateUserAttributesOutput, _a1 error) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_AdminUpdateUserAttributes_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.AdminUpdateUserAttributesInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)) *MockCognitoAPI_AdminUpdateUserAttributes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) CreateGroup(ctx context.Context, params *cognitoidentityprovider.CreateGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateGroup")
+	}
+
+	var r0 *cognitoidentityprovider.CreateGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.CreateGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.CreateGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.CreateGroupOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.CreateGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.CreateGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_CreateGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateGroup'
+type MockCognitoAPI_CreateGroup_Call struct {
+	*mock.Call
+}
+
+// CreateGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.CreateGroupInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) CreateGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_CreateGroup_Call {
+	return &MockCognitoAPI_CreateGroup_Call{Call: _e.mock.On("CreateGroup",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_CreateGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.CreateGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_CreateGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.CreateGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_CreateGroup_Call) Return(_a0 *cognitoidentityprovider.CreateGroupOutput, _a1 error) *MockCognitoAPI_CreateGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_CreateGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.CreateGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error)) *MockCognitoAPI_CreateGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) DeleteGroup(ctx context.Context, params *cognitoidentityprovider.DeleteGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteGroup")
+	}
+
+	var r0 *cognitoidentityprovider.DeleteGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.DeleteGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.DeleteGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.DeleteGroupOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.DeleteGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.DeleteGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_DeleteGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteGroup'
+type MockCognitoAPI_DeleteGroup_Call struct {
+	*mock.Call
+}
+
+// DeleteGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.DeleteGroupInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) DeleteGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_DeleteGroup_Call {
+	return &MockCognitoAPI_DeleteGroup_Call{Call: _e.mock.On("DeleteGroup",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_DeleteGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.DeleteGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_DeleteGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.DeleteGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_DeleteGroup_Call) Return(_a0 *cognitoidentityprovider.DeleteGroupOutput, _a1 error) *MockCognitoAPI_DeleteGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_DeleteGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.DeleteGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error)) *MockCognitoAPI_DeleteGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DescribeUserPool provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) DescribeUserPool(ctx context.Context, params *cognitoidentityprovider.DescribeUserPoolInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DescribeUserPool")
+	}
+
+	var r0 *cognitoidentityprovider.DescribeUserPoolOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.DescribeUserPoolInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.DescribeUserPoolInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.DescribeUserPoolOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.DescribeUserPoolOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.DescribeUserPoolInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_DescribeUserPool_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DescribeUserPool'
+type MockCognitoAPI_DescribeUserPool_Call struct {
+	*mock.Call
+}
+
+// DescribeUserPool is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.DescribeUserPoolInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) DescribeUserPool(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_DescribeUserPool_Call {
+	return &MockCognitoAPI_DescribeUserPool_Call{Call: _e.mock.On("DescribeUserPool",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_DescribeUserPool_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.DescribeUserPoolInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_DescribeUserPool_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.DescribeUserPoolInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_DescribeUserPool_Call) Return(_a0 *cognitoidentityprovider.DescribeUserPoolOutput, _a1 error) *MockCognitoAPI_DescribeUserPool_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_DescribeUserPool_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.DescribeUserPoolInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error)) *MockCognitoAPI_DescribeUserPool_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) GetGroup(ctx context.Context, params *cognitoidentityprovider.GetGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetGroupOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGroup")
+	}
+
+	var r0 *cognitoidentityprovider.GetGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.GetGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetGroupOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.GetGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.GetGroupOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.GetGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.GetGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_GetGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGroup'
+type MockCognitoAPI_GetGroup_Call struct {
+	*mock.Call
+}
+
+// GetGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.GetGroupInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) GetGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_GetGroup_Call {
+	return &MockCognitoAPI_GetGroup_Call{Call: _e.mock.On("GetGroup",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_GetGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.GetGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_GetGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.GetGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_GetGroup_Call) Return(_a0 *cognitoidentityprovider.GetGroupOutput, _a1 error) *MockCognitoAPI_GetGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_GetGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.GetGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetGroupOutput, error)) *MockCognitoAPI_GetGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUserPools provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) ListUserPools(ctx context.Context, params *cognitoidentityprovider.ListUserPoolsInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUserPools")
+	}
+
+	var r0 *cognitoidentityprovider.ListUserPoolsOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.ListUserPoolsOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.ListUserPoolsOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_ListUserPools_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUserPools'
+type MockCognitoAPI_ListUserPools_Call struct {
+	*mock.Call
+}
+
+// ListUserPools is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.ListUserPoolsInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) ListUserPools(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_ListUserPools_Call {
+	return &MockCognitoAPI_ListUserPools_Call{Call: _e.mock.On("ListUserPools",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_ListUserPools_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.ListUserPoolsInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_ListUserPools_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.ListUserPoolsInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_ListUserPools_Call) Return(_a0 *cognitoidentityprovider.ListUserPoolsOutput, _a1 error) *MockCognitoAPI_ListUserPools_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_ListUserPools_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.ListUserPoolsInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUserPoolsOutput, error)) *MockCognitoAPI_ListUserPools_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListUsers provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) ListUsers(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 *cognitoidentityprovider.ListUsersOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.ListUsersOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.ListUsersOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_ListUsers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListUsers'
+type MockCognitoAPI_ListUsers_Call struct {
+	*mock.Call
+}
+
+// ListUsers is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.ListUsersInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) ListUsers(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_ListUsers_Call {
+	return &MockCognitoAPI_ListUsers_Call{Call: _e.mock.On("ListUsers",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_ListUsers_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_ListUsers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.ListUsersInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_ListUsers_Call) Return(_a0 *cognitoidentityprovider.ListUsersOutput, _a1 error) *MockCognitoAPI_ListUsers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_ListUsers_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.ListUsersInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)) *MockCognitoAPI_ListUsers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateGroup provides a mock function with given fields: ctx, params, optFns
+func (_m *MockCognitoAPI) UpdateGroup(ctx context.Context, params *cognitoidentityprovider.UpdateGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.UpdateGroupOutput, error) {
+	_va := make([]interface{}, len(optFns))
+	for _i := range optFns {
+		_va[_i] = optFns[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, params)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateGroup")
+	}
+
+	var r0 *cognitoidentityprovider.UpdateGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.UpdateGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.UpdateGroupOutput, error)); ok {
+		return rf(ctx, params, optFns...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *cognitoidentityprovider.UpdateGroupInput, ...func(*cognitoidentityprovider.Options)) *cognitoidentityprovider.UpdateGroupOutput); ok {
+		r0 = rf(ctx, params, optFns...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*cognitoidentityprovider.UpdateGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *cognitoidentityprovider.UpdateGroupInput, ...func(*cognitoidentityprovider.Options)) error); ok {
+		r1 = rf(ctx, params, optFns...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockCognitoAPI_UpdateGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateGroup'
+type MockCognitoAPI_UpdateGroup_Call struct {
+	*mock.Call
+}
+
+// UpdateGroup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - params *cognitoidentityprovider.UpdateGroupInput
+//   - optFns ...func(*cognitoidentityprovider.Options)
+func (_e *MockCognitoAPI_Expecter) UpdateGroup(ctx interface{}, params interface{}, optFns ...interface{}) *MockCognitoAPI_UpdateGroup_Call {
+	return &MockCognitoAPI_UpdateGroup_Call{Call: _e.mock.On("UpdateGroup",
+		append([]interface{}{ctx, params}, optFns...)...)}
+}
+
+func (_c *MockCognitoAPI_UpdateGroup_Call) Run(run func(ctx context.Context, params *cognitoidentityprovider.UpdateGroupInput, optFns ...func(*cognitoidentityprovider.Options))) *MockCognitoAPI_UpdateGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*cognitoidentityprovider.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*cognitoidentityprovider.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*cognitoidentityprovider.UpdateGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockCognitoAPI_UpdateGroup_Call) Return(_a0 *cognitoidentityprovider.UpdateGroupOutput, _a1 error) *MockCognitoAPI_UpdateGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockCognitoAPI_UpdateGroup_Call) RunAndReturn(run func(context.Context, *cognitoidentityprovider.UpdateGroupInput, ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.UpdateGroupOutput, error)) *MockCognitoAPI_UpdateGroup_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -0,0 +1,183 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cognito
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// phoneNumberAttribute is the standard attribute holding the phone number of a user
+const phoneNumberAttribute = "phone_number"
+
+// usernameMode tells how a user pool names its users
+type usernameMode int
+
+const (
+	// emailUsernames pools sign users in with their email. Cognito generates the usernames, which
+	// are the subs, and accepts the emails in their place.
+	emailUsernames usernameMode = iota
+	// phoneUsernames pools sign users in with their phone number. Cognito generates the usernames,
+	// which are the subs, and accepts the phone numbers but not the emails in their place.
+	phoneUsernames
+	// ownUsernames pools keep the username given at creation, which is neither the sub nor the email
+	ownUsernames
+	// detectUsernames pools are described on first use to find out how they name their users
+	detectUsernames
+)
+
+// modeOf returns how a user pool with the given username attributes names its users
+func modeOf(usernameAttributes []types.UsernameAttributeType) usernameMode {
+	switch {
+	case slices.Contains(usernameAttributes, types.UsernameAttributeTypeEmail):
+		return emailUsernames
+	case slices.Contains(usernameAttributes, types.UsernameAttributeTypePhoneNumber):
+		return phoneUsernames
+	default:
+		return ownUsernames
+	}
+}
+
+// usernameMode returns how the user pool names its users, describing it on first use. The lock is
+// not held while describing, so concurrent first calls may each describe the pool; failures are
+// not cached.
+func (c *AWSClient) usernameMode(ctx context.Context) (usernameMode, error) {
+	c.mu.Lock()
+	mode := c.mode
+	c.mu.Unlock()
+	if mode != detectUsernames {
+		return mode, nil
+	}
+
+	output, err := c.cognito.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: aws.String(c.userPoolID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to describe user pool %s: %w", c.userPoolID, err)
+	}
+	if output.UserPool == nil {
+		return 0, fmt.Errorf("user pool %s not found", c.userPoolID)
+	}
+	mode = modeOf(output.UserPool.UsernameAttributes)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+	return mode, nil
+}
+
+// newUsername returns the username a user is created with. Pools signing users in with their
+// email or phone number expect it as username and generate the actual one; other pools keep the
+// username chosen by the caller, or the email when none was chosen.
+func (c *AWSClient) newUsername(ctx context.Context, user *userpool.User) (string, error) {
+	mode, err := c.usernameMode(ctx)
+	if err != nil {
+		return "", err
+	}
+	switch mode {
+	case emailUsernames:
+		return user.Email, nil
+	case phoneUsernames:
+		if phone := user.Attributes[phoneNumberAttribute]; phone != "" {
+			return phone, nil
+		}
+		return "", fmt.Errorf("user pool %s signs users in with their phone number, which user %s lacks",
+			c.userPoolID, user.Email)
+	default:
+		if user.Username != "" {
+			return user.Username, nil
+		}
+		return user.Email, nil
+	}
+}
+
+// username returns the Cognito username of the user identified by a sub or email. Pools signing
+// users in with their email accept both in place of the username. In other pools the user is
+// looked up by sub, then by email; identifiers matching neither are assumed to be usernames.
+func (c *AWSClient) username(ctx context.Context, identifier string) (string, error) {
+	mode, err := c.usernameMode(ctx)
+	if err != nil {
+		return "", err
+	}
+	if mode == emailUsernames {
+		return identifier, nil
+	}
+
+	c.mu.Lock()
+	username, ok := c.usernames[identifier]
+	c.mu.Unlock()
+	if ok {
+		return username, nil
+	}
+
+	for _, attribute := range []string{subAttribute, emailAttribute} {
+		output, err := c.cognito.ListUsers(ctx, &cognitoidentityprovider.ListUsersInput{
+			UserPoolId: aws.String(c.userPoolID),
+			Filter:     aws.String(attribute + " = " + strconv.Quote(identifier)),
+			Limit:      aws.Int32(2),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to look up user %s: %w", identifier, err)
+		}
+		switch len(output.Users) {
+		case 0:
+			continue
+		case 1:
+			username := aws.ToString(output.Users[0].Username)
+			if attribute == subAttribute {
+				c.rememberUsername(identifier, username)
+			}
+			return username, nil
+		default:
+			return "", fmt.Errorf("email %s is used by several users of user pool %s, which must be addressed by sub",
+				identifier, c.userPoolID)
+		}
+	}
+	return identifier, nil
+}
+
+// rememberUsername caches the username of a sub, as neither ever changes
+func (c *AWSClient) rememberUsername(sub, username string) {
+	if sub == "" || username == "" || sub == username {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.usernames == nil {
+		c.usernames = map[string]string{}
+	}
+	c.usernames[sub] = username
+}
+
+// subOf returns the sub of a Cognito user from its attributes, falling back to its username,
+// which is the sub in pools signing users in with their email or phone number
+func subOf(username *string, attributes []types.AttributeType) string {
+	for _, attr := range attributes {
+		if aws.ToString(attr.Name) == subAttribute && aws.ToString(attr.Value) != "" {
+			return *attr.Value
+		}
+	}
+	return aws.ToString(username)
+}
@@ -0,0 +1,778 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keycloak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+const (
+	// pageSize is the number of users and groups requested per page
+	pageSize = 100
+
+	// updatePasswordAction is the required action that makes users set a new password
+	updatePasswordAction = "UPDATE_PASSWORD"
+
+	// emailDeliveryMedium is the only invitation delivery medium Keycloak supports
+	emailDeliveryMedium = "EMAIL"
+
+	// Statuses reported for Keycloak users, named after their Cognito counterparts so that
+	// User status reads the same for every backend
+	statusConfirmed           = "CONFIRMED"
+	statusForceChangePassword = "FORCE_CHANGE_PASSWORD"
+
+	// totpMFAMethod is reported for users with a configured OTP authenticator
+	totpMFAMethod = "SOFTWARE_TOKEN_MFA"
+
+	// Group attributes holding the group description, precedence and owner
+	descriptionGroupAttribute = "description"
+	precedenceGroupAttribute  = "precedence"
+	ownerGroupAttribute       = "kcp_owner"
+)
+
+// internalAttributes are maintained by Keycloak user federation and never exposed as custom attributes
+var internalAttributes = map[string]bool{
+	"LDAP_ID":            true,
+	"LDAP_ENTRY_DN":      true,
+	"KERBEROS_PRINCIPAL": true,
+	"createTimestamp":    true,
+	"modifyTimestamp":    true,
+}
+
+// standardAttributes are stored in the Keycloak attributes map under their user pool name
+var standardAttributes = map[string]bool{
+	userpool.AttributePhoneNumber:       true,
+	userpool.AttributeLocale:            true,
+	userpool.AttributePreferredUsername: true,
+}
+
+// Config configures the connection to a Keycloak realm
+type Config struct {
+	// URL is the base URL of the Keycloak server, e.g. https://keycloak.example.com
+	URL string
+	// Realm is the realm users are managed in
+	Realm string
+	// ClientID and ClientSecret identify a confidential client whose service account holds
+	// the manage-users and view-realm roles of the realm-management client
+	ClientID     string
+	ClientSecret string
+	// HTTPClient sends all requests, including token requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Client implements the userpool.Client interface for the Keycloak Admin REST API
+type Client struct {
+	http    *http.Client
+	baseURL string
+}
+
+var _ userpool.Client = &Client{}
+
+// APIError is returned when the Keycloak Admin REST API responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("keycloak returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("keycloak returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// isStatus reports whether err is an APIError with the given status code
+func isStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// NewClient creates a new Keycloak client authenticating with the client credentials grant
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("keycloak URL cannot be empty")
+	}
+	if cfg.Realm == "" {
+		return nil, fmt.Errorf("keycloak realm cannot be empty")
+	}
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("keycloak client ID and secret cannot be empty")
+	}
+
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	realm := url.PathEscape(cfg.Realm)
+	credentials := clientcredentials.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		TokenURL:     baseURL + "/realms/" + realm + "/protocol/openid-connect/token",
+	}
+
+	// Tokens are fetched and refreshed for the lifetime of the client
+	ctx := context.Background()
+	if cfg.HTTPClient != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, cfg.HTTPClient)
+	}
+
+	return &Client{
+		http:    credentials.Client(ctx),
+		baseURL: baseURL + "/admin/realms/" + realm,
+	}, nil
+}
+
+// userRepresentation is the UserRepresentation of the Keycloak Admin REST API
+type userRepresentation struct {
+	ID               string              `json:"id,omitempty"`
+	Username         string              `json:"username,omitempty"`
+	Email            string              `json:"email"`
+	EmailVerified    bool                `json:"emailVerified"`
+	Enabled          bool                `json:"enabled"`
+	FirstName        string              `json:"firstName"`
+	LastName         string              `json:"lastName"`
+	Attributes       map[string][]string `json:"attributes"`
+	RequiredActions  []string            `json:"requiredActions,omitempty"`
+	CreatedTimestamp int64               `json:"createdTimestamp,omitempty"`
+	Totp             bool                `json:"totp,omitempty"`
+}
+
+// groupRepresentation is the GroupRepresentation of the Keycloak Admin REST API
+type groupRepresentation struct {
+	ID         string              `json:"id,omitempty"`
+	Name       string              `json:"name"`
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// credentialRepresentation is the CredentialRepresentation of the Keycloak Admin REST API
+type credentialRepresentation struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Temporary bool   `json:"temporary"`
+}
+
+// userProfile is the UPConfig of the Keycloak Admin REST API
+type userProfile struct {
+	Attributes []struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+}
+
+// CreateUser creates a new user in the Keycloak realm, using the email as username
+func (c *Client) CreateUser(ctx context.Context, user *userpool.User) (*userpool.User, error) {
+	if user == nil {
+		return nil, fmt.Errorf("user cannot be nil")
+	}
+	if user.Email == "" {
+		return nil, fmt.Errorf("email cannot be empty")
+	}
+	if err := validateDeliveryMediums(user.InvitationDeliveryMediums); err != nil {
+		return nil, err
+	}
+
+	rep := &userRepresentation{
+		Username:      user.Email,
+		EmailVerified: true,
+	}
+	applyUser(rep, user)
+	invite := len(user.InvitationDeliveryMediums) > 0
+	if invite {
+		rep.RequiredActions = []string{updatePasswordAction}
+	}
+
+	header, err := c.do(ctx, http.MethodPost, "/users", nil, rep, nil)
+	if err != nil {
+		// Report existing users so the caller can decide whether to adopt them
+		if isStatus(err, http.StatusConflict) {
+			return nil, fmt.Errorf("failed to create user %s: %w", user.Email, userpool.ErrUserExists)
+		}
+		return nil, fmt.Errorf("failed to create user %s: %w", user.Email, err)
+	}
+
+	// Keycloak returns the ID of the new user in the Location header only
+	id := path.Base(header.Get("Location"))
+	if invite {
+		if err := c.sendActionsEmail(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to send invitation to %s: %w", user.Email, err)
+		}
+	}
+
+	created, err := c.getUserByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get created user %s: %w", user.Email, err)
+	}
+	return fromRepresentation(created), nil
+}
+
+// GetUser retrieves a user from the Keycloak realm by ID, username or email
+func (c *Client) GetUser(ctx context.Context, username string) (*userpool.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	return fromRepresentation(rep), nil
+}
+
+// UpdateUser updates the email, attributes and enabled state of an existing user
+func (c *Client) UpdateUser(ctx context.Context, user *userpool.User) error {
+	if user == nil {
+		return fmt.Errorf("user cannot be nil")
+	}
+	identifier := user.Sub
+	if identifier == "" {
+		identifier = user.Email
+	}
+	if identifier == "" {
+		return fmt.Errorf("user sub or email must be set")
+	}
+
+	rep, err := c.lookupUser(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", identifier, err)
+	}
+	applyUser(rep, user)
+
+	if _, err := c.do(ctx, http.MethodPut, "/users/"+url.PathEscape(rep.ID), nil, rep, nil); err != nil {
+		return fmt.Errorf("failed to update user %s: %w", identifier, err)
+	}
+	return nil
+}
+
+// DeleteUserAttributes removes the named attributes from a user
+func (c *Client) DeleteUserAttributes(ctx context.Context, username string, names []string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	for _, name := range names {
+		switch name {
+		case userpool.AttributeGivenName:
+			rep.FirstName = ""
+		case userpool.AttributeFamilyName:
+			rep.LastName = ""
+		default:
+			delete(rep.Attributes, attributeName(name))
+		}
+	}
+
+	if _, err := c.do(ctx, http.MethodPut, "/users/"+url.PathEscape(rep.ID), nil, rep, nil); err != nil {
+		return fmt.Errorf("failed to delete attributes of user %s: %w", username, err)
+	}
+	return nil
+}
+
+// ResendInvitation emails the user a link to set their password. Only the EMAIL medium is supported.
+func (c *Client) ResendInvitation(ctx context.Context, username string, deliveryMediums []string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if err := validateDeliveryMediums(deliveryMediums); err != nil {
+		return err
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	if err := c.sendActionsEmail(ctx, rep.ID); err != nil {
+		return fmt.Errorf("failed to resend invitation to %s: %w", username, err)
+	}
+	return nil
+}
+
+// SetUserPassword sets a user's password. Non-permanent passwords must be changed at next sign-in.
+func (c *Client) SetUserPassword(ctx context.Context, username, password string, permanent bool) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	credential := credentialRepresentation{Type: "password", Value: password, Temporary: !permanent}
+	if _, err := c.do(ctx, http.MethodPut, "/users/"+url.PathEscape(rep.ID)+"/reset-password",
+		nil, credential, nil); err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
+	return nil
+}
+
+// DisableUser disables a user in the Keycloak realm, keeping the identity
+func (c *Client) DisableUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	rep.Enabled = false
+	if _, err := c.do(ctx, http.MethodPut, "/users/"+url.PathEscape(rep.ID), nil, rep, nil); err != nil {
+		return fmt.Errorf("failed to disable user %s: %w", username, err)
+	}
+	return nil
+}
+
+// DeleteUser removes a user from the Keycloak realm, succeeding when the user does not exist
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if isStatus(err, http.StatusNotFound) {
+		// User doesn't exist, this is not an error for deletion
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	_, err = c.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(rep.ID), nil, nil, nil)
+	if err != nil && !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete user %s: %w", username, err)
+	}
+	return nil
+}
+
+// ListUsers lists all users in the Keycloak realm
+func (c *Client) ListUsers(ctx context.Context) ([]*userpool.User, error) {
+	var users []*userpool.User
+
+	for first := 0; ; first += pageSize {
+		var page []userRepresentation
+		query := url.Values{
+			"first": {strconv.Itoa(first)},
+			"max":   {strconv.Itoa(pageSize)},
+		}
+		if _, err := c.do(ctx, http.MethodGet, "/users", query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for i := range page {
+			users = append(users, fromRepresentation(&page[i]))
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	return users, nil
+}
+
+// ListSchemaAttributes lists the attributes of the realm's user profile under their user pool names
+func (c *Client) ListSchemaAttributes(ctx context.Context) ([]string, error) {
+	var profile userProfile
+	if _, err := c.do(ctx, http.MethodGet, "/users/profile", nil, nil, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	names := make([]string, 0, len(profile.Attributes))
+	for _, attr := range profile.Attributes {
+		switch attr.Name {
+		case "username":
+			// The username is always the email
+		case "email":
+			names = append(names, "email")
+		case "firstName":
+			names = append(names, userpool.AttributeGivenName)
+		case "lastName":
+			names = append(names, userpool.AttributeFamilyName)
+		default:
+			names = append(names, poolAttributeName(attr.Name))
+		}
+	}
+	return names, nil
+}
+
+// GetGroup retrieves a top-level group from the Keycloak realm
+func (c *Client) GetGroup(ctx context.Context, name string) (*userpool.Group, error) {
+	if name == "" {
+		return nil, fmt.Errorf("group name cannot be empty")
+	}
+
+	rep, err := c.findGroup(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group %s: %w", name, err)
+	}
+	if rep == nil {
+		return nil, fmt.Errorf("%w: %s", userpool.ErrGroupNotFound, name)
+	}
+	group := &userpool.Group{
+		Name:        rep.Name,
+		Description: firstValue(rep.Attributes[descriptionGroupAttribute]),
+		Owner:       firstValue(rep.Attributes[ownerGroupAttribute]),
+	}
+	if value, err := strconv.Atoi(firstValue(rep.Attributes[precedenceGroupAttribute])); err == nil {
+		precedence := int32(value)
+		group.Precedence = &precedence
+	}
+	return group, nil
+}
+
+// CreateGroup creates a top-level group in the Keycloak realm, updating it if it already exists.
+// The description, precedence and owner are kept as group attributes.
+func (c *Client) CreateGroup(ctx context.Context, group *userpool.Group) error {
+	if group == nil {
+		return fmt.Errorf("group cannot be nil")
+	}
+	if group.Name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	existing, err := c.findGroup(ctx, group.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get group %s: %w", group.Name, err)
+	}
+
+	rep := &groupRepresentation{Name: group.Name, Attributes: map[string][]string{}}
+	if existing != nil {
+		rep = existing
+		if rep.Attributes == nil {
+			rep.Attributes = map[string][]string{}
+		}
+	}
+	delete(rep.Attributes, descriptionGroupAttribute)
+	delete(rep.Attributes, precedenceGroupAttribute)
+	delete(rep.Attributes, ownerGroupAttribute)
+	if group.Description != "" {
+		rep.Attributes[descriptionGroupAttribute] = []string{group.Description}
+	}
+	if group.Precedence != nil {
+		rep.Attributes[precedenceGroupAttribute] = []string{strconv.Itoa(int(*group.Precedence))}
+	}
+	if group.Owner != "" {
+		rep.Attributes[ownerGroupAttribute] = []string{group.Owner}
+	}
+
+	if existing != nil {
+		if _, err := c.do(ctx, http.MethodPut, "/groups/"+url.PathEscape(rep.ID), nil, rep, nil); err != nil {
+			return fmt.Errorf("failed to update group %s: %w", group.Name, err)
+		}
+		return nil
+	}
+	if _, err := c.do(ctx, http.MethodPost, "/groups", nil, rep, nil); err != nil {
+		return fmt.Errorf("failed to create group %s: %w", group.Name, err)
+	}
+	return nil
+}
+
+// DeleteGroup removes a group from the Keycloak realm
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	group, err := c.findGroup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get group %s: %w", name, err)
+	}
+	if group == nil {
+		// Group doesn't exist, this is not an error for deletion
+		return nil
+	}
+	if _, err := c.do(ctx, http.MethodDelete, "/groups/"+url.PathEscape(group.ID), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete group %s: %w", name, err)
+	}
+	return nil
+}
+
+// AddUserToGroup adds a user to a group in the Keycloak realm
+func (c *Client) AddUserToGroup(ctx context.Context, username, group string) error {
+	return c.changeMembership(ctx, http.MethodPut, username, group)
+}
+
+// RemoveUserFromGroup removes a user from a group in the Keycloak realm
+func (c *Client) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	return c.changeMembership(ctx, http.MethodDelete, username, group)
+}
+
+// ListGroupsForUser lists the names of the Keycloak groups a user belongs to
+func (c *Client) ListGroupsForUser(ctx context.Context, username string) ([]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	var groups []string
+	for first := 0; ; first += pageSize {
+		var page []groupRepresentation
+		query := url.Values{
+			"first": {strconv.Itoa(first)},
+			"max":   {strconv.Itoa(pageSize)},
+		}
+		if _, err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(rep.ID)+"/groups",
+			query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list groups for user %s: %w", username, err)
+		}
+
+		for _, group := range page {
+			groups = append(groups, group.Name)
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	return groups, nil
+}
+
+// changeMembership adds (PUT) or removes (DELETE) a user's membership of a group
+func (c *Client) changeMembership(ctx context.Context, method, username, group string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if group == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	rep, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	groupRep, err := c.findGroup(ctx, group)
+	if err != nil {
+		return fmt.Errorf("failed to get group %s: %w", group, err)
+	}
+	if groupRep == nil {
+		return fmt.Errorf("group %s not found", group)
+	}
+
+	membershipPath := "/users/" + url.PathEscape(rep.ID) + "/groups/" + url.PathEscape(groupRep.ID)
+	if _, err := c.do(ctx, method, membershipPath, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to change membership of user %s in group %s: %w", username, group, err)
+	}
+	return nil
+}
+
+// getUserByID retrieves a user by its Keycloak ID
+func (c *Client) getUserByID(ctx context.Context, id string) (*userRepresentation, error) {
+	var rep userRepresentation
+	if _, err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(id), nil, nil, &rep); err != nil {
+		return nil, err
+	}
+	return &rep, nil
+}
+
+// lookupUser finds a user by Keycloak ID, falling back to an exact username and email search
+func (c *Client) lookupUser(ctx context.Context, identifier string) (*userRepresentation, error) {
+	rep, err := c.getUserByID(ctx, identifier)
+	if err == nil {
+		return rep, nil
+	}
+	if !isStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+
+	for _, key := range []string{"username", "email"} {
+		var reps []userRepresentation
+		query := url.Values{key: {identifier}, "exact": {"true"}}
+		if _, err := c.do(ctx, http.MethodGet, "/users", query, nil, &reps); err != nil {
+			return nil, err
+		}
+		if len(reps) > 0 {
+			return &reps[0], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %w", userpool.ErrUserNotFound,
+		&APIError{StatusCode: http.StatusNotFound, Message: "no user matches " + identifier})
+}
+
+// findGroup finds a top-level group by name, returning nil when it does not exist
+func (c *Client) findGroup(ctx context.Context, name string) (*groupRepresentation, error) {
+	var groups []groupRepresentation
+	query := url.Values{"search": {name}, "exact": {"true"}, "briefRepresentation": {"false"}}
+	if _, err := c.do(ctx, http.MethodGet, "/groups", query, nil, &groups); err != nil {
+		return nil, err
+	}
+	// Older Keycloak versions ignore exact and match substrings
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// firstValue returns the first value of a multi-valued attribute, or an empty string
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// sendActionsEmail emails the user a link to set a new password
+func (c *Client) sendActionsEmail(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodPut, "/users/"+url.PathEscape(id)+"/execute-actions-email",
+		nil, []string{updatePasswordAction}, nil)
+	return err
+}
+
+// do sends a request to the Admin REST API, encoding in and decoding the response into out
+// when they are not nil, and returns the response headers
+func (c *Client) do(ctx context.Context, method, apiPath string, query url.Values, in, out any) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	reqURL := c.baseURL + apiPath
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// validateDeliveryMediums rejects delivery mediums other than EMAIL
+func validateDeliveryMediums(mediums []string) error {
+	for _, medium := range mediums {
+		if medium != emailDeliveryMedium {
+			return fmt.Errorf("invitation delivery medium %s is not supported by Keycloak", medium)
+		}
+	}
+	return nil
+}
+
+// applyUser copies the email, enabled state and attributes of user onto rep. Attributes not
+// present in user are kept.
+func applyUser(rep *userRepresentation, user *userpool.User) {
+	rep.Email = user.Email
+	rep.Enabled = user.Enabled
+	if rep.Attributes == nil {
+		rep.Attributes = map[string][]string{}
+	}
+	for name, value := range user.Attributes {
+		switch name {
+		case userpool.AttributeGivenName:
+			rep.FirstName = value
+		case userpool.AttributeFamilyName:
+			rep.LastName = value
+		default:
+			rep.Attributes[attributeName(name)] = []string{value}
+		}
+	}
+}
+
+// fromRepresentation converts a Keycloak user into a user pool user
+func fromRepresentation(rep *userRepresentation) *userpool.User {
+	user := &userpool.User{
+		Username:   rep.Username,
+		Email:      rep.Email,
+		Enabled:    rep.Enabled,
+		Sub:        rep.ID,
+		Attributes: map[string]string{},
+		Status:     statusConfirmed,
+	}
+	if rep.FirstName != "" {
+		user.Attributes[userpool.AttributeGivenName] = rep.FirstName
+	}
+	if rep.LastName != "" {
+		user.Attributes[userpool.AttributeFamilyName] = rep.LastName
+	}
+	for name, values := range rep.Attributes {
+		if internalAttributes[name] || len(values) == 0 {
+			continue
+		}
+		user.Attributes[poolAttributeName(name)] = values[0]
+	}
+	if slices.Contains(rep.RequiredActions, updatePasswordAction) {
+		user.PasswordChangeRequired = true
+		user.Status = statusForceChangePassword
+	}
+	if rep.CreatedTimestamp > 0 {
+		user.CreatedAt = time.UnixMilli(rep.CreatedTimestamp)
+	}
+	if rep.Totp {
+		user.MFAMethods = []string{totpMFAMethod}
+	}
+	return user
+}
+
+// attributeName returns the Keycloak name of a user pool attribute, dropping the custom: prefix
+func attributeName(name string) string {
+	return strings.TrimPrefix(name, userpool.CustomAttributePrefix)
+}
+
+// poolAttributeName returns the user pool name of a Keycloak attribute
+func poolAttributeName(name string) string {
+	if standardAttributes[name] {
+		return name
+	}
+	return userpool.CustomAttributePrefix + name
+}
@@ -0,0 +1,696 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/conformance"
+)
+
+const (
+	testRealm        = "tenants"
+	testClientID     = "kcp-users-controller"
+	testClientSecret = "s3cr3t"
+	testToken        = "test-access-token"
+)
+
+// fakeKeycloak is an in-memory fake of the parts of the Keycloak Admin REST API used by Client
+type fakeKeycloak struct {
+	mu          sync.Mutex
+	nextID      int
+	users       map[string]*userRepresentation
+	groups      map[string]*groupRepresentation
+	memberships map[string]map[string]bool
+	passwords   map[string]credentialRepresentation
+	emailsSent  map[string][]string
+	profile     []string
+	tokens      int
+}
+
+func newFakeKeycloak(t *testing.T) (*fakeKeycloak, *Client) {
+	fake := &fakeKeycloak{
+		users:       map[string]*userRepresentation{},
+		groups:      map[string]*groupRepresentation{},
+		memberships: map[string]map[string]bool{},
+		passwords:   map[string]credentialRepresentation{},
+		emailsSent:  map[string][]string{},
+		profile:     []string{"username", "email", "firstName", "lastName"},
+	}
+
+	admin := "/admin/realms/" + testRealm
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /realms/"+testRealm+"/protocol/openid-connect/token", fake.token)
+	mux.HandleFunc("POST "+admin+"/users", fake.authorized(fake.createUser))
+	mux.HandleFunc("GET "+admin+"/users", fake.authorized(fake.listUsers))
+	mux.HandleFunc("GET "+admin+"/users/profile", fake.authorized(fake.getProfile))
+	mux.HandleFunc("GET "+admin+"/users/{id}", fake.authorized(fake.getUser))
+	mux.HandleFunc("PUT "+admin+"/users/{id}", fake.authorized(fake.updateUser))
+	mux.HandleFunc("DELETE "+admin+"/users/{id}", fake.authorized(fake.deleteUser))
+	mux.HandleFunc("PUT "+admin+"/users/{id}/reset-password", fake.authorized(fake.resetPassword))
+	mux.HandleFunc("PUT "+admin+"/users/{id}/execute-actions-email", fake.authorized(fake.executeActionsEmail))
+	mux.HandleFunc("GET "+admin+"/users/{id}/groups", fake.authorized(fake.listUserGroups))
+	mux.HandleFunc("PUT "+admin+"/users/{id}/groups/{group}", fake.authorized(fake.joinGroup))
+	mux.HandleFunc("DELETE "+admin+"/users/{id}/groups/{group}", fake.authorized(fake.leaveGroup))
+	mux.HandleFunc("GET "+admin+"/groups", fake.authorized(fake.searchGroups))
+	mux.HandleFunc("POST "+admin+"/groups", fake.authorized(fake.createGroup))
+	mux.HandleFunc("PUT "+admin+"/groups/{id}", fake.authorized(fake.updateGroup))
+	mux.HandleFunc("DELETE "+admin+"/groups/{id}", fake.authorized(fake.deleteGroup))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{
+		URL:          server.URL,
+		Realm:        testRealm,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		HTTPClient:   server.Client(),
+	})
+	require.NoError(t, err)
+	return fake, client
+}
+
+func (f *fakeKeycloak) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != testClientID || secret != testClientSecret || r.FormValue("grant_type") != "client_credentials" {
+		http.Error(w, `{"error":"unauthorized_client"}`, http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	f.tokens++
+	f.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": testToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+	})
+}
+
+func (f *fakeKeycloak) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		handler(w, r)
+	}
+}
+
+func (f *fakeKeycloak) newID() string {
+	f.nextID++
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", f.nextID)
+}
+
+func (f *fakeKeycloak) addUser(rep userRepresentation) string {
+	rep.ID = f.newID()
+	f.users[rep.ID] = &rep
+	return rep.ID
+}
+
+func (f *fakeKeycloak) createUser(w http.ResponseWriter, r *http.Request) {
+	var rep userRepresentation
+	if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, existing := range f.users {
+		if strings.EqualFold(existing.Username, rep.Username) || strings.EqualFold(existing.Email, rep.Email) {
+			writeJSON(w, http.StatusConflict, map[string]string{"errorMessage": "User exists with same username"})
+			return
+		}
+	}
+	rep.CreatedTimestamp = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli()
+	id := f.addUser(rep)
+	w.Header().Set("Location", r.URL.String()+"/"+id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeKeycloak) listUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var matches []userRepresentation
+	for _, rep := range f.users {
+		if username := query.Get("username"); username != "" && !strings.EqualFold(rep.Username, username) {
+			continue
+		}
+		if email := query.Get("email"); email != "" && !strings.EqualFold(rep.Email, email) {
+			continue
+		}
+		matches = append(matches, *rep)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	writeJSON(w, http.StatusOK, page(matches, query))
+}
+
+func (f *fakeKeycloak) getProfile(w http.ResponseWriter, _ *http.Request) {
+	attributes := make([]map[string]string, 0, len(f.profile))
+	for _, name := range f.profile {
+		attributes = append(attributes, map[string]string{"name": name})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"attributes": attributes})
+}
+
+func (f *fakeKeycloak) getUser(w http.ResponseWriter, r *http.Request) {
+	rep, ok := f.users[r.PathValue("id")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, rep)
+}
+
+func (f *fakeKeycloak) updateUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := f.users[id]; !ok {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	var rep userRepresentation
+	if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rep.ID = id
+	f.users[id] = &rep
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := f.users[id]; !ok {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	delete(f.users, id)
+	delete(f.memberships, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var credential credentialRepresentation
+	if err := json.NewDecoder(r.Body).Decode(&credential); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := r.PathValue("id")
+	f.passwords[id] = credential
+	if credential.Temporary {
+		f.users[id].RequiredActions = []string{updatePasswordAction}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) executeActionsEmail(w http.ResponseWriter, r *http.Request) {
+	var actions []string
+	if err := json.NewDecoder(r.Body).Decode(&actions); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := r.PathValue("id")
+	f.emailsSent[id] = append(f.emailsSent[id], actions...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) listUserGroups(w http.ResponseWriter, r *http.Request) {
+	var groups []groupRepresentation
+	for groupID := range f.memberships[r.PathValue("id")] {
+		groups = append(groups, *f.groups[groupID])
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	writeJSON(w, http.StatusOK, page(groups, r.URL.Query()))
+}
+
+func (f *fakeKeycloak) joinGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if f.memberships[id] == nil {
+		f.memberships[id] = map[string]bool{}
+	}
+	f.memberships[id][r.PathValue("group")] = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) leaveGroup(w http.ResponseWriter, r *http.Request) {
+	delete(f.memberships[r.PathValue("id")], r.PathValue("group"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) searchGroups(w http.ResponseWriter, r *http.Request) {
+	// Like older Keycloak versions, match substrings regardless of the exact parameter
+	search := r.URL.Query().Get("search")
+	groups := []groupRepresentation{}
+	for _, group := range f.groups {
+		if strings.Contains(group.Name, search) {
+			groups = append(groups, *group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	writeJSON(w, http.StatusOK, groups)
+}
+
+func (f *fakeKeycloak) createGroup(w http.ResponseWriter, r *http.Request) {
+	var group groupRepresentation
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	group.ID = f.newID()
+	f.groups[group.ID] = &group
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeKeycloak) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var group groupRepresentation
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	group.ID = r.PathValue("id")
+	f.groups[group.ID] = &group
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	delete(f.groups, id)
+	for _, groups := range f.memberships {
+		delete(groups, id)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeKeycloak) groupByName(name string) *groupRepresentation {
+	for _, group := range f.groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// page applies the first and max query parameters to items
+func page[T any](items []T, query map[string][]string) []T {
+	first, _ := strconv.Atoi(firstValue(query["first"]))
+	limit, err := strconv.Atoi(firstValue(query["max"]))
+	if err != nil {
+		limit = len(items)
+	}
+	if first > len(items) {
+		first = len(items)
+	}
+	end := min(first+limit, len(items))
+	if items[first:end] == nil {
+		return []T{}
+	}
+	return items[first:end]
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		expectErr string
+	}{
+		{
+			name:      "missing URL",
+			config:    Config{Realm: testRealm, ClientID: testClientID, ClientSecret: testClientSecret},
+			expectErr: "keycloak URL cannot be empty",
+		},
+		{
+			name:      "missing realm",
+			config:    Config{URL: "https://keycloak.example.com", ClientID: testClientID, ClientSecret: testClientSecret},
+			expectErr: "keycloak realm cannot be empty",
+		},
+		{
+			name:      "missing client secret",
+			config:    Config{URL: "https://keycloak.example.com", Realm: testRealm, ClientID: testClientID},
+			expectErr: "keycloak client ID and secret cannot be empty",
+		},
+		{
+			name: "valid config",
+			config: Config{URL: "https://keycloak.example.com/", Realm: testRealm,
+				ClientID: testClientID, ClientSecret: testClientSecret},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.config)
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://keycloak.example.com/admin/realms/tenants", client.baseURL)
+		})
+	}
+}
+
+func TestClient_CreateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates user with attributes", func(t *testing.T) {
+		fake, client := newFakeKeycloak(t)
+
+		created, err := client.CreateUser(ctx, &userpool.User{
+			Username: "jane",
+			Email:    "jane@example.com",
+			Enabled:  true,
+			Attributes: map[string]string{
+				userpool.AttributeGivenName:  "Jane",
+				userpool.AttributeFamilyName: "Doe",
+				userpool.AttributeLocale:     "en",
+				"custom:department":          "engineering",
+			},
+		})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, created.Sub)
+		assert.Equal(t, "jane@example.com", created.Email)
+		assert.True(t, created.Enabled)
+		assert.Equal(t, statusConfirmed, created.Status)
+		assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), created.CreatedAt.UTC())
+		assert.Equal(t, map[string]string{
+			userpool.AttributeGivenName:  "Jane",
+			userpool.AttributeFamilyName: "Doe",
+			userpool.AttributeLocale:     "en",
+			"custom:department":          "engineering",
+		}, created.Attributes)
+
+		stored := fake.users[created.Sub]
+		assert.Equal(t, "jane@example.com", stored.Username)
+		assert.True(t, stored.EmailVerified)
+		assert.Equal(t, "Jane", stored.FirstName)
+		assert.Equal(t, []string{"engineering"}, stored.Attributes["department"])
+		assert.Empty(t, fake.emailsSent)
+	})
+
+	t.Run("sends invitation email", func(t *testing.T) {
+		fake, client := newFakeKeycloak(t)
+
+		created, err := client.CreateUser(ctx, &userpool.User{
+			Email:                     "jane@example.com",
+			Enabled:                   true,
+			InvitationDeliveryMediums: []string{"EMAIL"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{updatePasswordAction}, fake.emailsSent[created.Sub])
+		assert.True(t, created.PasswordChangeRequired)
+		assert.Equal(t, statusForceChangePassword, created.Status)
+	})
+
+	t.Run("SMS invitations are not supported", func(t *testing.T) {
+		fake, client := newFakeKeycloak(t)
+
+		_, err := client.CreateUser(ctx, &userpool.User{
+			Email:                     "jane@example.com",
+			InvitationDeliveryMediums: []string{"SMS"},
+		})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "delivery medium SMS is not supported")
+		assert.Empty(t, fake.users)
+	})
+
+	t.Run("existing user", func(t *testing.T) {
+		fake, client := newFakeKeycloak(t)
+		fake.addUser(userRepresentation{Username: "jane@example.com", Email: "jane@example.com"})
+
+		_, err := client.CreateUser(ctx, &userpool.User{Email: "jane@example.com"})
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, userpool.ErrUserExists))
+	})
+
+	t.Run("missing email", func(t *testing.T) {
+		_, client := newFakeKeycloak(t)
+
+		_, err := client.CreateUser(ctx, &userpool.User{Username: "jane"})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "email cannot be empty")
+	})
+}
+
+func TestClient_GetUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{
+		Username: "jane@example.com",
+		Email:    "jane@example.com",
+		Enabled:  true,
+		Attributes: map[string][]string{
+			"department": {"engineering"},
+			"LDAP_ID":    {"cn=jane"},
+		},
+		Totp: true,
+	})
+
+	for _, identifier := range []string{id, "jane@example.com", "Jane@Example.com"} {
+		t.Run(identifier, func(t *testing.T) {
+			user, err := client.GetUser(ctx, identifier)
+
+			require.NoError(t, err)
+			assert.Equal(t, id, user.Sub)
+			assert.Equal(t, map[string]string{"custom:department": "engineering"}, user.Attributes)
+			assert.Equal(t, []string{totpMFAMethod}, user.MFAMethods)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetUser(ctx, "john@example.com")
+
+		require.Error(t, err)
+		assert.True(t, isStatus(err, http.StatusNotFound))
+	})
+}
+
+func TestClient_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{
+		Username:   "jane@example.com",
+		Email:      "jane@example.com",
+		Enabled:    true,
+		FirstName:  "Jane",
+		Attributes: map[string][]string{"department": {"engineering"}, "team": {"platform"}},
+	})
+
+	err := client.UpdateUser(ctx, &userpool.User{
+		Sub:     id,
+		Email:   "jane@example.com",
+		Enabled: false,
+		Attributes: map[string]string{
+			userpool.AttributeFamilyName: "Doe",
+			"custom:department":          "sales",
+		},
+	})
+
+	require.NoError(t, err)
+	stored := fake.users[id]
+	assert.False(t, stored.Enabled)
+	assert.Equal(t, "Jane", stored.FirstName)
+	assert.Equal(t, "Doe", stored.LastName)
+	assert.Equal(t, map[string][]string{"department": {"sales"}, "team": {"platform"}}, stored.Attributes)
+}
+
+func TestClient_DeleteUserAttributes(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{
+		Username:   "jane@example.com",
+		Email:      "jane@example.com",
+		FirstName:  "Jane",
+		LastName:   "Doe",
+		Attributes: map[string][]string{"department": {"engineering"}, "team": {"platform"}},
+	})
+
+	err := client.DeleteUserAttributes(ctx, id, []string{userpool.AttributeGivenName, "custom:team"})
+
+	require.NoError(t, err)
+	stored := fake.users[id]
+	assert.Empty(t, stored.FirstName)
+	assert.Equal(t, "Doe", stored.LastName)
+	assert.Equal(t, map[string][]string{"department": {"engineering"}}, stored.Attributes)
+}
+
+func TestClient_Passwords(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{Username: "jane@example.com", Email: "jane@example.com"})
+
+	t.Run("temporary password", func(t *testing.T) {
+		require.NoError(t, client.SetUserPassword(ctx, id, "Temp123!", false))
+
+		assert.Equal(t, credentialRepresentation{Type: "password", Value: "Temp123!", Temporary: true}, fake.passwords[id])
+		user, err := client.GetUser(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, user.PasswordChangeRequired)
+	})
+
+	t.Run("permanent password", func(t *testing.T) {
+		require.NoError(t, client.SetUserPassword(ctx, "jane@example.com", "Perm123!", true))
+
+		assert.False(t, fake.passwords[id].Temporary)
+	})
+
+	t.Run("resend invitation", func(t *testing.T) {
+		require.NoError(t, client.ResendInvitation(ctx, id, []string{"EMAIL"}))
+
+		assert.Equal(t, []string{updatePasswordAction}, fake.emailsSent[id])
+	})
+}
+
+func TestClient_DisableAndDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{Username: "jane@example.com", Email: "jane@example.com", Enabled: true})
+
+	require.NoError(t, client.DisableUser(ctx, id))
+	assert.False(t, fake.users[id].Enabled)
+
+	require.NoError(t, client.DeleteUser(ctx, "jane@example.com"))
+	assert.Empty(t, fake.users)
+
+	require.NoError(t, client.DeleteUser(ctx, id), "deleting a missing user is not an error")
+}
+
+func TestClient_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	for i := range pageSize + 5 {
+		email := fmt.Sprintf("user-%03d@example.com", i)
+		fake.addUser(userRepresentation{Username: email, Email: email})
+	}
+
+	users, err := client.ListUsers(ctx)
+
+	require.NoError(t, err)
+	assert.Len(t, users, pageSize+5)
+	assert.Equal(t, "user-000@example.com", users[0].Email)
+	assert.Equal(t, "user-104@example.com", users[len(users)-1].Email)
+	assert.Equal(t, 1, fake.tokens, "the access token should be reused across requests")
+}
+
+func TestClient_ListSchemaAttributes(t *testing.T) {
+	fake, client := newFakeKeycloak(t)
+	fake.profile = append(fake.profile, "locale", "department")
+
+	names, err := client.ListSchemaAttributes(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "given_name", "family_name", "locale", "custom:department"}, names)
+}
+
+func TestClient_Groups(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeKeycloak(t)
+	id := fake.addUser(userRepresentation{Username: "jane@example.com", Email: "jane@example.com"})
+
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins-readonly"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{
+		Name:        "admins",
+		Description: "Workspace administrators",
+		Precedence:  ptr.To(int32(1)),
+	}))
+	admins := fake.groupByName("admins")
+	require.NotNil(t, admins)
+	assert.Equal(t, map[string][]string{
+		descriptionGroupAttribute: {"Workspace administrators"},
+		precedenceGroupAttribute:  {"1"},
+	}, admins.Attributes)
+
+	t.Run("update existing group", func(t *testing.T) {
+		require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins", Description: "Admins"}))
+
+		assert.Len(t, fake.groups, 2)
+		assert.Equal(t, map[string][]string{descriptionGroupAttribute: {"Admins"}},
+			fake.groupByName("admins").Attributes)
+	})
+
+	t.Run("membership", func(t *testing.T) {
+		require.NoError(t, client.AddUserToGroup(ctx, id, "admins"))
+		require.NoError(t, client.AddUserToGroup(ctx, id, "admins-readonly"))
+
+		groups, err := client.ListGroupsForUser(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins", "admins-readonly"}, groups)
+
+		require.NoError(t, client.RemoveUserFromGroup(ctx, id, "admins-readonly"))
+		groups, err = client.ListGroupsForUser(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admins"}, groups)
+	})
+
+	t.Run("membership of missing group", func(t *testing.T) {
+		err := client.AddUserToGroup(ctx, id, "auditors")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "group auditors not found")
+	})
+
+	t.Run("delete group", func(t *testing.T) {
+		require.NoError(t, client.DeleteGroup(ctx, "admins"))
+		assert.Nil(t, fake.groupByName("admins"))
+		assert.NotNil(t, fake.groupByName("admins-readonly"))
+
+		// Deleting a missing group is not an error
+		require.NoError(t, client.DeleteGroup(ctx, "admins"))
+	})
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	_, client := newFakeKeycloak(t)
+	client.http = http.DefaultClient
+
+	_, err := client.ListUsers(context.Background())
+
+	require.Error(t, err)
+	assert.True(t, isStatus(err, http.StatusUnauthorized))
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		_, client := newFakeKeycloak(t)
+		return client
+	})
+}
@@ -0,0 +1,692 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// pageSize is the number of resources requested per page
+const pageSize = 100
+
+// ErrNotSupported is returned for operations SCIM has no equivalent for
+var ErrNotSupported = errors.New("not supported by SCIM")
+
+// standardPaths maps the standard user pool attributes to their SCIM attribute paths
+var standardPaths = map[string]string{
+	userpool.AttributeGivenName:         "name.givenName",
+	userpool.AttributeFamilyName:        "name.familyName",
+	userpool.AttributePhoneNumber:       "phoneNumbers",
+	userpool.AttributeLocale:            "locale",
+	userpool.AttributePreferredUsername: "nickName",
+}
+
+// Config configures the connection to a SCIM 2.0 service provider
+type Config struct {
+	// URL is the SCIM base URL, e.g. https://example.okta.com/scim/v2
+	URL string
+	// Token is sent as bearer token with every request
+	Token string
+	// ExtensionSchema is the schema extension holding custom attributes.
+	// Defaults to DefaultExtensionSchema.
+	ExtensionSchema string
+	// HTTPClient sends all requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Client implements the userpool.Client interface for a SCIM 2.0 service provider
+type Client struct {
+	http      *http.Client
+	baseURL   string
+	token     string
+	extension string
+}
+
+var _ userpool.Client = &Client{}
+
+// APIError is returned when the service provider responds with an unexpected status code
+type APIError struct {
+	StatusCode int
+	SCIMType   string
+	Detail     string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("SCIM service provider returned status %d", e.StatusCode)
+	if e.SCIMType != "" {
+		msg += " (" + e.SCIMType + ")"
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// isStatus reports whether err is an APIError with the given status code
+func isStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// NewClient creates a new SCIM client
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("SCIM URL cannot be empty")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("SCIM token cannot be empty")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	extension := cfg.ExtensionSchema
+	if extension == "" {
+		extension = DefaultExtensionSchema
+	}
+
+	return &Client{
+		http:      httpClient,
+		baseURL:   strings.TrimSuffix(cfg.URL, "/"),
+		token:     cfg.Token,
+		extension: extension,
+	}, nil
+}
+
+// schemaResource is a SCIM Schema resource
+type schemaResource struct {
+	Attributes []struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+}
+
+// CreateUser provisions a new user, using the email as userName. Invitations are not supported.
+func (c *Client) CreateUser(ctx context.Context, user *userpool.User) (*userpool.User, error) {
+	if user == nil {
+		return nil, fmt.Errorf("user cannot be nil")
+	}
+	if user.Email == "" {
+		return nil, fmt.Errorf("email cannot be empty")
+	}
+	if len(user.InvitationDeliveryMediums) > 0 {
+		return nil, fmt.Errorf("invitations are %w", ErrNotSupported)
+	}
+
+	resource := c.toResource(user)
+	var created User
+	if err := c.do(ctx, http.MethodPost, "/Users", nil, resource, &created); err != nil {
+		// Report existing users so the caller can decide whether to adopt them
+		if isStatus(err, http.StatusConflict) {
+			return nil, fmt.Errorf("failed to create user %s: %w", user.Email, userpool.ErrUserExists)
+		}
+		return nil, fmt.Errorf("failed to create user %s: %w", user.Email, err)
+	}
+	return c.fromResource(&created), nil
+}
+
+// GetUser retrieves a user by SCIM id or userName
+func (c *Client) GetUser(ctx context.Context, username string) (*userpool.User, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	resource, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	return c.fromResource(resource), nil
+}
+
+// UpdateUser replaces the email, attributes and active state of an existing user with a PATCH
+func (c *Client) UpdateUser(ctx context.Context, user *userpool.User) error {
+	if user == nil {
+		return fmt.Errorf("user cannot be nil")
+	}
+	identifier := user.Sub
+	if identifier == "" {
+		identifier = user.Email
+	}
+	if identifier == "" {
+		return fmt.Errorf("user sub or email must be set")
+	}
+
+	id, err := c.userID(ctx, identifier)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", identifier, err)
+	}
+
+	operations := []PatchOperation{
+		{Op: "replace", Path: "active", Value: user.Enabled},
+		{Op: "replace", Path: "emails", Value: []MultiValue{{Value: user.Email, Type: "work", Primary: true}}},
+	}
+	for name, value := range user.Attributes {
+		switch {
+		case name == userpool.AttributePhoneNumber:
+			operations = append(operations, PatchOperation{Op: "replace", Path: standardPaths[name],
+				Value: []MultiValue{{Value: value, Type: "work", Primary: true}}})
+		case standardPaths[name] != "":
+			operations = append(operations, PatchOperation{Op: "replace", Path: standardPaths[name], Value: value})
+		default:
+			operations = append(operations, PatchOperation{Op: "replace", Path: c.extensionPath(name), Value: value})
+		}
+	}
+
+	if err := c.patch(ctx, "/Users/"+url.PathEscape(id), operations); err != nil {
+		return fmt.Errorf("failed to update user %s: %w", identifier, err)
+	}
+	return nil
+}
+
+// DeleteUserAttributes removes the named attributes from a user with a PATCH
+func (c *Client) DeleteUserAttributes(ctx context.Context, username string, names []string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	id, err := c.userID(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	operations := make([]PatchOperation, 0, len(names))
+	for _, name := range names {
+		attrPath := standardPaths[name]
+		if attrPath == "" {
+			attrPath = c.extensionPath(name)
+		}
+		operations = append(operations, PatchOperation{Op: "remove", Path: attrPath})
+	}
+
+	if err := c.patch(ctx, "/Users/"+url.PathEscape(id), operations); err != nil {
+		return fmt.Errorf("failed to delete attributes of user %s: %w", username, err)
+	}
+	return nil
+}
+
+// ResendInvitation is not supported, SCIM has no notion of invitations
+func (c *Client) ResendInvitation(_ context.Context, _ string, _ []string) error {
+	return fmt.Errorf("invitations are %w", ErrNotSupported)
+}
+
+// SetUserPassword sets a user's password. Only permanent passwords are supported.
+func (c *Client) SetUserPassword(ctx context.Context, username, password string, permanent bool) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+	if !permanent {
+		return fmt.Errorf("temporary passwords are %w", ErrNotSupported)
+	}
+
+	id, err := c.userID(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	operations := []PatchOperation{{Op: "replace", Path: "password", Value: password}}
+	if err := c.patch(ctx, "/Users/"+url.PathEscape(id), operations); err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
+	return nil
+}
+
+// DisableUser deactivates a user, keeping the identity
+func (c *Client) DisableUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	id, err := c.userID(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	operations := []PatchOperation{{Op: "replace", Path: "active", Value: false}}
+	if err := c.patch(ctx, "/Users/"+url.PathEscape(id), operations); err != nil {
+		return fmt.Errorf("failed to disable user %s: %w", username, err)
+	}
+	return nil
+}
+
+// DeleteUser deprovisions a user, succeeding when the user does not exist
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	id, err := c.userID(ctx, username)
+	if isStatus(err, http.StatusNotFound) {
+		// User doesn't exist, this is not an error for deletion
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	err = c.do(ctx, http.MethodDelete, "/Users/"+url.PathEscape(id), nil, nil, nil)
+	if err != nil && !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to delete user %s: %w", username, err)
+	}
+	return nil
+}
+
+// ListUsers lists all users of the service provider
+func (c *Client) ListUsers(ctx context.Context) ([]*userpool.User, error) {
+	var users []*userpool.User
+
+	for startIndex := 1; ; {
+		var page ListResponse[User]
+		query := url.Values{
+			"startIndex": {strconv.Itoa(startIndex)},
+			"count":      {strconv.Itoa(pageSize)},
+		}
+		if err := c.do(ctx, http.MethodGet, "/Users", query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for i := range page.Resources {
+			users = append(users, c.fromResource(&page.Resources[i]))
+		}
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || startIndex > page.TotalResults {
+			break
+		}
+	}
+
+	return users, nil
+}
+
+// ListSchemaAttributes lists the standard attributes and the attributes of the extension schema
+func (c *Client) ListSchemaAttributes(ctx context.Context) ([]string, error) {
+	names := []string{"email"}
+	for name := range standardPaths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var schema schemaResource
+	err := c.do(ctx, http.MethodGet, "/Schemas/"+url.PathEscape(c.extension), nil, nil, &schema)
+	if err != nil && !isStatus(err, http.StatusNotFound) {
+		return nil, fmt.Errorf("failed to get schema %s: %w", c.extension, err)
+	}
+	for _, attr := range schema.Attributes {
+		names = append(names, userpool.CustomAttributePrefix+attr.Name)
+	}
+	return names, nil
+}
+
+// GetGroup retrieves a group by displayName. The owner is kept in the externalId.
+func (c *Client) GetGroup(ctx context.Context, name string) (*userpool.Group, error) {
+	if name == "" {
+		return nil, fmt.Errorf("group name cannot be empty")
+	}
+
+	group, err := c.findGroup(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group %s: %w", name, err)
+	}
+	if group == nil {
+		return nil, fmt.Errorf("%w: %s", userpool.ErrGroupNotFound, name)
+	}
+	return &userpool.Group{Name: group.DisplayName, Owner: group.ExternalID}, nil
+}
+
+// CreateGroup creates a group if no group with the same displayName exists, recording the owner
+// in the externalId. SCIM groups have no description or precedence, so those are ignored.
+func (c *Client) CreateGroup(ctx context.Context, group *userpool.Group) error {
+	if group == nil {
+		return fmt.Errorf("group cannot be nil")
+	}
+	if group.Name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	existing, err := c.findGroup(ctx, group.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get group %s: %w", group.Name, err)
+	}
+	if existing != nil {
+		if existing.ExternalID == group.Owner {
+			return nil
+		}
+		operations := []PatchOperation{{Op: "replace", Path: "externalId", Value: group.Owner}}
+		if err := c.patch(ctx, "/Groups/"+url.PathEscape(existing.ID), operations); err != nil {
+			return fmt.Errorf("failed to update group %s: %w", group.Name, err)
+		}
+		return nil
+	}
+
+	resource := &Group{Schemas: []string{GroupSchema}, ExternalID: group.Owner, DisplayName: group.Name}
+	if err := c.do(ctx, http.MethodPost, "/Groups", nil, resource, nil); err != nil {
+		return fmt.Errorf("failed to create group %s: %w", group.Name, err)
+	}
+	return nil
+}
+
+// DeleteGroup removes a group
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	group, err := c.findGroup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get group %s: %w", name, err)
+	}
+	if group == nil {
+		// Group doesn't exist, this is not an error for deletion
+		return nil
+	}
+	if err := c.do(ctx, http.MethodDelete, "/Groups/"+url.PathEscape(group.ID), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete group %s: %w", name, err)
+	}
+	return nil
+}
+
+// AddUserToGroup adds a user to the members of a group
+func (c *Client) AddUserToGroup(ctx context.Context, username, group string) error {
+	userID, groupID, err := c.membershipIDs(ctx, username, group)
+	if err != nil {
+		return err
+	}
+	operations := []PatchOperation{{Op: "add", Path: "members", Value: []MultiValue{{Value: userID}}}}
+	if err := c.patch(ctx, "/Groups/"+url.PathEscape(groupID), operations); err != nil {
+		return fmt.Errorf("failed to add user %s to group %s: %w", username, group, err)
+	}
+	return nil
+}
+
+// RemoveUserFromGroup removes a user from the members of a group
+func (c *Client) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	userID, groupID, err := c.membershipIDs(ctx, username, group)
+	if err != nil {
+		return err
+	}
+	operations := []PatchOperation{{Op: "remove", Path: fmt.Sprintf("members[value eq %s]", quote(userID))}}
+	if err := c.patch(ctx, "/Groups/"+url.PathEscape(groupID), operations); err != nil {
+		return fmt.Errorf("failed to remove user %s from group %s: %w", username, group, err)
+	}
+	return nil
+}
+
+// ListGroupsForUser lists the displayNames of the groups a user belongs to
+func (c *Client) ListGroupsForUser(ctx context.Context, username string) ([]string, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	resource, err := c.lookupUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	groups := make([]string, 0, len(resource.Groups))
+	for _, group := range resource.Groups {
+		groups = append(groups, group.Display)
+	}
+	return groups, nil
+}
+
+// membershipIDs resolves the SCIM ids of a user and a group
+func (c *Client) membershipIDs(ctx context.Context, username, group string) (string, string, error) {
+	if username == "" {
+		return "", "", fmt.Errorf("username cannot be empty")
+	}
+	if group == "" {
+		return "", "", fmt.Errorf("group name cannot be empty")
+	}
+
+	userID, err := c.userID(ctx, username)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	groupRes, err := c.findGroup(ctx, group)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get group %s: %w", group, err)
+	}
+	if groupRes == nil {
+		return "", "", fmt.Errorf("group %s not found", group)
+	}
+	return userID, groupRes.ID, nil
+}
+
+// userID returns the SCIM id of a user given its id or userName
+func (c *Client) userID(ctx context.Context, identifier string) (string, error) {
+	resource, err := c.lookupUser(ctx, identifier)
+	if err != nil {
+		return "", err
+	}
+	return resource.ID, nil
+}
+
+// lookupUser finds a user by SCIM id, falling back to a userName filter
+func (c *Client) lookupUser(ctx context.Context, identifier string) (*User, error) {
+	var resource User
+	err := c.do(ctx, http.MethodGet, "/Users/"+url.PathEscape(identifier), nil, nil, &resource)
+	if err == nil {
+		return &resource, nil
+	}
+	if !isStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+
+	var page ListResponse[User]
+	query := url.Values{"filter": {"userName eq " + quote(identifier)}}
+	if err := c.do(ctx, http.MethodGet, "/Users", query, nil, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Resources) == 0 {
+		return nil, fmt.Errorf("%w: %w", userpool.ErrUserNotFound,
+			&APIError{StatusCode: http.StatusNotFound, Detail: "no user matches " + identifier})
+	}
+	return &page.Resources[0], nil
+}
+
+// findGroup finds a group by displayName, returning nil when it does not exist
+func (c *Client) findGroup(ctx context.Context, name string) (*Group, error) {
+	var page ListResponse[Group]
+	query := url.Values{
+		"filter":             {"displayName eq " + quote(name)},
+		"excludedAttributes": {"members"},
+	}
+	if err := c.do(ctx, http.MethodGet, "/Groups", query, nil, &page); err != nil {
+		return nil, err
+	}
+	for i := range page.Resources {
+		if page.Resources[i].DisplayName == name {
+			return &page.Resources[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// patch sends a PatchOp message with the given operations
+func (c *Client) patch(ctx context.Context, resourcePath string, operations []PatchOperation) error {
+	request := PatchOp{Schemas: []string{PatchOpSchema}, Operations: operations}
+	return c.do(ctx, http.MethodPatch, resourcePath, nil, request, nil)
+}
+
+// do sends a request to the service provider, encoding in and decoding the response into out
+// when they are not nil
+func (c *Client) do(ctx context.Context, method, resourcePath string, query url.Values, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	reqURL := c.baseURL + resourcePath
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", ContentType)
+	if in != nil {
+		req.Header.Set("Content-Type", ContentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errResp ErrorResponse
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &errResp) == nil && (errResp.Detail != "" || errResp.SCIMType != "") {
+			apiErr.SCIMType = errResp.SCIMType
+			apiErr.Detail = errResp.Detail
+		} else {
+			apiErr.Detail = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// extensionPath returns the attribute path of a custom attribute in the extension schema
+func (c *Client) extensionPath(name string) string {
+	return c.extension + ":" + strings.TrimPrefix(name, userpool.CustomAttributePrefix)
+}
+
+// toResource converts a user pool user into a SCIM User resource
+func (c *Client) toResource(user *userpool.User) *User {
+	active := user.Enabled
+	resource := &User{
+		Schemas:  []string{UserSchema},
+		UserName: user.Email,
+		Active:   &active,
+		Emails:   []MultiValue{{Value: user.Email, Type: "work", Primary: true}},
+	}
+
+	for name, value := range user.Attributes {
+		switch name {
+		case userpool.AttributeGivenName, userpool.AttributeFamilyName:
+			if resource.Name == nil {
+				resource.Name = &Name{}
+			}
+			if name == userpool.AttributeGivenName {
+				resource.Name.GivenName = value
+			} else {
+				resource.Name.FamilyName = value
+			}
+		case userpool.AttributePhoneNumber:
+			resource.PhoneNumbers = []MultiValue{{Value: value, Type: "work", Primary: true}}
+		case userpool.AttributeLocale:
+			resource.Locale = value
+		case userpool.AttributePreferredUsername:
+			resource.NickName = value
+		default:
+			if resource.Extension == nil {
+				resource.Extension = map[string]string{}
+				resource.Schemas = append(resource.Schemas, c.extension)
+			}
+			resource.Extension[c.extensionPath(name)] = value
+		}
+	}
+	return resource
+}
+
+// fromResource converts a SCIM User resource into a user pool user. Attributes of the
+// extension schema are reported as custom attributes.
+func (c *Client) fromResource(resource *User) *userpool.User {
+	user := &userpool.User{
+		Username:   resource.UserName,
+		Email:      primaryValue(resource.Emails),
+		Enabled:    resource.Active == nil || *resource.Active,
+		Sub:        resource.ID,
+		Attributes: map[string]string{},
+	}
+	if user.Email == "" {
+		user.Email = resource.UserName
+	}
+
+	if resource.Name != nil {
+		setIfNotEmpty(user.Attributes, userpool.AttributeGivenName, resource.Name.GivenName)
+		setIfNotEmpty(user.Attributes, userpool.AttributeFamilyName, resource.Name.FamilyName)
+	}
+	setIfNotEmpty(user.Attributes, userpool.AttributePhoneNumber, primaryValue(resource.PhoneNumbers))
+	setIfNotEmpty(user.Attributes, userpool.AttributeLocale, resource.Locale)
+	setIfNotEmpty(user.Attributes, userpool.AttributePreferredUsername, resource.NickName)
+	for key, value := range resource.Extension {
+		if name, ok := strings.CutPrefix(key, c.extension+":"); ok {
+			user.Attributes[userpool.CustomAttributePrefix+name] = value
+		}
+	}
+
+	if resource.Meta != nil {
+		user.CreatedAt = resource.Meta.Created
+		user.LastModifiedAt = resource.Meta.LastModified
+	}
+	return user
+}
+
+// primaryValue returns the primary value of a multi-valued attribute, or its first value
+func primaryValue(values []MultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// setIfNotEmpty sets attributes[name] when value is not empty
+func setIfNotEmpty(attributes map[string]string, name, value string) {
+	if value != "" {
+		attributes[name] = value
+	}
+}
+
+// quote returns s as a SCIM filter string literal
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,623 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/conformance"
+)
+
+const testToken = "test-scim-token"
+
+// fakeProvider is an in-memory fake of a SCIM 2.0 service provider
+type fakeProvider struct {
+	mu        sync.Mutex
+	nextID    int
+	users     map[string]*User
+	groups    map[string]*Group
+	passwords map[string]string
+	schema    []string
+	patches   []PatchOp
+}
+
+func newFakeProvider(t *testing.T) (*fakeProvider, *Client) {
+	fake := &fakeProvider{
+		users:     map[string]*User{},
+		groups:    map[string]*Group{},
+		passwords: map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scim/v2/Users", fake.authorized(fake.createUser))
+	mux.HandleFunc("GET /scim/v2/Users", fake.authorized(fake.listUsers))
+	mux.HandleFunc("GET /scim/v2/Users/{id}", fake.authorized(fake.getUser))
+	mux.HandleFunc("PATCH /scim/v2/Users/{id}", fake.authorized(fake.patchUser))
+	mux.HandleFunc("DELETE /scim/v2/Users/{id}", fake.authorized(fake.deleteUser))
+	mux.HandleFunc("GET /scim/v2/Groups", fake.authorized(fake.listGroups))
+	mux.HandleFunc("POST /scim/v2/Groups", fake.authorized(fake.createGroup))
+	mux.HandleFunc("PATCH /scim/v2/Groups/{id}", fake.authorized(fake.patchGroup))
+	mux.HandleFunc("DELETE /scim/v2/Groups/{id}", fake.authorized(fake.deleteGroup))
+	mux.HandleFunc("GET /scim/v2/Schemas/{id}", fake.authorized(fake.getSchema))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{URL: server.URL + "/scim/v2/", Token: testToken, HTTPClient: server.Client()})
+	require.NoError(t, err)
+	return fake, client
+}
+
+func (f *fakeProvider) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			scimError(w, http.StatusUnauthorized, "", "invalid token")
+			return
+		}
+		if r.Body != http.NoBody && r.Header.Get("Content-Type") != ContentType {
+			scimError(w, http.StatusUnsupportedMediaType, "", "expected "+ContentType)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		handler(w, r)
+	}
+}
+
+func (f *fakeProvider) addUser(resource User) string {
+	f.nextID++
+	resource.ID = fmt.Sprintf("user-%03d", f.nextID)
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	resource.Meta = &Meta{Created: created, LastModified: created}
+	f.users[resource.ID] = &resource
+	return resource.ID
+}
+
+func (f *fakeProvider) addGroup(name string) string {
+	f.nextID++
+	id := fmt.Sprintf("group-%03d", f.nextID)
+	f.groups[id] = &Group{ID: id, DisplayName: name}
+	return id
+}
+
+// withGroups returns a copy of the user with its read-only groups attribute filled in
+func (f *fakeProvider) withGroups(resource *User) User {
+	user := *resource
+	user.Groups = nil
+	for _, group := range f.groups {
+		for _, member := range group.Members {
+			if member.Value == user.ID {
+				user.Groups = append(user.Groups, MultiValue{Value: group.ID, Display: group.DisplayName})
+			}
+		}
+	}
+	sort.Slice(user.Groups, func(i, j int) bool { return user.Groups[i].Display < user.Groups[j].Display })
+	return user
+}
+
+func (f *fakeProvider) createUser(w http.ResponseWriter, r *http.Request) {
+	var resource User
+	if err := json.NewDecoder(r.Body).Decode(&resource); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	for _, existing := range f.users {
+		if strings.EqualFold(existing.UserName, resource.UserName) {
+			scimError(w, http.StatusConflict, "uniqueness", "userName is already taken")
+			return
+		}
+	}
+	id := f.addUser(resource)
+	writeSCIM(w, http.StatusCreated, f.users[id])
+}
+
+func (f *fakeProvider) listUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userName, filtered := parseEqFilter(query.Get("filter"), "userName")
+
+	var matches []User
+	for _, resource := range f.users {
+		if filtered && !strings.EqualFold(resource.UserName, userName) {
+			continue
+		}
+		matches = append(matches, f.withGroups(resource))
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	startIndex, err := strconv.Atoi(query.Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil {
+		count = len(matches)
+	}
+	first := min(startIndex-1, len(matches))
+	end := min(first+count, len(matches))
+	writeSCIM(w, http.StatusOK, ListResponse[User]{
+		TotalResults: len(matches),
+		StartIndex:   startIndex,
+		ItemsPerPage: end - first,
+		Resources:    matches[first:end],
+	})
+}
+
+func (f *fakeProvider) getUser(w http.ResponseWriter, r *http.Request) {
+	resource, ok := f.users[r.PathValue("id")]
+	if !ok {
+		scimError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+	writeSCIM(w, http.StatusOK, f.withGroups(resource))
+}
+
+func (f *fakeProvider) patchUser(w http.ResponseWriter, r *http.Request) {
+	resource, ok := f.users[r.PathValue("id")]
+	if !ok {
+		scimError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+	var request PatchOp
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	f.patches = append(f.patches, request)
+
+	for _, op := range request.Operations {
+		remove := op.Op == "remove"
+		switch {
+		case op.Path == "active":
+			active, _ := op.Value.(bool)
+			resource.Active = &active
+		case op.Path == "emails":
+			resource.Emails = nil
+			decodeValue(op.Value, &resource.Emails)
+		case op.Path == "phoneNumbers":
+			resource.PhoneNumbers = nil
+			if !remove {
+				decodeValue(op.Value, &resource.PhoneNumbers)
+			}
+		case op.Path == "name.givenName" || op.Path == "name.familyName":
+			if resource.Name == nil {
+				resource.Name = &Name{}
+			}
+			value, _ := op.Value.(string)
+			if op.Path == "name.givenName" {
+				resource.Name.GivenName = value
+			} else {
+				resource.Name.FamilyName = value
+			}
+		case op.Path == "locale":
+			resource.Locale, _ = op.Value.(string)
+		case op.Path == "nickName":
+			resource.NickName, _ = op.Value.(string)
+		case op.Path == "password":
+			f.passwords[resource.ID], _ = op.Value.(string)
+		case strings.HasPrefix(op.Path, "urn:"):
+			if remove {
+				delete(resource.Extension, op.Path)
+				continue
+			}
+			if resource.Extension == nil {
+				resource.Extension = map[string]string{}
+			}
+			resource.Extension[op.Path], _ = op.Value.(string)
+		default:
+			scimError(w, http.StatusBadRequest, "invalidPath", op.Path)
+			return
+		}
+	}
+	writeSCIM(w, http.StatusOK, f.withGroups(resource))
+}
+
+func (f *fakeProvider) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, ok := f.users[id]; !ok {
+		scimError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+	delete(f.users, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeProvider) listGroups(w http.ResponseWriter, r *http.Request) {
+	displayName, filtered := parseEqFilter(r.URL.Query().Get("filter"), "displayName")
+	resources := []Group{}
+	for _, group := range f.groups {
+		if !filtered || group.DisplayName == displayName {
+			resources = append(resources, *group)
+		}
+	}
+	writeSCIM(w, http.StatusOK, ListResponse[Group]{TotalResults: len(resources), Resources: resources})
+}
+
+func (f *fakeProvider) createGroup(w http.ResponseWriter, r *http.Request) {
+	var group Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	id := f.addGroup(group.DisplayName)
+	f.groups[id].ExternalID = group.ExternalID
+	writeSCIM(w, http.StatusCreated, f.groups[id])
+}
+
+func (f *fakeProvider) patchGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := f.groups[r.PathValue("id")]
+	if !ok {
+		scimError(w, http.StatusNotFound, "", "group not found")
+		return
+	}
+	var request PatchOp
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		scimError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	for _, op := range request.Operations {
+		switch {
+		case op.Op == "add" && op.Path == "members":
+			var members []MultiValue
+			decodeValue(op.Value, &members)
+			group.Members = append(group.Members, members...)
+		case op.Op == "remove" && strings.HasPrefix(op.Path, "members["):
+			value, _ := parseEqFilter(strings.TrimSuffix(strings.TrimPrefix(op.Path, "members["), "]"), "value")
+			var kept []MultiValue
+			for _, member := range group.Members {
+				if member.Value != value {
+					kept = append(kept, member)
+				}
+			}
+			group.Members = kept
+		case op.Op == "replace" && op.Path == "externalId":
+			decodeValue(op.Value, &group.ExternalID)
+		default:
+			scimError(w, http.StatusBadRequest, "invalidPath", op.Path)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeProvider) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	delete(f.groups, r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeProvider) getSchema(w http.ResponseWriter, r *http.Request) {
+	if f.schema == nil || r.PathValue("id") != DefaultExtensionSchema {
+		scimError(w, http.StatusNotFound, "", "schema not found")
+		return
+	}
+	attributes := make([]map[string]string, 0, len(f.schema))
+	for _, name := range f.schema {
+		attributes = append(attributes, map[string]string{"name": name})
+	}
+	writeSCIM(w, http.StatusOK, map[string]any{"id": DefaultExtensionSchema, "attributes": attributes})
+}
+
+// parseEqFilter parses a filter of the form <attribute> eq "<value>"
+func parseEqFilter(filter, attribute string) (string, bool) {
+	quoted, ok := strings.CutPrefix(filter, attribute+" eq ")
+	if !ok {
+		return "", false
+	}
+	var value string
+	if err := json.Unmarshal([]byte(quoted), &value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+func decodeValue(value any, out any) {
+	data, _ := json.Marshal(value)
+	_ = json.Unmarshal(data, out)
+}
+
+func writeSCIM(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func scimError(w http.ResponseWriter, status int, scimType, detail string) {
+	writeSCIM(w, status, map[string]any{
+		"schemas":  []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+		"status":   strconv.Itoa(status),
+		"scimType": scimType,
+		"detail":   detail,
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(Config{Token: testToken})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SCIM URL cannot be empty")
+
+	_, err = NewClient(Config{URL: "https://idp.example.com/scim/v2"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SCIM token cannot be empty")
+
+	client, err := NewClient(Config{URL: "https://idp.example.com/scim/v2/", Token: testToken})
+	require.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/scim/v2", client.baseURL)
+	assert.Equal(t, DefaultExtensionSchema, client.extension)
+}
+
+func TestClient_CreateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates user with attributes", func(t *testing.T) {
+		fake, client := newFakeProvider(t)
+
+		created, err := client.CreateUser(ctx, &userpool.User{
+			Username: "jane",
+			Email:    "jane@example.com",
+			Enabled:  true,
+			Attributes: map[string]string{
+				userpool.AttributeGivenName:   "Jane",
+				userpool.AttributeFamilyName:  "Doe",
+				userpool.AttributePhoneNumber: "+15555550100",
+				"custom:department":           "engineering",
+			},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "user-001", created.Sub)
+		assert.Equal(t, "jane@example.com", created.Email)
+		assert.True(t, created.Enabled)
+		assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), created.CreatedAt)
+		assert.Equal(t, map[string]string{
+			userpool.AttributeGivenName:   "Jane",
+			userpool.AttributeFamilyName:  "Doe",
+			userpool.AttributePhoneNumber: "+15555550100",
+			"custom:department":           "engineering",
+		}, created.Attributes)
+
+		stored := fake.users[created.Sub]
+		assert.Equal(t, []string{UserSchema, DefaultExtensionSchema}, stored.Schemas)
+		assert.Equal(t, "jane@example.com", stored.UserName)
+	})
+
+	t.Run("existing user", func(t *testing.T) {
+		fake, client := newFakeProvider(t)
+		fake.addUser(User{UserName: "jane@example.com"})
+
+		_, err := client.CreateUser(ctx, &userpool.User{Email: "jane@example.com"})
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, userpool.ErrUserExists))
+	})
+
+	t.Run("invitations are not supported", func(t *testing.T) {
+		fake, client := newFakeProvider(t)
+
+		_, err := client.CreateUser(ctx, &userpool.User{
+			Email:                     "jane@example.com",
+			InvitationDeliveryMediums: []string{"EMAIL"},
+		})
+
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrNotSupported))
+		assert.Empty(t, fake.users)
+	})
+}
+
+func TestClient_GetUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeProvider(t)
+	id := fake.addUser(User{
+		UserName: "jane@example.com",
+		Emails:   []MultiValue{{Value: "jane.doe@example.com"}, {Value: "jane@example.com", Primary: true}},
+		Extension: map[string]string{
+			DefaultExtensionSchema + ":department":                                      "engineering",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "42",
+		},
+	})
+
+	for _, identifier := range []string{id, "jane@example.com"} {
+		t.Run(identifier, func(t *testing.T) {
+			user, err := client.GetUser(ctx, identifier)
+
+			require.NoError(t, err)
+			assert.Equal(t, id, user.Sub)
+			assert.Equal(t, "jane@example.com", user.Email)
+			assert.True(t, user.Enabled, "users without active are enabled")
+			assert.Equal(t, map[string]string{"custom:department": "engineering"}, user.Attributes)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetUser(ctx, "john@example.com")
+
+		require.Error(t, err)
+		assert.True(t, isStatus(err, http.StatusNotFound))
+	})
+}
+
+func TestClient_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeProvider(t)
+	id := fake.addUser(User{UserName: "jane@example.com"})
+
+	err := client.UpdateUser(ctx, &userpool.User{
+		Sub:     id,
+		Email:   "jane@example.com",
+		Enabled: false,
+		Attributes: map[string]string{
+			userpool.AttributeGivenName: "Jane",
+			userpool.AttributeLocale:    "en",
+			"custom:department":         "sales",
+		},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, fake.patches, 1)
+	assert.Equal(t, []string{PatchOpSchema}, fake.patches[0].Schemas)
+
+	user, err := client.GetUser(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, user.Enabled)
+	assert.Equal(t, map[string]string{
+		userpool.AttributeGivenName: "Jane",
+		userpool.AttributeLocale:    "en",
+		"custom:department":         "sales",
+	}, user.Attributes)
+
+	t.Run("remove attributes", func(t *testing.T) {
+		err := client.DeleteUserAttributes(ctx, id, []string{userpool.AttributeLocale, "custom:department"})
+
+		require.NoError(t, err)
+		user, err := client.GetUser(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{userpool.AttributeGivenName: "Jane"}, user.Attributes)
+	})
+}
+
+func TestClient_Passwords(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeProvider(t)
+	id := fake.addUser(User{UserName: "jane@example.com"})
+
+	require.NoError(t, client.SetUserPassword(ctx, "jane@example.com", "Perm123!", true))
+	assert.Equal(t, "Perm123!", fake.passwords[id])
+
+	err := client.SetUserPassword(ctx, id, "Temp123!", false)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+
+	err = client.ResendInvitation(ctx, id, []string{"EMAIL"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestClient_DisableAndDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeProvider(t)
+	id := fake.addUser(User{UserName: "jane@example.com"})
+
+	require.NoError(t, client.DisableUser(ctx, id))
+	require.NotNil(t, fake.users[id].Active)
+	assert.False(t, *fake.users[id].Active)
+
+	require.NoError(t, client.DeleteUser(ctx, "jane@example.com"))
+	assert.Empty(t, fake.users)
+
+	require.NoError(t, client.DeleteUser(ctx, id), "deleting a missing user is not an error")
+}
+
+func TestClient_ListUsers(t *testing.T) {
+	fake, client := newFakeProvider(t)
+	for i := range pageSize + 5 {
+		fake.addUser(User{UserName: fmt.Sprintf("user-%03d@example.com", i)})
+	}
+
+	users, err := client.ListUsers(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, users, pageSize+5)
+	assert.Equal(t, "user-000@example.com", users[0].Email)
+	assert.Equal(t, "user-104@example.com", users[len(users)-1].Email)
+}
+
+func TestClient_ListSchemaAttributes(t *testing.T) {
+	standard := []string{"email", "family_name", "given_name", "locale", "phone_number", "preferred_username"}
+
+	t.Run("without extension schema", func(t *testing.T) {
+		_, client := newFakeProvider(t)
+
+		names, err := client.ListSchemaAttributes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, standard, names)
+	})
+
+	t.Run("with extension schema", func(t *testing.T) {
+		fake, client := newFakeProvider(t)
+		fake.schema = []string{"department", "kcp_owner"}
+
+		names, err := client.ListSchemaAttributes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, append(standard, "custom:department", "custom:kcp_owner"), names)
+	})
+}
+
+func TestClient_Groups(t *testing.T) {
+	ctx := context.Background()
+	fake, client := newFakeProvider(t)
+	id := fake.addUser(User{UserName: "jane@example.com"})
+
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "auditors"}))
+	assert.Len(t, fake.groups, 2)
+
+	require.NoError(t, client.AddUserToGroup(ctx, id, "admins"))
+	require.NoError(t, client.AddUserToGroup(ctx, "jane@example.com", "auditors"))
+
+	groups, err := client.ListGroupsForUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admins", "auditors"}, groups)
+
+	require.NoError(t, client.RemoveUserFromGroup(ctx, id, "auditors"))
+	groups, err = client.ListGroupsForUser(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admins"}, groups)
+
+	err = client.AddUserToGroup(ctx, id, "developers")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "group developers not found")
+
+	require.NoError(t, client.DeleteGroup(ctx, "admins"))
+	require.NoError(t, client.DeleteGroup(ctx, "admins"))
+	assert.Len(t, fake.groups, 1)
+}
+
+func TestClient_Errors(t *testing.T) {
+	_, client := newFakeProvider(t)
+	client.token = "wrong-token"
+
+	_, err := client.ListUsers(context.Background())
+
+	require.Error(t, err)
+	assert.True(t, isStatus(err, http.StatusUnauthorized))
+	assert.Contains(t, err.Error(), "invalid token")
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		_, client := newFakeProvider(t)
+		return client
+	})
+}
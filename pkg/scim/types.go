@@ -0,0 +1,183 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// Schema URNs defined by RFC 7643 and RFC 7644
+	UserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// DefaultExtensionSchema is the schema extension custom attributes are stored in by default
+	DefaultExtensionSchema = "urn:ietf:params:scim:schemas:extension:kcp:2.0:User"
+
+	// ContentType is the media type of SCIM requests and responses
+	ContentType = "application/scim+json"
+)
+
+// User is a SCIM User resource
+type User struct {
+	Schemas      []string          `json:"schemas,omitempty"`
+	ID           string            `json:"id,omitempty"`
+	ExternalID   string            `json:"externalId,omitempty"`
+	UserName     string            `json:"userName,omitempty"`
+	Name         *Name             `json:"name,omitempty"`
+	DisplayName  string            `json:"displayName,omitempty"`
+	NickName     string            `json:"nickName,omitempty"`
+	Locale       string            `json:"locale,omitempty"`
+	Active       *bool             `json:"active,omitempty"`
+	Emails       []MultiValue      `json:"emails,omitempty"`
+	PhoneNumbers []MultiValue      `json:"phoneNumbers,omitempty"`
+	Groups       []MultiValue      `json:"groups,omitempty"`
+	Meta         *Meta             `json:"meta,omitempty"`
+	Extension    map[string]string `json:"-"`
+}
+
+// Name is the complex name attribute of a SCIM User
+type Name struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// MultiValue is an entry of a multi-valued SCIM attribute such as emails or members
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Meta is the meta attribute of a SCIM resource
+type Meta struct {
+	ResourceType string    `json:"resourceType,omitempty"`
+	Created      time.Time `json:"created,omitempty"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+	Location     string    `json:"location,omitempty"`
+	Version      string    `json:"version,omitempty"`
+}
+
+// Group is a SCIM Group resource
+type Group struct {
+	Schemas     []string     `json:"schemas,omitempty"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []MultiValue `json:"members,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+// ListResponse is a SCIM ListResponse message
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas,omitempty"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// PatchOperation is a single operation of a SCIM PatchOp message
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// PatchOp is a SCIM PatchOp message
+type PatchOp struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// ErrorResponse is a SCIM Error message
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas,omitempty"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// MarshalJSON encodes the user with its custom attributes under the extension schema
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	data, err := json.Marshal(plain(u))
+	if err != nil || len(u.Extension) == 0 {
+		return data, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for schema, value := range u.extensions() {
+		fields[schema] = value
+	}
+	return json.Marshal(fields)
+}
+
+// extensions groups the extension attributes by schema URN. Attribute keys are stored as
+// <schema>:<name>.
+func (u User) extensions() map[string]map[string]string {
+	extensions := map[string]map[string]string{}
+	for key, value := range u.Extension {
+		i := strings.LastIndex(key, ":")
+		schema, name := key[:i], key[i+1:]
+		if extensions[schema] == nil {
+			extensions[schema] = map[string]string{}
+		}
+		extensions[schema][name] = value
+	}
+	return extensions
+}
+
+// UnmarshalJSON decodes the user, collecting string attributes of extension schemas
+func (u *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	if err := json.Unmarshal(data, (*plain)(u)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for schema, raw := range fields {
+		if !strings.HasPrefix(schema, "urn:") || schema == UserSchema {
+			continue
+		}
+		var attributes map[string]any
+		if json.Unmarshal(raw, &attributes) != nil {
+			continue
+		}
+		for name, value := range attributes {
+			if s, ok := value.(string); ok {
+				if u.Extension == nil {
+					u.Extension = map[string]string{}
+				}
+				u.Extension[schema+":"+name] = s
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance provides a test suite that checks a userpool.Client implementation against
+// the semantics the controller relies on. Backends run it from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, func(t *testing.T) userpool.Client {
+//			return newTestClient(t)
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// listUsersCount is the number of users created to exercise pagination. It exceeds the page size of
+// every backend.
+const listUsersCount = 250
+
+// Factory returns a client for an empty user pool. It is called once per test.
+type Factory func(t *testing.T) userpool.Client
+
+// Run runs the conformance suite against the clients returned by newClient
+func Run(t *testing.T, newClient Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, client userpool.Client)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateExisting", testCreateExisting},
+		{"CreateDisabled", testCreateDisabled},
+		{"GetMissing", testGetMissing},
+		{"Update", testUpdate},
+		{"DeleteAttributes", testDeleteAttributes},
+		{"EnableDisable", testEnableDisable},
+		{"Delete", testDelete},
+		{"DeleteMissing", testDeleteMissing},
+		{"ListUsers", testListUsers},
+		{"Groups", testGroups},
+		{"ContextCancellation", testContextCancellation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newClient(t))
+		})
+	}
+}
+
+// newUser returns a user as the controller creates it
+func newUser(email string) *userpool.User {
+	return &userpool.User{
+		Username: email,
+		Email:    email,
+		Enabled:  true,
+		Attributes: map[string]string{
+			userpool.AttributeGivenName:  "Jane",
+			userpool.AttributeFamilyName: "Doe",
+			userpool.OwnerAttribute:      "root:org:team/default/jane",
+			userpool.OwnerUIDAttribute:   "6f1c2a3e-0d4b-4f8a-9c1e-2b3d4e5f6a7b",
+			userpool.InstanceAttribute:   "kcp-users-controller",
+		},
+	}
+}
+
+// createUser creates a user and fails the test on error
+func createUser(t *testing.T, client userpool.Client, user *userpool.User) *userpool.User {
+	t.Helper()
+	created, err := client.CreateUser(context.Background(), user)
+	require.NoError(t, err)
+	require.NotNil(t, created)
+	require.NotEmpty(t, created.Sub, "created users must have a sub")
+	return created
+}
+
+// getUser gets a user and fails the test on error
+func getUser(t *testing.T, client userpool.Client, identifier string) *userpool.User {
+	t.Helper()
+	user, err := client.GetUser(context.Background(), identifier)
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	return user
+}
+
+func testCreateAndGet(t *testing.T, client userpool.Client) {
+	created := createUser(t, client, newUser("jane@example.com"))
+	assert.Equal(t, "jane@example.com", created.Email)
+	assert.True(t, created.Enabled)
+
+	bySub := getUser(t, client, created.Sub)
+	assert.Equal(t, created.Sub, bySub.Sub)
+	assert.Equal(t, "jane@example.com", bySub.Email)
+	assert.True(t, bySub.Enabled)
+	assert.Equal(t, "Jane", bySub.Attributes[userpool.AttributeGivenName])
+	assert.Equal(t, "Doe", bySub.Attributes[userpool.AttributeFamilyName])
+	assert.Equal(t, "root:org:team/default/jane", bySub.Attributes[userpool.OwnerAttribute],
+		"the ownership marker must survive a round-trip")
+	assert.Equal(t, "6f1c2a3e-0d4b-4f8a-9c1e-2b3d4e5f6a7b", bySub.Attributes[userpool.OwnerUIDAttribute])
+	assert.Equal(t, "kcp-users-controller", bySub.Attributes[userpool.InstanceAttribute])
+
+	// Adoption looks up existing users by email
+	byEmail := getUser(t, client, "jane@example.com")
+	assert.Equal(t, created.Sub, byEmail.Sub)
+}
+
+func testCreateExisting(t *testing.T, client userpool.Client) {
+	created := createUser(t, client, newUser("jane@example.com"))
+
+	_, err := client.CreateUser(context.Background(), newUser("jane@example.com"))
+
+	require.ErrorIs(t, err, userpool.ErrUserExists)
+	users, err := client.ListUsers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, created.Sub, users[0].Sub)
+}
+
+func testCreateDisabled(t *testing.T, client userpool.Client) {
+	user := newUser("jane@example.com")
+	user.Enabled = false
+
+	created := createUser(t, client, user)
+
+	assert.False(t, getUser(t, client, created.Sub).Enabled)
+}
+
+func testGetMissing(t *testing.T, client userpool.Client) {
+	_, err := client.GetUser(context.Background(), "nobody@example.com")
+
+	assert.ErrorIs(t, err, userpool.ErrUserNotFound)
+}
+
+func testUpdate(t *testing.T, client userpool.Client) {
+	created := createUser(t, client, newUser("jane@example.com"))
+
+	update := newUser("jane.doe@example.com")
+	update.Sub = created.Sub
+	update.Attributes[userpool.AttributeGivenName] = "Janet"
+	update.Attributes[userpool.AttributeLocale] = "pl-PL"
+	require.NoError(t, client.UpdateUser(context.Background(), update))
+
+	user := getUser(t, client, created.Sub)
+	assert.Equal(t, created.Sub, user.Sub, "updates must not change the sub")
+	assert.Equal(t, "jane.doe@example.com", user.Email)
+	assert.Equal(t, "Janet", user.Attributes[userpool.AttributeGivenName])
+	assert.Equal(t, "Doe", user.Attributes[userpool.AttributeFamilyName])
+	assert.Equal(t, "pl-PL", user.Attributes[userpool.AttributeLocale])
+	assert.Equal(t, "root:org:team/default/jane", user.Attributes[userpool.OwnerAttribute])
+}
+
+func testDeleteAttributes(t *testing.T, client userpool.Client) {
+	created := createUser(t, client, newUser("jane@example.com"))
+
+	require.NoError(t, client.DeleteUserAttributes(context.Background(), created.Sub,
+		[]string{userpool.AttributeFamilyName}))
+
+	user := getUser(t, client, created.Sub)
+	assert.Empty(t, user.Attributes[userpool.AttributeFamilyName])
+	assert.Equal(t, "Jane", user.Attributes[userpool.AttributeGivenName], "other attributes must be kept")
+}
+
+func testEnableDisable(t *testing.T, client userpool.Client) {
+	ctx := context.Background()
+	created := createUser(t, client, newUser("jane@example.com"))
+
+	require.NoError(t, client.DisableUser(ctx, created.Sub))
+	assert.False(t, getUser(t, client, created.Sub).Enabled, "DisableUser must disable the user")
+
+	update := newUser("jane@example.com")
+	update.Sub = created.Sub
+	require.NoError(t, client.UpdateUser(ctx, update))
+	assert.True(t, getUser(t, client, created.Sub).Enabled, "UpdateUser must enable the user")
+
+	update.Enabled = false
+	require.NoError(t, client.UpdateUser(ctx, update))
+	assert.False(t, getUser(t, client, created.Sub).Enabled, "UpdateUser must disable the user")
+
+	require.NoError(t, client.DisableUser(ctx, created.Sub), "disabling a disabled user is not an error")
+}
+
+func testDelete(t *testing.T, client userpool.Client) {
+	ctx := context.Background()
+	created := createUser(t, client, newUser("jane@example.com"))
+	other := createUser(t, client, newUser("john@example.com"))
+
+	require.NoError(t, client.DeleteUser(ctx, created.Sub))
+
+	_, err := client.GetUser(ctx, created.Sub)
+	assert.ErrorIs(t, err, userpool.ErrUserNotFound, "deleted users must not be found")
+	users, err := client.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, other.Sub, users[0].Sub)
+
+	// The email can be used again
+	createUser(t, client, newUser("jane@example.com"))
+}
+
+func testDeleteMissing(t *testing.T, client userpool.Client) {
+	ctx := context.Background()
+	created := createUser(t, client, newUser("jane@example.com"))
+	require.NoError(t, client.DeleteUser(ctx, created.Sub))
+
+	assert.NoError(t, client.DeleteUser(ctx, created.Sub), "deleting a deleted user is not an error")
+	assert.NoError(t, client.DeleteUser(ctx, "nobody@example.com"), "deleting an unknown user is not an error")
+}
+
+func testListUsers(t *testing.T, client userpool.Client) {
+	users, err := client.ListUsers(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	subs := map[string]string{}
+	for i := range listUsersCount {
+		email := fmt.Sprintf("user-%03d@example.com", i)
+		subs[email] = createUser(t, client, newUser(email)).Sub
+	}
+
+	users, err = client.ListUsers(context.Background())
+	require.NoError(t, err)
+	listed := map[string]string{}
+	for _, user := range users {
+		_, duplicate := listed[user.Email]
+		assert.False(t, duplicate, "user %s is listed more than once", user.Email)
+		listed[user.Email] = user.Sub
+	}
+	assert.Equal(t, subs, listed)
+}
+
+func testGroups(t *testing.T, client userpool.Client) {
+	ctx := context.Background()
+	created := createUser(t, client, newUser("jane@example.com"))
+
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins", Description: "Administrators"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "developers"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "developers", Description: "Developers"}),
+		"creating an existing group updates it")
+
+	group, err := client.GetGroup(ctx, "developers")
+	require.NoError(t, err)
+	assert.Equal(t, "developers", group.Name)
+	assert.Empty(t, group.Owner)
+	owned := &userpool.Group{Name: "developers", Description: "Developers", Owner: "root:org/default/developers"}
+	require.NoError(t, client.CreateGroup(ctx, owned))
+	group, err = client.GetGroup(ctx, "developers")
+	require.NoError(t, err)
+	assert.Equal(t, owned.Owner, group.Owner, "the owner of the group is recorded")
+	_, err = client.GetGroup(ctx, "auditors")
+	assert.ErrorIs(t, err, userpool.ErrGroupNotFound)
+
+	groups, err := client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	require.NoError(t, client.AddUserToGroup(ctx, created.Sub, "developers"))
+	require.NoError(t, client.AddUserToGroup(ctx, created.Sub, "admins"))
+	groups, err = client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"admins", "developers"}, groups)
+
+	require.NoError(t, client.RemoveUserFromGroup(ctx, created.Sub, "admins"))
+	groups, err = client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"developers"}, groups)
+
+	require.NoError(t, client.DeleteGroup(ctx, "developers"))
+	require.NoError(t, client.DeleteGroup(ctx, "developers"), "deleting a missing group is not an error")
+	groups, err = client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func testContextCancellation(t *testing.T, client userpool.Client) {
+	created := createUser(t, client, newUser("jane@example.com"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.CreateUser(ctx, newUser("john@example.com"))
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = client.GetUser(ctx, created.Sub)
+	assert.ErrorIs(t, err, context.Canceled)
+	update := newUser("john@example.com")
+	update.Sub = created.Sub
+	assert.ErrorIs(t, client.UpdateUser(ctx, update), context.Canceled)
+	assert.ErrorIs(t, client.DeleteUser(ctx, created.Sub), context.Canceled)
+	_, err = client.ListUsers(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Cancelled calls must not change the user pool
+	users, err := client.ListUsers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "jane@example.com", users[0].Email)
+	assert.True(t, users[0].Enabled)
+}
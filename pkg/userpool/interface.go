@@ -18,30 +18,174 @@ package userpool
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 )
 
+// Standard attribute names managed through the User spec
+const (
+	AttributeGivenName         = "given_name"
+	AttributeFamilyName        = "family_name"
+	AttributePhoneNumber       = "phone_number"
+	AttributeLocale            = "locale"
+	AttributePreferredUsername = "preferred_username"
+
+	// CustomAttributePrefix is the prefix of custom attributes in the user pool
+	CustomAttributePrefix = "custom:"
+
+	// ReservedAttributePrefix is the prefix of custom attributes maintained by the controller itself.
+	// They cannot be set through the User spec and are never removed as stale.
+	ReservedAttributePrefix = CustomAttributePrefix + "kcp_"
+
+	// OwnerAttribute records which User resource owns the identity, as <cluster>/<namespace>/<name>
+	OwnerAttribute = ReservedAttributePrefix + "owner"
+
+	// OwnerUIDAttribute records the UID of the User resource that owns the identity, telling apart
+	// Users recreated under the same name
+	OwnerUIDAttribute = ReservedAttributePrefix + "owner_uid"
+
+	// InstanceAttribute records the ID of the controller instance managing the identity, so that
+	// several controllers can share a user pool
+	InstanceAttribute = ReservedAttributePrefix + "instance"
+)
+
+// OwnershipAttributes lists the attributes the controller stamps on the identities it manages.
+// They must be declared in the user pool schema.
+var OwnershipAttributes = []string{OwnerAttribute, OwnerUIDAttribute, InstanceAttribute}
+
+// ErrUserExists is returned by CreateUser when a user with the same username already exists
+var ErrUserExists = errors.New("user already exists")
+
+// ErrUserNotFound is returned by GetUser when no user matches the sub or email
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrGroupNotFound is returned by GetGroup when no group has the name
+var ErrGroupNotFound = errors.New("group not found")
+
+// ErrCredentials is returned when a client cannot obtain credentials for the user pool, for
+// example because a role cannot be assumed
+var ErrCredentials = errors.New("failed to obtain user pool credentials")
+
+// standardAttributes lists the standard attributes managed by the controller
+var standardAttributes = map[string]bool{
+	AttributeGivenName:         true,
+	AttributeFamilyName:        true,
+	AttributePhoneNumber:       true,
+	AttributeLocale:            true,
+	AttributePreferredUsername: true,
+}
+
+// IsManagedAttribute reports whether the attribute is owned by the User spec,
+// meaning it may be removed from the pool when it is dropped from the spec
+func IsManagedAttribute(name string) bool {
+	if strings.HasPrefix(name, ReservedAttributePrefix) {
+		return false
+	}
+	return standardAttributes[name] || strings.HasPrefix(name, CustomAttributePrefix)
+}
+
 // User represents a user in a user pool
 type User struct {
 	Username string
 	Email    string
 	Enabled  bool
 	Sub      string // The unique identifier (subject) of the user in the pool
+
+	// Attributes holds additional user attributes keyed by their pool name,
+	// e.g. given_name or custom:department
+	Attributes map[string]string
+
+	// PasswordChangeRequired reports that the user has not yet replaced a temporary password
+	PasswordChangeRequired bool
+
+	// Status is the account status reported by the user pool, e.g. CONFIRMED or FORCE_CHANGE_PASSWORD
+	Status string
+
+	// CreatedAt and LastModifiedAt are the timestamps recorded by the user pool
+	CreatedAt      time.Time
+	LastModifiedAt time.Time
+
+	// MFAMethods lists the MFA methods enabled for the user, e.g. SMS_MFA or SOFTWARE_TOKEN_MFA
+	MFAMethods []string
+
+	// InvitationDeliveryMediums lists the channels (EMAIL, SMS) through which an invitation
+	// is sent when the user is created. No invitation is sent when empty.
+	InvitationDeliveryMediums []string
+}
+
+// Group represents a group in a user pool
+type Group struct {
+	Name        string
+	Description string
+	Precedence  *int32
+
+	// Owner records which Group resource owns the group, as <cluster>/<namespace>/<name>. It is
+	// empty for groups created outside kcp.
+	Owner string
 }
 
-// Client defines the interface for managing users in a user pool
+// Client defines the interface for managing users in a user pool.
+// Users are identified by their sub or their email. Implementations are checked against these
+// semantics by the conformance package.
 type Client interface {
-	// CreateUser creates a new user in the user pool and returns the created user info
+	// CreateUser creates a new user in the user pool and returns the created user info,
+	// disabled unless user.Enabled is set.
+	// It returns an error wrapping ErrUserExists when the user already exists.
 	CreateUser(ctx context.Context, user *User) (*User, error)
 
-	// GetUser retrieves a user from the user pool by username
+	// GetUser retrieves a user from the user pool by sub or email.
+	// It returns an error wrapping ErrUserNotFound when the user does not exist.
 	GetUser(ctx context.Context, username string) (*User, error)
 
-	// UpdateUser updates an existing user in the user pool
+	// UpdateUser updates the email, enabled state and attributes of the user identified by user.Sub,
+	// or user.Email when the sub is unknown. Attributes not in user.Attributes are kept.
 	UpdateUser(ctx context.Context, user *User) error
 
-	// DeleteUser removes a user from the user pool
+	// DeleteUserAttributes removes the named attributes from a user in the user pool
+	DeleteUserAttributes(ctx context.Context, username string, names []string) error
+
+	// ResendInvitation sends the invitation message again to a user that has not signed in yet
+	ResendInvitation(ctx context.Context, username string, deliveryMediums []string) error
+
+	// SetUserPassword sets a user's password. Non-permanent passwords must be changed at next sign-in.
+	SetUserPassword(ctx context.Context, username, password string, permanent bool) error
+
+	// DisableUser disables a user in the user pool, keeping the identity
+	DisableUser(ctx context.Context, username string) error
+
+	// DeleteUser removes a user from the user pool. Deleting a user that does not exist is not an error.
 	DeleteUser(ctx context.Context, username string) error
 
 	// ListUsers lists all users in the user pool
 	ListUsers(ctx context.Context) ([]*User, error)
+
+	// ListSchemaAttributes lists the names of the attributes declared in the user pool schema,
+	// including custom attributes with their custom: prefix
+	ListSchemaAttributes(ctx context.Context) ([]string, error)
+
+	// GetGroup retrieves a group from the user pool by name.
+	// It returns an error wrapping ErrGroupNotFound when the group does not exist.
+	GetGroup(ctx context.Context, name string) (*Group, error)
+
+	// CreateGroup creates a group in the user pool, updating it if it already exists
+	CreateGroup(ctx context.Context, group *Group) error
+
+	// DeleteGroup removes a group from the user pool. Deleting a group that does not exist is not an error.
+	DeleteGroup(ctx context.Context, name string) error
+
+	// AddUserToGroup adds a user to a group in the user pool
+	AddUserToGroup(ctx context.Context, username, group string) error
+
+	// RemoveUserFromGroup removes a user from a group in the user pool
+	RemoveUserFromGroup(ctx context.Context, username, group string) error
+
+	// ListGroupsForUser lists the names of the groups a user belongs to
+	ListGroupsForUser(ctx context.Context, username string) ([]string, error)
+}
+
+// Scoped is implemented by clients of backends that serve several user pools. Scope identifies the
+// user pool of the client, the same for every client of that user pool however it was selected.
+type Scoped interface {
+	Scope() string
 }
@@ -0,0 +1,600 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package memory provides an in-memory user pool for development and tests. It follows the
+// behaviour of a Cognito user pool closely enough to run the controller without AWS credentials.
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+)
+
+// User statuses reported by the in-memory user pool, named after their Cognito counterparts
+const (
+	StatusForceChangePassword = "FORCE_CHANGE_PASSWORD"
+	StatusConfirmed           = "CONFIRMED"
+)
+
+var (
+	// ErrNotFound is returned when a user or group does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrInjected is returned by calls failing because of the configured error rate
+	ErrInjected = errors.New("injected failure")
+)
+
+// Client is an in-memory implementation of userpool.Client. It is safe for concurrent use.
+type Client struct {
+	mu     sync.RWMutex
+	users  map[string]*user // keyed by sub
+	groups map[string]userpool.Group
+	schema map[string]bool
+
+	latency   time.Duration
+	errorRate float64
+	failures  map[string]error
+	now       func() time.Time
+}
+
+// user is a user stored in the pool
+type user struct {
+	userpool.User
+	password    string
+	groups      map[string]bool
+	invitations int
+}
+
+var _ userpool.Client = &Client{}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithLatency delays every call by the given duration
+func WithLatency(latency time.Duration) Option {
+	return func(c *Client) {
+		c.latency = latency
+	}
+}
+
+// WithErrorRate fails the given fraction of calls, between 0 and 1, with ErrInjected
+func WithErrorRate(rate float64) Option {
+	return func(c *Client) {
+		c.errorRate = rate
+	}
+}
+
+// WithCustomAttributes declares custom attributes in the schema, with or without their custom: prefix.
+// Like Cognito, the pool rejects users with undeclared attributes.
+func WithCustomAttributes(names ...string) Option {
+	return func(c *Client) {
+		for _, name := range names {
+			c.schema[userpool.CustomAttributePrefix+strings.TrimPrefix(name, userpool.CustomAttributePrefix)] = true
+		}
+	}
+}
+
+// WithClock replaces the clock used for creation and modification timestamps
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// NewClient creates an empty in-memory user pool
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		users:    map[string]*user{},
+		groups:   map[string]userpool.Group{},
+		failures: map[string]error{},
+		now:      time.Now,
+		schema: map[string]bool{
+			"email":                             true,
+			userpool.AttributeGivenName:         true,
+			userpool.AttributeFamilyName:        true,
+			userpool.AttributePhoneNumber:       true,
+			userpool.AttributeLocale:            true,
+			userpool.AttributePreferredUsername: true,
+			userpool.OwnerAttribute:             true,
+			userpool.OwnerUIDAttribute:          true,
+			userpool.InstanceAttribute:          true,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// InjectError makes every call of the named method, e.g. "CreateUser", fail with err.
+// A nil error clears the failure.
+func (c *Client) InjectError(method string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.failures, method)
+		return
+	}
+	c.failures[method] = err
+}
+
+// CompletePasswordChange simulates the user signing in and replacing the temporary password
+func (c *Client) CompletePasswordChange(identifier, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(identifier)
+	if err != nil {
+		return err
+	}
+	if u.Status != StatusForceChangePassword {
+		return fmt.Errorf("user %s has no temporary password", identifier)
+	}
+	u.password = password
+	u.Status = StatusConfirmed
+	u.PasswordChangeRequired = false
+	u.LastModifiedAt = c.timestamp()
+	return nil
+}
+
+// Invitations returns the number of invitations sent to a user
+func (c *Client) Invitations(identifier string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, err := c.find(identifier)
+	if err != nil {
+		return 0
+	}
+	return u.invitations
+}
+
+// CreateUser creates a new user, using the email as username
+func (c *Client) CreateUser(ctx context.Context, poolUser *userpool.User) (*userpool.User, error) {
+	if err := c.call(ctx, "CreateUser"); err != nil {
+		return nil, err
+	}
+	if poolUser == nil {
+		return nil, fmt.Errorf("user cannot be nil")
+	}
+	if poolUser.Email == "" {
+		return nil, fmt.Errorf("email cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.findByEmail(poolUser.Email); err == nil {
+		return nil, fmt.Errorf("failed to create user %s: %w", poolUser.Email, userpool.ErrUserExists)
+	}
+	if err := c.checkSchema(poolUser.Attributes); err != nil {
+		return nil, fmt.Errorf("failed to create user %s: %w", poolUser.Email, err)
+	}
+
+	now := c.timestamp()
+	u := &user{
+		User: userpool.User{
+			Username:               poolUser.Username,
+			Email:                  poolUser.Email,
+			Enabled:                poolUser.Enabled,
+			Sub:                    uuid.NewString(),
+			Attributes:             maps.Clone(poolUser.Attributes),
+			PasswordChangeRequired: true,
+			Status:                 StatusForceChangePassword,
+			CreatedAt:              now,
+			LastModifiedAt:         now,
+		},
+		groups: map[string]bool{},
+	}
+	if len(poolUser.InvitationDeliveryMediums) > 0 {
+		u.invitations++
+	}
+	c.users[u.Sub] = u
+	return copyUser(u), nil
+}
+
+// GetUser retrieves a user by sub or email
+func (c *Client) GetUser(ctx context.Context, username string) (*userpool.User, error) {
+	if err := c.call(ctx, "GetUser"); err != nil {
+		return nil, err
+	}
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, err := c.find(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	return copyUser(u), nil
+}
+
+// UpdateUser sets the email, attributes and enabled state of the user identified by its sub
+func (c *Client) UpdateUser(ctx context.Context, poolUser *userpool.User) error {
+	if err := c.call(ctx, "UpdateUser"); err != nil {
+		return err
+	}
+	if poolUser == nil {
+		return fmt.Errorf("user cannot be nil")
+	}
+	identifier := poolUser.Sub
+	if identifier == "" {
+		identifier = poolUser.Email
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(identifier)
+	if err != nil {
+		return fmt.Errorf("failed to update user %s: %w", identifier, err)
+	}
+	if other, err := c.findByEmail(poolUser.Email); err == nil && other != u {
+		return fmt.Errorf("failed to update user %s: email %s is already in use", identifier, poolUser.Email)
+	}
+	if err := c.checkSchema(poolUser.Attributes); err != nil {
+		return fmt.Errorf("failed to update user %s: %w", identifier, err)
+	}
+
+	if poolUser.Email != "" {
+		u.Email = poolUser.Email
+	}
+	if u.Attributes == nil {
+		u.Attributes = map[string]string{}
+	}
+	maps.Copy(u.Attributes, poolUser.Attributes)
+	u.Enabled = poolUser.Enabled
+	u.LastModifiedAt = c.timestamp()
+	return nil
+}
+
+// DeleteUserAttributes removes the named attributes from a user
+func (c *Client) DeleteUserAttributes(ctx context.Context, username string, names []string) error {
+	if err := c.call(ctx, "DeleteUserAttributes"); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(username)
+	if err != nil {
+		return fmt.Errorf("failed to delete attributes of user %s: %w", username, err)
+	}
+	for _, name := range names {
+		delete(u.Attributes, name)
+	}
+	u.LastModifiedAt = c.timestamp()
+	return nil
+}
+
+// ResendInvitation counts another invitation for a user that has not replaced its temporary password
+func (c *Client) ResendInvitation(ctx context.Context, username string, deliveryMediums []string) error {
+	if err := c.call(ctx, "ResendInvitation"); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if len(deliveryMediums) == 0 {
+		return fmt.Errorf("at least one delivery medium is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(username)
+	if err != nil {
+		return fmt.Errorf("failed to resend invitation to user %s: %w", username, err)
+	}
+	if u.Status != StatusForceChangePassword {
+		return fmt.Errorf("failed to resend invitation to user %s: user status is %s", username, u.Status)
+	}
+	u.invitations++
+	return nil
+}
+
+// SetUserPassword sets a user's password. Non-permanent passwords must be changed at next sign-in.
+func (c *Client) SetUserPassword(ctx context.Context, username, password string, permanent bool) error {
+	if err := c.call(ctx, "SetUserPassword"); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(username)
+	if err != nil {
+		return fmt.Errorf("failed to set password for user %s: %w", username, err)
+	}
+	u.password = password
+	u.PasswordChangeRequired = !permanent
+	u.Status = StatusForceChangePassword
+	if permanent {
+		u.Status = StatusConfirmed
+	}
+	u.LastModifiedAt = c.timestamp()
+	return nil
+}
+
+// DisableUser disables a user, keeping the identity
+func (c *Client) DisableUser(ctx context.Context, username string) error {
+	if err := c.call(ctx, "DisableUser"); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(username)
+	if err != nil {
+		return fmt.Errorf("failed to disable user %s: %w", username, err)
+	}
+	u.Enabled = false
+	u.LastModifiedAt = c.timestamp()
+	return nil
+}
+
+// DeleteUser removes a user. Deleting a user that does not exist is not an error.
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	if err := c.call(ctx, "DeleteUser"); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if u, err := c.find(username); err == nil {
+		delete(c.users, u.Sub)
+	}
+	return nil
+}
+
+// ListUsers lists all users ordered by creation time
+func (c *Client) ListUsers(ctx context.Context) ([]*userpool.User, error) {
+	if err := c.call(ctx, "ListUsers"); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	users := make([]*userpool.User, 0, len(c.users))
+	for _, u := range c.users {
+		users = append(users, copyUser(u))
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].Email < users[j].Email
+		}
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+	return users, nil
+}
+
+// ListSchemaAttributes lists the standard attributes and the declared custom attributes
+func (c *Client) ListSchemaAttributes(ctx context.Context) ([]string, error) {
+	if err := c.call(ctx, "ListSchemaAttributes"); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.schema)), nil
+}
+
+// GetGroup returns the group with the given name
+func (c *Client) GetGroup(ctx context.Context, name string) (*userpool.Group, error) {
+	if err := c.call(ctx, "GetGroup"); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	group, ok := c.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: %w", userpool.ErrGroupNotFound, name, ErrNotFound)
+	}
+	if group.Precedence != nil {
+		precedence := *group.Precedence
+		group.Precedence = &precedence
+	}
+	return &group, nil
+}
+
+// CreateGroup creates a group, updating it if it already exists
+func (c *Client) CreateGroup(ctx context.Context, group *userpool.Group) error {
+	if err := c.call(ctx, "CreateGroup"); err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("group cannot be nil")
+	}
+	if group.Name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stored := *group
+	if group.Precedence != nil {
+		precedence := *group.Precedence
+		stored.Precedence = &precedence
+	}
+	c.groups[group.Name] = stored
+	return nil
+}
+
+// DeleteGroup removes a group and its memberships. Deleting a group that does not exist is not an error.
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	if err := c.call(ctx, "DeleteGroup"); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.groups, name)
+	for _, u := range c.users {
+		delete(u.groups, name)
+	}
+	return nil
+}
+
+// AddUserToGroup adds a user to an existing group
+func (c *Client) AddUserToGroup(ctx context.Context, username, group string) error {
+	return c.setMembership(ctx, "AddUserToGroup", username, group, true)
+}
+
+// RemoveUserFromGroup removes a user from an existing group
+func (c *Client) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	return c.setMembership(ctx, "RemoveUserFromGroup", username, group, false)
+}
+
+// ListGroupsForUser lists the names of the groups a user belongs to, in alphabetical order
+func (c *Client) ListGroupsForUser(ctx context.Context, username string) ([]string, error) {
+	if err := c.call(ctx, "ListGroupsForUser"); err != nil {
+		return nil, err
+	}
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	u, err := c.find(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups for user %s: %w", username, err)
+	}
+	return slices.Sorted(maps.Keys(u.groups)), nil
+}
+
+// setMembership adds a user to or removes it from a group
+func (c *Client) setMembership(ctx context.Context, method, username, group string, member bool) error {
+	if err := c.call(ctx, method); err != nil {
+		return err
+	}
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if group == "" {
+		return fmt.Errorf("group name cannot be empty")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, err := c.find(username)
+	if err != nil {
+		return fmt.Errorf("failed to change groups of user %s: %w", username, err)
+	}
+	if _, ok := c.groups[group]; !ok {
+		return fmt.Errorf("failed to change groups of user %s: group %s: %w", username, group, ErrNotFound)
+	}
+	if member {
+		u.groups[group] = true
+	} else {
+		delete(u.groups, group)
+	}
+	return nil
+}
+
+// call simulates the latency and failures of a user pool API call
+func (c *Client) call(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.latency > 0 {
+		select {
+		case <-time.After(c.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.mu.RLock()
+	err := c.failures[method]
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if c.errorRate > 0 && rand.Float64() < c.errorRate {
+		return fmt.Errorf("%s: %w", method, ErrInjected)
+	}
+	return nil
+}
+
+// find returns the user with the given sub or email. Callers must hold the lock.
+func (c *Client) find(identifier string) (*user, error) {
+	if u, ok := c.users[identifier]; ok {
+		return u, nil
+	}
+	return c.findByEmail(identifier)
+}
+
+// findByEmail returns the user with the given email, compared case-insensitively like Cognito
+// usernames. Callers must hold the lock.
+func (c *Client) findByEmail(email string) (*user, error) {
+	for _, u := range c.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s: %w", userpool.ErrUserNotFound, email, ErrNotFound)
+}
+
+// checkSchema rejects attributes that are not declared in the schema
+func (c *Client) checkSchema(attributes map[string]string) error {
+	for name := range attributes {
+		if !c.schema[name] {
+			return fmt.Errorf("attribute %s is not declared in the user pool schema", name)
+		}
+	}
+	return nil
+}
+
+// timestamp returns the current time in UTC
+func (c *Client) timestamp() time.Time {
+	return c.now().UTC()
+}
+
+// copyUser returns a copy of the stored user that callers may modify
+func copyUser(u *user) *userpool.User {
+	poolUser := u.User
+	poolUser.Attributes = maps.Clone(u.Attributes)
+	poolUser.MFAMethods = slices.Clone(u.MFAMethods)
+	return &poolUser
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2025 Piotr Janik.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool"
+	"github.com/cogniteo/kcp-users-controller/pkg/userpool/conformance"
+)
+
+func TestClient_Users(t *testing.T) {
+	ctx := context.Background()
+	clock := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := NewClient(WithCustomAttributes("department"), WithClock(func() time.Time { return clock }))
+
+	created, err := client.CreateUser(ctx, &userpool.User{
+		Username:   "jane",
+		Email:      "jane@example.com",
+		Enabled:    true,
+		Attributes: map[string]string{userpool.AttributeGivenName: "Jane", "custom:department": "R&D"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Sub)
+	assert.Equal(t, StatusForceChangePassword, created.Status)
+	assert.True(t, created.PasswordChangeRequired)
+	assert.Equal(t, clock, created.CreatedAt)
+
+	t.Run("duplicate email", func(t *testing.T) {
+		_, err := client.CreateUser(ctx, &userpool.User{Email: "JANE@example.com"})
+		assert.ErrorIs(t, err, userpool.ErrUserExists)
+	})
+
+	t.Run("undeclared attribute", func(t *testing.T) {
+		_, err := client.CreateUser(ctx, &userpool.User{
+			Email:      "john@example.com",
+			Attributes: map[string]string{"custom:team": "a"},
+		})
+		assert.ErrorContains(t, err, "custom:team is not declared")
+	})
+
+	t.Run("get by sub and email", func(t *testing.T) {
+		bySub, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		byEmail, err := client.GetUser(ctx, "Jane@Example.com")
+		require.NoError(t, err)
+		assert.Equal(t, bySub, byEmail)
+		assert.Equal(t, "Jane", bySub.Attributes[userpool.AttributeGivenName])
+
+		_, err = client.GetUser(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("returned users are copies", func(t *testing.T) {
+		user, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		user.Attributes[userpool.AttributeGivenName] = "changed"
+
+		user, err = client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "Jane", user.Attributes[userpool.AttributeGivenName])
+	})
+
+	t.Run("update and delete attributes", func(t *testing.T) {
+		clock = clock.Add(time.Hour)
+		require.NoError(t, client.UpdateUser(ctx, &userpool.User{
+			Sub:        created.Sub,
+			Email:      "jane.doe@example.com",
+			Enabled:    false,
+			Attributes: map[string]string{userpool.AttributeFamilyName: "Doe"},
+		}))
+		require.NoError(t, client.DeleteUserAttributes(ctx, created.Sub, []string{"custom:department"}))
+
+		user, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", user.Email)
+		assert.False(t, user.Enabled)
+		assert.Equal(t, map[string]string{
+			userpool.AttributeGivenName:  "Jane",
+			userpool.AttributeFamilyName: "Doe",
+		}, user.Attributes)
+		assert.Equal(t, clock, user.LastModifiedAt)
+	})
+
+	t.Run("passwords", func(t *testing.T) {
+		require.NoError(t, client.SetUserPassword(ctx, created.Sub, "temporary", false))
+		user, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, StatusForceChangePassword, user.Status)
+
+		require.NoError(t, client.CompletePasswordChange(created.Sub, "chosen"))
+		user, err = client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.Equal(t, StatusConfirmed, user.Status)
+		assert.False(t, user.PasswordChangeRequired)
+
+		assert.Error(t, client.ResendInvitation(ctx, created.Sub, []string{"EMAIL"}),
+			"confirmed users cannot be invited again")
+	})
+
+	t.Run("disable and delete", func(t *testing.T) {
+		require.NoError(t, client.UpdateUser(ctx, &userpool.User{Sub: created.Sub, Email: "jane.doe@example.com", Enabled: true}))
+		require.NoError(t, client.DisableUser(ctx, created.Sub))
+		user, err := client.GetUser(ctx, created.Sub)
+		require.NoError(t, err)
+		assert.False(t, user.Enabled)
+
+		require.NoError(t, client.DeleteUser(ctx, created.Sub))
+		require.NoError(t, client.DeleteUser(ctx, created.Sub), "deleting a missing user is not an error")
+		users, err := client.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
+
+func TestClient_Invitations(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient()
+
+	created, err := client.CreateUser(ctx, &userpool.User{
+		Email:                     "jane@example.com",
+		InvitationDeliveryMediums: []string{"EMAIL"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.ResendInvitation(ctx, created.Sub, []string{"EMAIL"}))
+
+	assert.Equal(t, 2, client.Invitations("jane@example.com"))
+	assert.Equal(t, 0, client.Invitations("nobody@example.com"))
+}
+
+func TestClient_Groups(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient()
+	created, err := client.CreateUser(ctx, &userpool.User{Email: "jane@example.com"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, client.AddUserToGroup(ctx, created.Sub, "admins"), ErrNotFound,
+		"groups must exist before users join them")
+
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "admins"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "developers", Description: "Developers"}))
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "developers", Description: "Updated"}))
+	require.NoError(t, client.AddUserToGroup(ctx, created.Sub, "developers"))
+	require.NoError(t, client.AddUserToGroup(ctx, "jane@example.com", "admins"))
+
+	groups, err := client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admins", "developers"}, groups)
+
+	require.NoError(t, client.RemoveUserFromGroup(ctx, created.Sub, "admins"))
+	require.NoError(t, client.DeleteGroup(ctx, "developers"))
+	require.NoError(t, client.DeleteGroup(ctx, "developers"))
+
+	groups, err = client.ListGroupsForUser(ctx, created.Sub)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestClient_Schema(t *testing.T) {
+	client := NewClient(WithCustomAttributes("custom:department", "team"))
+
+	names, err := client.ListSchemaAttributes(context.Background())
+
+	require.NoError(t, err)
+	assert.Contains(t, names, "custom:department")
+	assert.Contains(t, names, "custom:team")
+	assert.Contains(t, names, userpool.AttributeGivenName)
+	assert.Contains(t, names, userpool.OwnerAttribute)
+}
+
+func TestClient_Failures(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("injected error", func(t *testing.T) {
+		client := NewClient()
+		throttled := errors.New("TooManyRequestsException")
+		client.InjectError("CreateUser", throttled)
+
+		_, err := client.CreateUser(ctx, &userpool.User{Email: "jane@example.com"})
+		assert.ErrorIs(t, err, throttled)
+
+		client.InjectError("CreateUser", nil)
+		_, err = client.CreateUser(ctx, &userpool.User{Email: "jane@example.com"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("error rate", func(t *testing.T) {
+		client := NewClient(WithErrorRate(1))
+
+		_, err := client.ListUsers(ctx)
+		assert.ErrorIs(t, err, ErrInjected)
+	})
+
+	t.Run("latency honours context", func(t *testing.T) {
+		client := NewClient(WithLatency(time.Hour))
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := client.ListUsers(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestClient_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	client := NewClient()
+	require.NoError(t, client.CreateGroup(ctx, &userpool.Group{Name: "team"}))
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			created, err := client.CreateUser(ctx, &userpool.User{Email: fmt.Sprintf("user-%d@example.com", i)})
+			assert.NoError(t, err)
+			assert.NoError(t, client.AddUserToGroup(ctx, created.Sub, "team"))
+			_, err = client.ListUsers(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	users, err := client.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 20)
+}
+
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) userpool.Client {
+		return NewClient()
+	})
+}